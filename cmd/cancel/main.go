@@ -0,0 +1,124 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+
+	"cli-tools/internal/auth"
+	"cli-tools/internal/cli"
+	"cli-tools/internal/git"
+	"cli-tools/internal/github"
+	"cli-tools/internal/picker"
+	"cli-tools/internal/prompt"
+	"cli-tools/internal/runs"
+)
+
+func main() {
+	ctx, cancel := cli.Init()
+	defer cancel()
+
+	fs := flag.NewFlagSet("cancel", flag.ExitOnError)
+	fs.Usage = func() {
+		fmt.Fprintln(os.Stderr, "Usage: cancel [run-id|url]")
+		fmt.Fprintln(os.Stderr, "Cancels a queued or in-progress GitHub Actions workflow run.")
+		fmt.Fprintln(os.Stderr, "Without a run ID, cancels the run in progress for the commit you have checked out, or lets you pick one.")
+		fmt.Fprintln(os.Stderr, "Examples:")
+		fmt.Fprintln(os.Stderr, "  cancel")
+		fmt.Fprintln(os.Stderr, "  cancel 1234567890")
+	}
+	args := cli.Parse(fs)
+
+	if !git.IsInsideRepo(ctx) {
+		fmt.Fprintln(os.Stderr, "Error: not inside a git repository")
+		os.Exit(1)
+	}
+	if len(args) > 1 {
+		fs.Usage()
+		os.Exit(1)
+	}
+
+	arg := ""
+	if len(args) == 1 {
+		arg = args[0]
+	}
+	if err := cancelRun(ctx, arg); err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+}
+
+// cancelRun cancels the run given as arg, or one in progress for HEAD
+func cancelRun(ctx context.Context, arg string) error {
+	var repo string
+	var run *auth.WorkflowRun
+	if arg != "" {
+		var id int64
+		var err error
+		if repo, id, err = runs.Parse(ctx, arg); err != nil {
+			return err
+		}
+		if run, err = auth.GetRun(ctx, repo, id); err != nil {
+			return err
+		}
+	} else {
+		var err error
+		if repo, err = github.GetOwnerRepo(ctx); err != nil {
+			return err
+		}
+		if run, err = pickActive(ctx, repo); err != nil {
+			return err
+		}
+	}
+
+	if run.Status == "completed" {
+		return fmt.Errorf("%s has already completed", runs.Describe(run))
+	}
+	if err := auth.CancelRun(ctx, repo, run.ID); err != nil {
+		return err
+	}
+	fmt.Printf("Cancelling %s: %s\n", runs.Describe(run), run.HTMLURL)
+	return nil
+}
+
+// pickActive returns the run in progress for HEAD, or lets the user pick
+// one if there are several
+func pickActive(ctx context.Context, repo string) (*auth.WorkflowRun, error) {
+	list, sha, err := runs.ForHead(ctx, repo)
+	if err != nil {
+		return nil, err
+	}
+	var active []auth.WorkflowRun
+	for _, r := range list {
+		if r.Status != "completed" {
+			active = append(active, r)
+		}
+	}
+
+	switch len(active) {
+	case 0:
+		return nil, fmt.Errorf("no runs in progress for commit %s", runs.ShortSHA(sha))
+	case 1:
+		return &active[0], nil
+	}
+
+	items := make([]string, len(active))
+	for i, r := range active {
+		items[i] = fmt.Sprintf("%d  %s  %s", r.ID, r.Name, r.Status)
+	}
+	idx, err := picker.Pick(ctx, "Select a run to cancel", items)
+	if errors.Is(err, prompt.ErrNotInteractive) {
+		descs := make([]string, len(active))
+		for i := range active {
+			descs[i] = runs.Describe(&active[i])
+		}
+		return nil, fmt.Errorf("several runs in progress, pass a run ID: %s", strings.Join(descs, ", "))
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &active[idx], nil
+}
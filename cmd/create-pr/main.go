@@ -5,31 +5,35 @@ import (
 	"os"
 
 	"cli-tools/internal/browser"
+	"cli-tools/internal/cli"
 	"cli-tools/internal/git"
 	"cli-tools/internal/github"
 )
 
 func main() {
-	if !git.IsInsideRepo() {
+	ctx, cancel := cli.Init()
+	defer cancel()
+
+	if !git.IsInsideRepo(ctx) {
 		fmt.Fprintln(os.Stderr, "Error: not inside a git repository")
 		os.Exit(1)
 	}
 
 	// Get current branch
-	branch, err := git.GetCurrentBranch()
+	branch, err := git.GetCurrentBranch(ctx)
 	if err != nil {
 		fmt.Fprintf(os.Stderr, "Error getting current branch: %v\n", err)
 		os.Exit(1)
 	}
 
 	// Check if on default branch
-	defaultBranch, _ := git.GetDefaultBranch()
+	defaultBranch, _ := git.GetDefaultBranch(ctx)
 	if branch == defaultBranch {
 		fmt.Fprintf(os.Stderr, "Error: cannot create PR from %s branch\n", defaultBranch)
 		os.Exit(1)
 	}
 
-	url, err := github.BuildCompareURL(branch)
+	url, err := github.BuildCompareURL(ctx, branch)
 	if err != nil {
 		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
 		os.Exit(1)
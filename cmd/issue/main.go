@@ -5,33 +5,51 @@ import (
 	"os"
 	"strconv"
 
+	"cli-tools/internal/auth"
 	"cli-tools/internal/browser"
+	"cli-tools/internal/cli"
 	"cli-tools/internal/git"
 	"cli-tools/internal/github"
 )
 
 func main() {
-	if !git.IsInsideRepo() {
+	ctx, cancel := cli.Init()
+	defer cancel()
+
+	if !git.IsInsideRepo(ctx) {
 		fmt.Fprintln(os.Stderr, "Error: not inside a git repository")
 		os.Exit(1)
 	}
 
-	if len(os.Args) < 2 {
-		fmt.Fprintln(os.Stderr, "Usage: issue <number>")
-		os.Exit(1)
-	}
+	var url string
 
-	// Validate that the argument is a number
-	issueNum, err := strconv.Atoi(os.Args[1])
-	if err != nil || issueNum <= 0 {
-		fmt.Fprintln(os.Stderr, "Error: issue number must be a positive integer")
-		os.Exit(1)
-	}
+	if len(os.Args) < 2 {
+		// No number: pick one of the open issues
+		ownerRepo, err := github.GetOwnerRepo(ctx)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+		issue, err := auth.PickOpenIssue(ctx, ownerRepo)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			fmt.Fprintln(os.Stderr, "Usage: issue [number]")
+			os.Exit(1)
+		}
+		url = issue.HTMLURL
+	} else {
+		// Validate that the argument is a number
+		issueNum, err := strconv.Atoi(os.Args[1])
+		if err != nil || issueNum <= 0 {
+			fmt.Fprintln(os.Stderr, "Error: issue number must be a positive integer")
+			os.Exit(1)
+		}
 
-	url, err := github.BuildURL(fmt.Sprintf("/issues/%d", issueNum))
-	if err != nil {
-		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
-		os.Exit(1)
+		url, err = github.BuildURL(ctx, fmt.Sprintf("/issues/%d", issueNum))
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
 	}
 
 	if err := browser.Open(url); err != nil {
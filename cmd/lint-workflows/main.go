@@ -0,0 +1,122 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"cli-tools/internal/cli"
+	"cli-tools/internal/format"
+	"cli-tools/internal/git"
+	"cli-tools/internal/workflows"
+	"cli-tools/internal/yaml"
+)
+
+func main() {
+	ctx, cancel := cli.Init()
+	defer cancel()
+
+	fs := flag.NewFlagSet("lint-workflows", flag.ExitOnError)
+	fs.Usage = func() {
+		fmt.Fprintln(os.Stderr, "Usage: lint-workflows [file...]")
+		fmt.Fprintln(os.Stderr, "Checks GitHub Actions workflow files for syntax errors, unknown keys, bad needs,")
+		fmt.Fprintln(os.Stderr, "invalid ${{ }} expressions and matrix mistakes. Without files, checks all of .github/workflows.")
+		fmt.Fprintln(os.Stderr, "Problems are printed as file:line: message; open-file accepts the file:line part.")
+		fmt.Fprintln(os.Stderr, "Examples:")
+		fmt.Fprintln(os.Stderr, "  lint-workflows")
+		fmt.Fprintln(os.Stderr, "  lint-workflows .github/workflows/ci.yml")
+	}
+	files := cli.Parse(fs)
+
+	if !git.IsInsideRepo(ctx) {
+		fmt.Fprintln(os.Stderr, "Error: not inside a git repository")
+		os.Exit(1)
+	}
+
+	if len(files) == 0 {
+		root, err := git.GetRepoRoot(ctx)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+		paths, err := workflows.List(root)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+		if len(paths) == 0 {
+			fmt.Fprintf(os.Stderr, "Error: no workflows found in %s\n", workflows.Dir)
+			os.Exit(1)
+		}
+		// Print paths relative to the current directory, like open-file expects
+		cwd, _ := os.Getwd()
+		for _, path := range paths {
+			abs := filepath.Join(root, path)
+			if rel, err := filepath.Rel(cwd, abs); err == nil {
+				abs = rel
+			}
+			files = append(files, abs)
+		}
+	}
+
+	problems := 0
+	failed := 0
+	skipped := 0
+	for _, file := range files {
+		n, ok, err := lintFile(file)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+		problems += n
+		if n > 0 {
+			failed++
+		}
+		if !ok {
+			skipped++
+		}
+	}
+
+	if problems > 0 {
+		fmt.Fprintf(os.Stderr, "\n%d %s in %d of %d %s\n", problems, format.Plural(problems, "problem", "problems"), failed, len(files), format.Plural(len(files), "file", "files"))
+		os.Exit(1)
+	}
+	checked := len(files) - skipped
+	fmt.Printf("Checked %d workflow %s, no problems found\n", checked, format.Plural(checked, "file", "files"))
+	if skipped > 0 {
+		fmt.Printf("Skipped %d %s using YAML features lint-workflows can't parse\n", skipped, format.Plural(skipped, "file", "files"))
+	}
+}
+
+// lintFile prints the problems in one workflow file and returns how many
+// there are. ok is false if the file uses YAML the parser doesn't support,
+// which is warned about rather than counted as a problem.
+func lintFile(file string) (n int, ok bool, err error) {
+	data, err := os.ReadFile(file)
+	if err != nil {
+		return 0, false, err
+	}
+	file = filepath.ToSlash(file)
+
+	root, err := yaml.Parse(data)
+	var syntaxErr *yaml.Error
+	var unsupported *yaml.UnsupportedError
+	switch {
+	case errors.As(err, &syntaxErr):
+		fmt.Printf("%s:%d: %s\n", file, syntaxErr.Line, syntaxErr.Msg)
+		return 1, true, nil
+	case errors.As(err, &unsupported):
+		fmt.Fprintf(os.Stderr, "Warning: %s:%d: %s are not supported by lint-workflows; skipping the file\n", file, unsupported.Line, unsupported.Feature)
+		return 0, false, nil
+	case err != nil:
+		return 0, false, err
+	}
+
+	problems := workflows.Lint(root)
+	for _, p := range problems {
+		fmt.Printf("%s:%d: %s\n", file, p.Line, p.Msg)
+	}
+	return len(problems), true, nil
+}
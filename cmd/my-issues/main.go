@@ -1,6 +1,7 @@
 package main
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 	"os"
@@ -8,6 +9,8 @@ import (
 	"strings"
 
 	"cli-tools/internal/auth"
+	"cli-tools/internal/cli"
+	"cli-tools/internal/trace"
 )
 
 type Issue struct {
@@ -21,21 +24,24 @@ type Issue struct {
 }
 
 func main() {
-	if auth.HasGhCLI() {
-		showIssuesWithGh()
+	ctx, cancel := cli.Init()
+	defer cancel()
+
+	if auth.HasGhCLI(ctx) {
+		showIssuesWithGh(ctx)
 		return
 	}
 
-	showIssuesWithAPI()
+	showIssuesWithAPI(ctx)
 }
 
-func showIssuesWithGh() {
+func showIssuesWithGh(ctx context.Context) {
 	// Search for issues assigned to the current user
-	cmd := exec.Command("gh", "search", "issues",
+	cmd := exec.CommandContext(ctx, "gh", "search", "issues",
 		"--assignee", "@me",
 		"--state", "open",
 		"--json", "number,title,state,url,repository")
-	out, err := cmd.Output()
+	out, err := trace.Output(cmd)
 	if err != nil {
 		if exitErr, ok := err.(*exec.ExitError); ok {
 			fmt.Fprintln(os.Stderr, strings.TrimSpace(string(exitErr.Stderr)))
@@ -64,9 +70,9 @@ func showIssuesWithGh() {
 	}
 }
 
-func showIssuesWithAPI() {
+func showIssuesWithAPI(ctx context.Context) {
 	// Get current user
-	userData, err := auth.APIRequest("GET", "/user", nil)
+	userData, err := auth.APIRequest(ctx, "GET", "/user", nil)
 	if err != nil {
 		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
 		fmt.Fprintln(os.Stderr, "")
@@ -86,7 +92,7 @@ func showIssuesWithAPI() {
 	query := fmt.Sprintf("is:issue is:open assignee:%s", user.Login)
 	endpoint := fmt.Sprintf("/search/issues?q=%s&sort=updated&per_page=20", strings.ReplaceAll(query, " ", "+"))
 
-	data, err := auth.APIRequest("GET", endpoint, nil)
+	data, err := auth.APIRequest(ctx, "GET", endpoint, nil)
 	if err != nil {
 		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
 		os.Exit(1)
@@ -1,6 +1,7 @@
 package main
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 	"os"
@@ -8,6 +9,8 @@ import (
 	"strings"
 
 	"cli-tools/internal/auth"
+	"cli-tools/internal/cli"
+	"cli-tools/internal/trace"
 )
 
 type PR struct {
@@ -22,28 +25,31 @@ type PR struct {
 }
 
 func main() {
-	if auth.HasGhCLI() {
-		showPRsWithGh()
+	ctx, cancel := cli.Init()
+	defer cancel()
+
+	if auth.HasGhCLI(ctx) {
+		showPRsWithGh(ctx)
 		return
 	}
 
-	showPRsWithAPI()
+	showPRsWithAPI(ctx)
 }
 
-func showPRsWithGh() {
+func showPRsWithGh(ctx context.Context) {
 	// Use gh CLI to search for PRs authored by the current user
-	cmd := exec.Command("gh", "pr", "list",
+	cmd := exec.CommandContext(ctx, "gh", "pr", "list",
 		"--author", "@me",
 		"--state", "open",
 		"--json", "number,title,state,url,repository,createdAt")
-	out, err := cmd.Output()
+	out, err := trace.Output(cmd)
 	if err != nil {
 		// Try without --author flag (some gh versions don't support it in list)
-		cmd = exec.Command("gh", "search", "prs",
+		cmd = exec.CommandContext(ctx, "gh", "search", "prs",
 			"--author", "@me",
 			"--state", "open",
 			"--json", "number,title,state,url,repository")
-		out, err = cmd.Output()
+		out, err = trace.Output(cmd)
 		if err != nil {
 			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
 			os.Exit(1)
@@ -73,9 +79,9 @@ func showPRsWithGh() {
 	}
 }
 
-func showPRsWithAPI() {
+func showPRsWithAPI(ctx context.Context) {
 	// Get current user
-	userData, err := auth.APIRequest("GET", "/user", nil)
+	userData, err := auth.APIRequest(ctx, "GET", "/user", nil)
 	if err != nil {
 		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
 		fmt.Fprintln(os.Stderr, "")
@@ -95,7 +101,7 @@ func showPRsWithAPI() {
 	query := fmt.Sprintf("is:pr is:open author:%s", user.Login)
 	endpoint := fmt.Sprintf("/search/issues?q=%s&sort=updated&per_page=20", strings.ReplaceAll(query, " ", "+"))
 
-	data, err := auth.APIRequest("GET", endpoint, nil)
+	data, err := auth.APIRequest(ctx, "GET", endpoint, nil)
 	if err != nil {
 		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
 		os.Exit(1)
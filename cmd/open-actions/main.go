@@ -5,17 +5,21 @@ import (
 	"os"
 
 	"cli-tools/internal/browser"
+	"cli-tools/internal/cli"
 	"cli-tools/internal/git"
 	"cli-tools/internal/github"
 )
 
 func main() {
-	if !git.IsInsideRepo() {
+	ctx, cancel := cli.Init()
+	defer cancel()
+
+	if !git.IsInsideRepo(ctx) {
 		fmt.Fprintln(os.Stderr, "Error: not inside a git repository")
 		os.Exit(1)
 	}
 
-	url, err := github.BuildURL("/actions")
+	url, err := github.BuildURL(ctx, "/actions")
 	if err != nil {
 		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
 		os.Exit(1)
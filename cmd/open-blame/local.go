@@ -0,0 +1,95 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"time"
+
+	"cli-tools/internal/auth"
+	"cli-tools/internal/git"
+	"cli-tools/internal/github"
+	"cli-tools/internal/runs"
+)
+
+// showLocalBlame prints a terminal blame of filePath (or lines start..end),
+// grouping consecutive lines by commit and annotating each group with the
+// PR the commit came from
+func showLocalBlame(ctx context.Context, filePath string, start, end int, ignoreRevsFile string) error {
+	if ignoreRevsFile == "" {
+		ignoreRevsFile = defaultIgnoreRevsFile(ctx)
+	}
+
+	blame, err := git.Blame(ctx, filePath, start, end, ignoreRevsFile)
+	if err != nil {
+		return err
+	}
+	if len(blame) == 0 {
+		return nil
+	}
+
+	// Look up PRs for every distinct commit in one batched query
+	var shas []string
+	seen := make(map[string]bool)
+	for _, line := range blame {
+		if !line.Uncommitted() && !seen[line.SHA] {
+			seen[line.SHA] = true
+			shas = append(shas, line.SHA)
+		}
+	}
+
+	var prs map[string][]auth.PullRequest
+	ownerRepo, err := github.GetOwnerRepo(ctx)
+	if err == nil {
+		prs, err = auth.FindPRsForCommitsBatch(ctx, ownerRepo, shas)
+	}
+	if err != nil {
+		if ctx.Err() != nil {
+			return err
+		}
+		fmt.Fprintf(os.Stderr, "Warning: showing blame without PRs: %v\n\n", err)
+	}
+
+	width := len(strconv.Itoa(blame[len(blame)-1].Line))
+	now := time.Now()
+	for i, line := range blame {
+		if i == 0 || blame[i-1].SHA != line.SHA {
+			if i > 0 {
+				fmt.Println()
+			}
+			fmt.Println(groupHeader(line, prs[line.SHA], now))
+		}
+		fmt.Printf("  %*d | %s\n", width, line.Line, line.Text)
+	}
+	return nil
+}
+
+// groupHeader describes the commit behind a run of lines:
+// short SHA, author, age, and the PR (or commit summary if there is none)
+func groupHeader(line git.BlameLine, prs []auth.PullRequest, now time.Time) string {
+	if line.Uncommitted() {
+		return "0000000  Not committed yet"
+	}
+
+	what := line.Summary
+	if len(prs) > 0 {
+		what = fmt.Sprintf("#%d %s", prs[0].Number, prs[0].Title)
+	}
+	return fmt.Sprintf("%s  %s  %s  %s", line.SHA[:7], line.Author, runs.Age(line.AuthorTime.Format(time.RFC3339), now), what)
+}
+
+// defaultIgnoreRevsFile returns .git-blame-ignore-revs at the repo root if it
+// exists, matching GitHub's blame view
+func defaultIgnoreRevsFile(ctx context.Context) string {
+	root, err := git.GetRepoRoot(ctx)
+	if err != nil {
+		return ""
+	}
+	path := filepath.Join(root, ".git-blame-ignore-revs")
+	if _, err := os.Stat(path); err != nil {
+		return ""
+	}
+	return path
+}
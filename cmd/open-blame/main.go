@@ -1,31 +1,58 @@
 package main
 
 import (
+	"context"
+	"errors"
+	"flag"
 	"fmt"
 	"os"
-	"strconv"
-	"strings"
 
+	"cli-tools/internal/auth"
 	"cli-tools/internal/browser"
+	"cli-tools/internal/cli"
+	"cli-tools/internal/fileref"
 	"cli-tools/internal/git"
 	"cli-tools/internal/github"
 )
 
 func main() {
-	if !git.IsInsideRepo() {
-		fmt.Fprintln(os.Stderr, "Error: not inside a git repository")
-		os.Exit(1)
-	}
+	ctx, cancel := cli.Init()
+	defer cancel()
 
-	if len(os.Args) < 2 {
-		fmt.Fprintln(os.Stderr, "Usage: open-blame <file[:line]>")
+	fs := flag.NewFlagSet("open-blame", flag.ExitOnError)
+	openPR := fs.Bool("pr", false, "open the PR that last touched the line instead")
+	local := fs.Bool("local", false, "print the blame in the terminal, annotated with PRs")
+	ignoreRevsFile := fs.String("ignore-revs-file", "", "with --local, skip commits listed in this file (default: .git-blame-ignore-revs if present)")
+	fs.Usage = func() {
+		fmt.Fprintln(os.Stderr, "Usage: open-blame <file[:line[-end]]> [--pr | --local [--ignore-revs-file <file>]]")
 		fmt.Fprintln(os.Stderr, "Examples:")
 		fmt.Fprintln(os.Stderr, "  open-blame main.go")
 		fmt.Fprintln(os.Stderr, "  open-blame main.go:42")
+		fmt.Fprintln(os.Stderr, "  open-blame main.go:42 --pr")
+		fmt.Fprintln(os.Stderr, "  open-blame main.go:40-60 --local")
+	}
+	args := cli.Parse(fs)
+
+	if !git.IsInsideRepo(ctx) {
+		fmt.Fprintln(os.Stderr, "Error: not inside a git repository")
 		os.Exit(1)
 	}
 
-	filePath, line := parseFileArg(os.Args[1])
+	if len(args) < 1 {
+		fs.Usage()
+		os.Exit(1)
+	}
+
+	if *local && *openPR {
+		fmt.Fprintln(os.Stderr, "Error: --local can't be combined with --pr")
+		os.Exit(1)
+	}
+	if *ignoreRevsFile != "" && !*local {
+		fmt.Fprintln(os.Stderr, "Error: --ignore-revs-file only works with --local")
+		os.Exit(1)
+	}
+
+	filePath, line, end := fileref.Parse(args[0])
 
 	// Check if file exists
 	if _, err := os.Stat(filePath); os.IsNotExist(err) {
@@ -33,7 +60,27 @@ func main() {
 		os.Exit(1)
 	}
 
-	url, err := github.BuildBlameURL(filePath, line, "")
+	if *local {
+		if err := showLocalBlame(ctx, filePath, line, end, *ignoreRevsFile); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+		return
+	}
+
+	if *openPR {
+		if line == 0 {
+			fmt.Fprintln(os.Stderr, "Error: --pr needs a line number (e.g. main.go:42)")
+			os.Exit(1)
+		}
+		if err := openLinePR(ctx, filePath, line); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+		return
+	}
+
+	url, err := github.BuildBlameURL(ctx, filePath, line, "")
 	if err != nil {
 		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
 		os.Exit(1)
@@ -45,14 +92,36 @@ func main() {
 	}
 }
 
-// parseFileArg parses "file:line" format, returns file path and line number
-func parseFileArg(arg string) (string, int) {
-	// Check for file:line format
-	if idx := strings.LastIndex(arg, ":"); idx != -1 {
-		lineStr := arg[idx+1:]
-		if line, err := strconv.Atoi(lineStr); err == nil && line > 0 {
-			return arg[:idx], line
-		}
+// openLinePR opens the PR that introduced the last change to a line
+func openLinePR(ctx context.Context, filePath string, line int) error {
+	blame, err := git.Blame(ctx, filePath, line, line, "")
+	if err != nil {
+		return err
+	}
+	if len(blame) == 0 {
+		return fmt.Errorf("no blame information for %s:%d", filePath, line)
+	}
+	if blame[0].Uncommitted() {
+		return fmt.Errorf("%s:%d has uncommitted changes", filePath, line)
+	}
+
+	prs, err := auth.FindPRsForCommit(ctx, blame[0].SHA)
+	if errors.Is(err, auth.ErrCommitNotFound) {
+		return fmt.Errorf("commit %s (%s) hasn't been pushed to GitHub", blame[0].SHA[:7], blame[0].Summary)
+	}
+	if err != nil {
+		return err
+	}
+	pr, err := auth.ChoosePR(ctx, auth.IntroducedBy(prs))
+	if err != nil {
+		return err
+	}
+	if pr == nil {
+		return fmt.Errorf("commit %s (%s) has no PR", blame[0].SHA[:7], blame[0].Summary)
+	}
+
+	if err := browser.Open(pr.HTMLURL); err != nil {
+		return fmt.Errorf("opening browser: %w", err)
 	}
-	return arg, 0
+	return nil
 }
@@ -7,12 +7,16 @@ import (
 	"strings"
 
 	"cli-tools/internal/browser"
+	"cli-tools/internal/cli"
 	"cli-tools/internal/git"
 	"cli-tools/internal/github"
 )
 
 func main() {
-	if !git.IsInsideRepo() {
+	ctx, cancel := cli.Init()
+	defer cancel()
+
+	if !git.IsInsideRepo(ctx) {
 		fmt.Fprintln(os.Stderr, "Error: not inside a git repository")
 		os.Exit(1)
 	}
@@ -33,7 +37,7 @@ func main() {
 		os.Exit(1)
 	}
 
-	url, err := github.BuildFileURL(filePath, line, "")
+	url, err := github.BuildFileURL(ctx, filePath, line, "")
 	if err != nil {
 		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
 		os.Exit(1)
@@ -1,37 +1,125 @@
 package main
 
 import (
+	"context"
+	"flag"
 	"fmt"
 	"os"
-	"os/exec"
+	"regexp"
+	"strconv"
+	"strings"
 
 	"cli-tools/internal/auth"
+	"cli-tools/internal/browser"
+	"cli-tools/internal/cli"
 	"cli-tools/internal/git"
+	"cli-tools/internal/github"
 )
 
+var shaRegex = regexp.MustCompile(`^[0-9a-fA-F]{7,40}$`)
+
 func main() {
-	if !git.IsInsideRepo() {
+	ctx, cancel := cli.Init()
+	defer cancel()
+
+	fs := flag.NewFlagSet("open-pr", flag.ExitOnError)
+	files := fs.Bool("files", false, "open the Files changed tab")
+	commits := fs.Bool("commits", false, "open the Commits tab")
+	checks := fs.Bool("checks", false, "open the Checks tab")
+	fs.Usage = func() {
+		fmt.Fprintln(os.Stderr, "Usage: open-pr [number|branch|commit] [--files|--commits|--checks]")
+		fmt.Fprintln(os.Stderr, "An all-digit argument is taken as a commit if one matches, else as a PR number; #123 is always a PR.")
+		fmt.Fprintln(os.Stderr, "Examples:")
+		fmt.Fprintln(os.Stderr, "  open-pr                 # PR for the current branch")
+		fmt.Fprintln(os.Stderr, "  open-pr 123 --files     # Files tab of PR #123")
+		fmt.Fprintln(os.Stderr, "  open-pr feature/login   # PR for another branch")
+		fmt.Fprintln(os.Stderr, "  open-pr 1a2b3c4         # PR that introduced a commit")
+		fmt.Fprintln(os.Stderr, "  open-pr '#1234567'      # PR #1234567, even if a commit starts with 1234567")
+	}
+	args := cli.Parse(fs)
+
+	if !git.IsInsideRepo(ctx) {
 		fmt.Fprintln(os.Stderr, "Error: not inside a git repository")
 		os.Exit(1)
 	}
 
-	// Use gh CLI if available (handles the web opening itself)
-	if auth.HasGhCLI() {
-		cmd := exec.Command("gh", "pr", "view", "--web")
-		cmd.Stdout = os.Stdout
-		cmd.Stderr = os.Stderr
-		if err := cmd.Run(); err != nil {
-			// gh will print its own error message (e.g., "no pull request found")
-			os.Exit(1)
+	tab := ""
+	tabs := 0
+	for flagTab, set := range map[string]bool{"/files": *files, "/commits": *commits, "/checks": *checks} {
+		if set {
+			tab = flagTab
+			tabs++
+		}
+	}
+	if tabs > 1 || len(args) > 1 {
+		fs.Usage()
+		os.Exit(1)
+	}
+
+	target := ""
+	if len(args) == 1 {
+		target = args[0]
+	}
+
+	url, err := resolvePRURL(ctx, target)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+
+	if err := browser.Open(url + tab); err != nil {
+		fmt.Fprintf(os.Stderr, "Error opening browser: %v\n", err)
+		os.Exit(1)
+	}
+}
+
+// resolvePRURL finds the PR URL for a PR number, branch name or commit SHA.
+// An empty target means the current branch. An all-digit target is a
+// commit if it names one, and a PR number otherwise; "#123" is always a PR.
+func resolvePRURL(ctx context.Context, target string) (string, error) {
+	// A hex target is a commit unless a local branch has that name
+	sha := ""
+	if shaRegex.MatchString(target) && !git.BranchExists(ctx, target) {
+		sha, _ = git.ResolveCommit(ctx, target)
+	}
+
+	if num, err := strconv.Atoi(strings.TrimPrefix(target, "#")); err == nil && sha == "" {
+		if num <= 0 {
+			return "", fmt.Errorf("PR number must be a positive integer")
 		}
-		return
+		return github.BuildURL(ctx, fmt.Sprintf("/pull/%d", num))
 	}
 
-	// Without gh CLI, we need to find the PR number via API
-	fmt.Fprintln(os.Stderr, "Error: gh CLI required for this command")
-	fmt.Fprintln(os.Stderr, "")
-	fmt.Fprintln(os.Stderr, "Install gh CLI:")
-	fmt.Fprintln(os.Stderr, "  brew install gh")
-	fmt.Fprintln(os.Stderr, "  gh auth login")
-	os.Exit(1)
+	var pr *auth.PullRequest
+	var err error
+	switch {
+	case target == "":
+		pr, err = auth.GetCurrentPullRequest(ctx)
+		if err == nil && pr == nil {
+			err = fmt.Errorf("no PR found for current branch")
+		}
+
+	case sha != "":
+		var prs []auth.PullRequest
+		if prs, err = auth.FindPRsForCommit(ctx, sha); err == nil {
+			pr, err = auth.ChoosePR(ctx, auth.IntroducedBy(prs))
+		}
+		if err == nil && pr == nil {
+			err = fmt.Errorf("no PR found for commit %s (is it pushed?)", target)
+		}
+
+	default:
+		var prs []auth.PullRequest
+		if prs, err = auth.FindPRsForBranch(ctx, target); err == nil {
+			pr, err = auth.ChoosePR(ctx, prs)
+		}
+		if err == nil && pr == nil {
+			err = fmt.Errorf("no PR found for branch %s", target)
+		}
+	}
+
+	if err != nil {
+		return "", err
+	}
+	return pr.HTMLURL, nil
 }
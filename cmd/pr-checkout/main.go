@@ -1,47 +1,330 @@
 package main
 
 import (
+	"context"
+	"flag"
 	"fmt"
 	"os"
-	"os/exec"
 	"strconv"
+	"strings"
 
 	"cli-tools/internal/auth"
+	"cli-tools/internal/cli"
 	"cli-tools/internal/git"
+	"cli-tools/internal/github"
 )
 
+// options are the pr-checkout flags
+type options struct {
+	detach   bool
+	force    bool
+	worktree string
+}
+
+// target is a resolved PR: its number and its base repository, which must
+// be reachable through a local remote
+type target struct {
+	remote string // local remote of the base repository
+	repo   string // "owner/repo" of the base repository
+	number int
+}
+
+// plan describes where a PR's head is fetched from and which local branch
+// tracks it
+type plan struct {
+	remote    string // remote to fetch from
+	fetchRef  string // ref on the remote (branch name or refs/pull/N/head)
+	trackRef  string // local ref the fetch updates, used as the start point
+	branch    string // local branch name
+	addRemote string // URL of a fork remote that must be added first
+}
+
 func main() {
-	if !git.IsInsideRepo() {
+	ctx, cancel := cli.Init()
+	defer cancel()
+
+	var opts options
+	fs := flag.NewFlagSet("pr-checkout", flag.ExitOnError)
+	fs.BoolVar(&opts.detach, "detach", false, "check out the PR head without creating a branch")
+	fs.BoolVar(&opts.force, "force", false, "reset an existing local branch to the PR head")
+	fs.StringVar(&opts.worktree, "worktree", "", "check the PR out into a new git worktree at `dir`")
+	fs.Usage = func() {
+		fmt.Fprintln(os.Stderr, "Usage: pr-checkout [number|url|branch|owner:branch] [--detach] [--force] [--worktree <dir>]")
+		fmt.Fprintln(os.Stderr, "Without an argument, choose from the open PRs.")
+		fmt.Fprintln(os.Stderr, "Examples:")
+		fmt.Fprintln(os.Stderr, "  pr-checkout")
+		fmt.Fprintln(os.Stderr, "  pr-checkout 123")
+		fmt.Fprintln(os.Stderr, "  pr-checkout https://github.com/owner/repo/pull/123")
+		fmt.Fprintln(os.Stderr, "  pr-checkout alice:fix-login")
+		fmt.Fprintln(os.Stderr, "  pr-checkout 123 --worktree ../review-123")
+		fmt.Fprintln(os.Stderr, "  pr-checkout 123 --detach")
+	}
+	args := cli.Parse(fs)
+
+	if !git.IsInsideRepo(ctx) {
 		fmt.Fprintln(os.Stderr, "Error: not inside a git repository")
 		os.Exit(1)
 	}
 
-	if len(os.Args) < 2 {
-		fmt.Fprintln(os.Stderr, "Usage: pr-checkout <pr-number>")
+	if len(args) > 1 {
+		fs.Usage()
 		os.Exit(1)
 	}
 
-	prNum, err := strconv.Atoi(os.Args[1])
-	if err != nil || prNum <= 0 {
-		fmt.Fprintln(os.Stderr, "Error: PR number must be a positive integer")
+	arg := ""
+	if len(args) == 1 {
+		arg = args[0]
+	}
+
+	t, err := resolveTarget(ctx, arg)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
 		os.Exit(1)
 	}
 
-	if !auth.HasGhCLI() {
-		fmt.Fprintln(os.Stderr, "Error: gh CLI required for this command")
-		fmt.Fprintln(os.Stderr, "")
-		fmt.Fprintln(os.Stderr, "Install gh CLI:")
-		fmt.Fprintln(os.Stderr, "  brew install gh")
-		fmt.Fprintln(os.Stderr, "  gh auth login")
+	if err := checkout(ctx, t, opts); err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
 		os.Exit(1)
 	}
+}
 
-	cmd := exec.Command("gh", "pr", "checkout", os.Args[1])
-	cmd.Stdout = os.Stdout
-	cmd.Stderr = os.Stderr
-	cmd.Stdin = os.Stdin
+// resolveTarget works out which PR arg refers to: a PR number or URL, a head
+// branch name, "owner:branch", or, if arg is empty, one the user picks
+func resolveTarget(ctx context.Context, arg string) (*target, error) {
+	origin, err := github.GetRepoInfo(ctx)
+	if err != nil {
+		return nil, err
+	}
+	t := &target{remote: "origin", repo: origin.Owner + "/" + origin.Repo}
 
-	if err := cmd.Run(); err != nil {
-		os.Exit(1)
+	if num, err := strconv.Atoi(arg); err == nil {
+		if num <= 0 {
+			return nil, fmt.Errorf("PR number must be a positive integer")
+		}
+		t.number = num
+		return t, nil
+	}
+
+	// A PR URL may point at another repository, e.g. the upstream of a fork
+	if strings.HasPrefix(arg, "https://") || strings.HasPrefix(arg, "http://") {
+		repo, num, err := github.ParsePRURL(arg)
+		if err != nil {
+			return nil, err
+		}
+		return targetFor(ctx, origin, repo, num)
+	}
+
+	if arg == "" {
+		return pickOpenPR(ctx, t)
+	}
+
+	// A head branch, "owner:branch" or bare; its PR may be opened against
+	// origin or, when origin is a fork, the upstream repository
+	candidates, err := auth.FindPRsForHead(ctx, arg)
+	if err != nil {
+		return nil, err
+	}
+	pr, err := auth.ChoosePR(ctx, candidates)
+	if err != nil {
+		return nil, err
+	}
+	if pr == nil {
+		return nil, fmt.Errorf("no PR found with head %s", arg)
+	}
+	return targetFor(ctx, origin, pr.BaseRepo(), pr.Number)
+}
+
+// targetFor returns PR num of repo, checked out through the local remote
+// that points to repo
+func targetFor(ctx context.Context, origin *github.RepoInfo, repo string, num int) (*target, error) {
+	remote, err := github.FindRemote(ctx, repo)
+	if err != nil {
+		return nil, err
+	}
+	if remote == "" {
+		return nil, fmt.Errorf("no local remote points to %s; add one with: git remote add <name> %s", repo, origin.RemoteURLFor(repo))
+	}
+	return &target{remote: remote, repo: repo, number: num}, nil
+}
+
+// pickOpenPR lets the user choose one of the open PRs of t's repository
+func pickOpenPR(ctx context.Context, t *target) (*target, error) {
+	pr, err := auth.PickOpenPR(ctx, t.repo)
+	if err != nil {
+		return nil, err
+	}
+	t.number = pr.Number
+	return t, nil
+}
+
+// checkout fetches the target PR and checks it out according to opts
+func checkout(ctx context.Context, t *target, opts options) error {
+	base, err := github.GetRemoteRepoInfo(ctx, t.remote)
+	if err != nil {
+		return err
+	}
+
+	pr, err := auth.GetPR(ctx, t.repo, t.number)
+	if err != nil {
+		return err
+	}
+
+	p, err := planCheckout(ctx, t.remote, base, pr)
+	if err != nil {
+		return err
+	}
+
+	if p.addRemote != "" {
+		fmt.Fprintf(os.Stderr, "Adding remote %s -> %s\n", p.remote, p.addRemote)
+		if err := git.Run(ctx, "remote", "add", p.remote, p.addRemote); err != nil {
+			return err
+		}
+	}
+
+	refspec := p.fetchRef
+	if p.trackRef != "FETCH_HEAD" {
+		refspec = fmt.Sprintf("+refs/heads/%s:%s", p.fetchRef, p.trackRef)
+	}
+	if err := git.Run(ctx, "fetch", p.remote, refspec); err != nil {
+		return err
+	}
+
+	// Pin the start point to a SHA, since FETCH_HEAD is per repository and
+	// a worktree checkout would otherwise race with other fetches
+	start, err := git.ResolveCommit(ctx, p.trackRef)
+	if err != nil {
+		return fmt.Errorf("cannot resolve fetched PR head: %w", err)
+	}
+
+	if opts.detach {
+		if opts.worktree != "" {
+			err = git.Run(ctx, "worktree", "add", "--detach", opts.worktree, start)
+		} else {
+			err = git.Run(ctx, "checkout", "--detach", start)
+		}
+		if err != nil {
+			return err
+		}
+		reportDone(pr, "detached HEAD", opts.worktree)
+		return nil
+	}
+
+	exists := git.BranchExists(ctx, p.branch)
+	switch {
+	case opts.worktree != "" && (!exists || opts.force):
+		create := "-b"
+		if exists {
+			create = "-B"
+		}
+		err = git.Run(ctx, "worktree", "add", create, p.branch, opts.worktree, start)
+	case opts.worktree != "":
+		// Reuse the existing branch, fast-forwarding it inside the new worktree
+		if err = git.Run(ctx, "worktree", "add", opts.worktree, p.branch); err == nil {
+			err = fastForward(ctx, p.branch, start, "-C", opts.worktree)
+		}
+	case !exists:
+		err = git.Run(ctx, "checkout", "-b", p.branch, start)
+	case opts.force:
+		err = git.Run(ctx, "checkout", "-B", p.branch, start)
+	default:
+		if err = git.Run(ctx, "checkout", p.branch); err == nil {
+			err = fastForward(ctx, p.branch, start)
+		}
+	}
+	if err != nil {
+		return err
+	}
+
+	if err := git.SetUpstream(ctx, p.branch, p.remote, p.fetchRef); err != nil {
+		return fmt.Errorf("failed to set upstream of %s: %w", p.branch, err)
+	}
+
+	reportDone(pr, "branch "+p.branch, opts.worktree)
+	return nil
+}
+
+// planCheckout decides how to fetch the PR head and what to call the local branch:
+//   - heads in a repository that a local remote points to (origin itself, or
+//     a fork added earlier) track that remote's branch
+//   - heads in forks that maintainers can push to track a remote named after
+//     the fork owner, added on demand, so `git push` updates the PR
+//   - other forks (or deleted ones) track refs/pull/N/head on the base remote, read-only
+func planCheckout(ctx context.Context, baseRemote string, base *github.RepoInfo, pr *auth.PullRequest) (*plan, error) {
+	head := pr.Head.Repo
+
+	headRemote := ""
+	if head != nil {
+		var err error
+		if headRemote, err = github.FindRemote(ctx, head.FullName); err != nil {
+			return nil, err
+		}
+	}
+
+	p := &plan{branch: pr.Head.Ref}
+	switch {
+	case headRemote != "":
+		p.remote = headRemote
+		p.fetchRef = pr.Head.Ref
+		p.trackRef = fmt.Sprintf("refs/remotes/%s/%s", p.remote, pr.Head.Ref)
+
+	case head != nil && pr.MaintainerCanModify:
+		p.remote = head.Owner.Login
+		p.fetchRef = pr.Head.Ref
+		p.trackRef = fmt.Sprintf("refs/remotes/%s/%s", p.remote, pr.Head.Ref)
+		if _, err := git.GetRemoteURLFor(ctx, p.remote); err == nil {
+			return nil, fmt.Errorf("remote %q already exists and does not point to %s", p.remote, head.FullName)
+		} else if ctx.Err() != nil {
+			return nil, context.Cause(ctx)
+		}
+		p.addRemote = base.RemoteURLFor(head.FullName)
+
+	default:
+		p.remote = baseRemote
+		p.fetchRef = fmt.Sprintf("refs/pull/%d/head", pr.Number)
+		p.trackRef = "FETCH_HEAD"
+	}
+
+	// Fork branches are often named like local ones ("main", "fix");
+	// prefix the owner when the plain name is taken by something else
+	owner := "pr"
+	if head != nil {
+		owner = head.Owner.Login
+	}
+	defaultBranch, err := git.GetDefaultBranch(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if p.branch == defaultBranch {
+		p.branch = owner + "-" + p.branch
+	} else if git.BranchExists(ctx, p.branch) {
+		remote, merge, err := git.GetUpstream(ctx, p.branch)
+		if err != nil {
+			return nil, err
+		}
+		if remote != p.remote || merge != p.fetchRef {
+			p.branch = owner + "-" + p.branch
+		}
+	}
+	return p, nil
+}
+
+// fastForward moves branch to start if it is a fast-forward, refusing to
+// discard local commits. extra arguments (e.g. "-C", dir) go before the subcommand.
+func fastForward(ctx context.Context, branch, start string, extra ...string) error {
+	args := append(extra, "merge", "--ff-only", start)
+	if err := git.Run(ctx, args...); err != nil {
+		if ctx.Err() != nil {
+			return err
+		}
+		return fmt.Errorf("local branch %s has diverged from the PR; use --force to reset it", branch)
+	}
+	return nil
+}
+
+func reportDone(pr *auth.PullRequest, what, worktree string) {
+	if worktree != "" {
+		fmt.Printf("Checked out PR #%d (%s) on %s in %s\n", pr.Number, pr.Title, what, worktree)
+		return
 	}
+	fmt.Printf("Checked out PR #%d (%s) on %s\n", pr.Number, pr.Title, what)
 }
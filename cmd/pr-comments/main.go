@@ -0,0 +1,300 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+
+	"cli-tools/internal/auth"
+	"cli-tools/internal/cli"
+	"cli-tools/internal/diff"
+	"cli-tools/internal/format"
+	"cli-tools/internal/git"
+	"cli-tools/internal/github"
+	"cli-tools/internal/pager"
+	"cli-tools/internal/prompt"
+)
+
+// hunkContext is how many diff lines are shown above each thread, ending at
+// the commented line
+const hunkContext = 4
+
+func main() {
+	ctx, cancel := cli.Init()
+	defer cancel()
+
+	fs := flag.NewFlagSet("pr-comments", flag.ExitOnError)
+	unresolved := fs.Bool("unresolved", false, "show only unresolved threads")
+	reply := fs.String("reply", "", "reply to the thread with this `id`")
+	resolve := fs.String("resolve", "", "resolve the thread with this `id`")
+	unresolve := fs.String("unresolve", "", "reopen the resolved thread with this `id`")
+	body := fs.String("body", "", "reply `text`")
+	bodyFile := fs.String("body-file", "", "read the reply from `file` (- for stdin)")
+	fs.Usage = func() {
+		fmt.Fprintln(os.Stderr, "Usage: pr-comments [number|url] [--unresolved]")
+		fmt.Fprintln(os.Stderr, "       pr-comments --reply <id> [--body <text>|--body-file <file>]")
+		fmt.Fprintln(os.Stderr, "       pr-comments --resolve <id> | --unresolve <id>")
+		fmt.Fprintln(os.Stderr, "Shows the PR's review threads by file and line. Without a number, uses the current branch's PR.")
+		fmt.Fprintln(os.Stderr, "Thread IDs are shown next to each thread. Without --body or --body-file, the reply is written in your editor.")
+		fmt.Fprintln(os.Stderr, "Examples:")
+		fmt.Fprintln(os.Stderr, "  pr-comments")
+		fmt.Fprintln(os.Stderr, "  pr-comments 123 --unresolved")
+		fmt.Fprintln(os.Stderr, "  pr-comments --reply PRRT_kwDOAbc123 --body 'Fixed in the latest push'")
+		fmt.Fprintln(os.Stderr, "  pr-comments --resolve PRRT_kwDOAbc123")
+	}
+	args := cli.Parse(fs)
+
+	if !git.IsInsideRepo(ctx) {
+		fmt.Fprintln(os.Stderr, "Error: not inside a git repository")
+		os.Exit(1)
+	}
+
+	actions := 0
+	for _, id := range []string{*reply, *resolve, *unresolve} {
+		if id != "" {
+			actions++
+		}
+	}
+	if actions > 1 {
+		fmt.Fprintln(os.Stderr, "Error: use only one of --reply, --resolve and --unresolve")
+		os.Exit(1)
+	}
+	if *reply == "" && (*body != "" || *bodyFile != "") {
+		fmt.Fprintln(os.Stderr, "Error: --body and --body-file need --reply")
+		os.Exit(1)
+	}
+	if len(args) > 1 || actions > 0 && len(args) > 0 || *body != "" && *bodyFile != "" {
+		fs.Usage()
+		os.Exit(1)
+	}
+
+	var err error
+	switch {
+	case *reply != "":
+		err = replyTo(ctx, *reply, *body, *bodyFile)
+	case *resolve != "":
+		err = auth.ResolveThread(ctx, *resolve, true)
+		if err == nil {
+			fmt.Printf("Resolved thread %s\n", *resolve)
+		}
+	case *unresolve != "":
+		err = auth.ResolveThread(ctx, *unresolve, false)
+		if err == nil {
+			fmt.Printf("Reopened thread %s\n", *unresolve)
+		}
+	default:
+		arg := ""
+		if len(args) == 1 {
+			arg = args[0]
+		}
+		err = showThreads(ctx, arg, *unresolved)
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+}
+
+// resolvePR works out which PR to show: a number on origin, a PR URL, the
+// current branch's PR, or one the user picks
+func resolvePR(ctx context.Context, arg string) (string, int, error) {
+	if strings.HasPrefix(arg, "https://") || strings.HasPrefix(arg, "http://") {
+		return github.ParsePRURL(arg)
+	}
+	if arg != "" {
+		number, err := strconv.Atoi(arg)
+		if err != nil || number <= 0 {
+			return "", 0, fmt.Errorf("invalid PR number: %s", arg)
+		}
+		repo, err := github.GetOwnerRepo(ctx)
+		return repo, number, err
+	}
+
+	pr, err := auth.GetCurrentPullRequest(ctx)
+	if err != nil {
+		return "", 0, err
+	}
+	if pr != nil {
+		return pr.BaseRepo(), pr.Number, nil
+	}
+	if !prompt.IsInteractive() {
+		return "", 0, errors.New("no PR found for current branch; pass a PR number")
+	}
+	// No PR for this branch: pick one of the open PRs instead
+	repo, err := github.GetOwnerRepo(ctx)
+	if err != nil {
+		return "", 0, err
+	}
+	pr, err = auth.PickOpenPR(ctx, repo)
+	if err != nil {
+		return "", 0, err
+	}
+	return pr.BaseRepo(), pr.Number, nil
+}
+
+// showThreads prints the review threads of a PR grouped by file and line
+func showThreads(ctx context.Context, arg string, unresolvedOnly bool) error {
+	repo, number, err := resolvePR(ctx, arg)
+	if err != nil {
+		return err
+	}
+	pr, err := auth.GetPR(ctx, repo, number)
+	if err != nil {
+		return err
+	}
+	threads, err := auth.ListReviewThreads(ctx, repo, number)
+	if err != nil {
+		return err
+	}
+
+	total, open := len(threads), 0
+	for _, t := range threads {
+		if !t.IsResolved {
+			open++
+		}
+	}
+	if unresolvedOnly {
+		var kept []auth.ReviewThread
+		for _, t := range threads {
+			if !t.IsResolved {
+				kept = append(kept, t)
+			}
+		}
+		threads = kept
+	}
+	// Threads of a file are listed top to bottom, ties in the order they started
+	sort.SliceStable(threads, func(i, j int) bool {
+		if threads[i].Path != threads[j].Path {
+			return threads[i].Path < threads[j].Path
+		}
+		return threads[i].Line < threads[j].Line
+	})
+
+	p := pager.Start(ctx)
+	defer p.Close()
+
+	fmt.Fprintf(p, "PR #%d: %s\n", pr.Number, pr.Title)
+	fmt.Fprintf(p, "URL: %s\n", pr.HTMLURL)
+	fmt.Fprintf(p, "%d review %s, %d unresolved\n", total, format.Plural(total, "thread", "threads"), open)
+
+	path := ""
+	for _, t := range threads {
+		if t.Path != path {
+			path = t.Path
+			fmt.Fprintln(p)
+			fmt.Fprintln(p, paint(p.Color(), diff.Bold, path))
+		}
+		writeThread(p, t, p.Color())
+	}
+	return nil
+}
+
+// writeThread writes one thread: its location and state, the end of the
+// diff hunk it is attached to, and its comments
+func writeThread(w io.Writer, t auth.ReviewThread, color bool) {
+	location := fmt.Sprintf("line %d", t.Line)
+	if t.StartLine > 0 && t.StartLine != t.Line {
+		location = fmt.Sprintf("lines %d-%d", t.StartLine, t.Line)
+	}
+	state := "[unresolved]"
+	if t.IsResolved {
+		state = "[resolved]"
+		if t.ResolvedBy != "" {
+			state = fmt.Sprintf("[resolved by @%s]", t.ResolvedBy)
+		}
+	}
+	if t.IsOutdated {
+		state += " [outdated]"
+	}
+	fmt.Fprintf(w, "\n  %s  %s  %s\n", location, state, paint(color, diff.Dim, t.ID))
+
+	if len(t.Comments) > 0 {
+		colorer := diff.NewColorer(t.Path)
+		for _, line := range hunkTail(t.Comments[0].DiffHunk) {
+			if color {
+				line = colorer.Line(line, true)
+			}
+			fmt.Fprintf(w, "    %s\n", line)
+		}
+	}
+
+	for _, c := range t.Comments {
+		date, _, _ := strings.Cut(c.CreatedAt, "T")
+		fmt.Fprintf(w, "\n    %s  %s\n", paint(color, diff.Bold, "@"+c.Author), date)
+		for _, line := range strings.Split(strings.TrimSpace(strings.ReplaceAll(c.Body, "\r\n", "\n")), "\n") {
+			fmt.Fprintln(w, strings.TrimRight("      "+line, " "))
+		}
+	}
+}
+
+// hunkTail returns the header of a diff hunk and its last few lines, which
+// end at the commented line
+func hunkTail(hunk string) []string {
+	lines := strings.Split(strings.TrimRight(hunk, "\n"), "\n")
+	if len(lines) == 0 || lines[0] == "" {
+		return nil
+	}
+	if !strings.HasPrefix(lines[0], "@@") || len(lines) <= hunkContext+1 {
+		return lines
+	}
+	return append([]string{lines[0]}, lines[len(lines)-hunkContext:]...)
+}
+
+// replyTo adds a reply to a thread, taking its text from body, bodyFile or
+// the editor
+func replyTo(ctx context.Context, threadID, body, bodyFile string) error {
+	switch {
+	case bodyFile == "-":
+		data, err := io.ReadAll(os.Stdin)
+		if err != nil {
+			return err
+		}
+		body = string(data)
+	case bodyFile != "":
+		data, err := os.ReadFile(bodyFile)
+		if err != nil {
+			return err
+		}
+		body = string(data)
+	case body == "":
+		help := fmt.Sprintf("Reply to review thread %s.\n", threadID) +
+			"An empty reply is not posted."
+		text, err := prompt.Edit(ctx, "REPLY.md", "", help)
+		if errors.Is(err, prompt.ErrNotInteractive) {
+			return errors.New("a reply is required; use --body or --body-file")
+		}
+		if err != nil {
+			return err
+		}
+		body = text
+	}
+
+	body = strings.TrimSpace(body)
+	if body == "" {
+		return errors.New("empty reply; nothing posted")
+	}
+
+	url, err := auth.ReplyToThread(ctx, threadID, body)
+	if err != nil {
+		return err
+	}
+	fmt.Printf("Replied to thread %s\n", threadID)
+	if url != "" {
+		fmt.Println(url)
+	}
+	return nil
+}
+
+// paint wraps s in an ANSI color if color is set
+func paint(color bool, code, s string) string {
+	if !color {
+		return s
+	}
+	return code + s + diff.Reset
+}
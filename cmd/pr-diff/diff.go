@@ -0,0 +1,202 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"path"
+	"strings"
+
+	"cli-tools/internal/diff"
+	"cli-tools/internal/format"
+)
+
+// Diffstat layout
+const (
+	statWidth   = 50 // columns for the +/- bar
+	maxNameSize = 50 // longer paths are shortened to ".../dir/file"
+)
+
+// fileDiff is the part of a unified diff that covers one file
+type fileDiff struct {
+	path    string // new path, or the old one for deleted files
+	lines   []string
+	added   int
+	deleted int
+	binary  bool
+}
+
+// parseDiff splits a unified diff as produced by git into files
+func parseDiff(diff string) []fileDiff {
+	var files []fileDiff
+	var cur *fileDiff
+	inHunk := false
+
+	for _, line := range strings.SplitAfter(diff, "\n") {
+		if line == "" {
+			continue
+		}
+		if strings.HasPrefix(line, "diff --git ") {
+			files = append(files, fileDiff{path: headerPath(strings.TrimRight(line, "\n"))})
+			cur = &files[len(files)-1]
+			inHunk = false
+		}
+		if cur == nil {
+			continue
+		}
+		cur.lines = append(cur.lines, line)
+
+		switch {
+		case strings.HasPrefix(line, "@@"):
+			inHunk = true
+		case !inHunk && strings.HasPrefix(line, "+++ b/"):
+			cur.path = strings.TrimRight(strings.TrimPrefix(line, "+++ b/"), "\n")
+		case !inHunk && strings.HasPrefix(line, "rename to "):
+			cur.path = strings.TrimRight(strings.TrimPrefix(line, "rename to "), "\n")
+		case !inHunk && strings.HasPrefix(line, "Binary files "):
+			cur.binary = true
+		case inHunk && strings.HasPrefix(line, "+"):
+			cur.added++
+		case inHunk && strings.HasPrefix(line, "-"):
+			cur.deleted++
+		}
+	}
+	return files
+}
+
+// headerPath extracts the path from a "diff --git a/x b/x" line. It is only
+// a fallback for files without a "+++" line, e.g. binary or mode changes.
+func headerPath(header string) string {
+	rest := strings.TrimPrefix(header, "diff --git ")
+	// Both sides are the same length when the file wasn't renamed
+	if n := len(rest); n%2 == 1 && strings.HasPrefix(rest, "a/") {
+		if b := rest[n/2+1:]; strings.HasPrefix(b, "b/") {
+			return b[2:]
+		}
+	}
+	if i := strings.Index(rest, " b/"); i >= 0 {
+		return rest[i+3:]
+	}
+	return rest
+}
+
+// filterFiles keeps the files matching any of the patterns: a path, a
+// directory, or a glob matched against the path or the file name
+func filterFiles(files []fileDiff, patterns []string) []fileDiff {
+	if len(patterns) == 0 {
+		return files
+	}
+
+	var kept []fileDiff
+	for _, f := range files {
+		for _, p := range patterns {
+			p = strings.TrimSuffix(p, "/")
+			if matchPath(p, f.path) {
+				kept = append(kept, f)
+				break
+			}
+		}
+	}
+	return kept
+}
+
+// matchPath reports whether pattern selects the file p
+func matchPath(pattern, p string) bool {
+	if p == pattern || strings.HasPrefix(p, pattern+"/") {
+		return true
+	}
+	if ok, _ := path.Match(pattern, p); ok {
+		return true
+	}
+	ok, _ := path.Match(pattern, path.Base(p))
+	return ok
+}
+
+// writePatch writes the files' diffs, colored if color is set
+func writePatch(w io.Writer, files []fileDiff, color bool) {
+	for _, f := range files {
+		colorer := diff.NewColorer(f.path)
+		inHunk := false
+		for _, line := range f.lines {
+			if !color {
+				io.WriteString(w, line)
+				continue
+			}
+			text := strings.TrimRight(line, "\n")
+			if strings.HasPrefix(text, "@@") {
+				inHunk = true
+			}
+			fmt.Fprintln(w, colorer.Line(text, inHunk))
+		}
+	}
+}
+
+// writeNames writes the changed file paths, one per line
+func writeNames(w io.Writer, files []fileDiff) {
+	for _, f := range files {
+		fmt.Fprintln(w, f.path)
+	}
+}
+
+// writeStat writes a diffstat like `git diff --stat`
+func writeStat(w io.Writer, files []fileDiff, color bool) {
+	nameWidth, maxChanges := 0, 0
+	var added, deleted int
+	for _, f := range files {
+		nameWidth = max(nameWidth, len(shortenPath(f.path)))
+		maxChanges = max(maxChanges, f.added+f.deleted)
+		added += f.added
+		deleted += f.deleted
+	}
+	countWidth := len(fmt.Sprint(maxChanges))
+
+	for _, f := range files {
+		name := shortenPath(f.path)
+		if f.binary {
+			fmt.Fprintf(w, " %-*s | %*s\n", nameWidth, name, countWidth, "Bin")
+			continue
+		}
+
+		plus, minus := f.added, f.deleted
+		if maxChanges > statWidth {
+			// Scale the bar, keeping at least one mark for any change
+			plus = scale(f.added, maxChanges)
+			minus = scale(f.deleted, maxChanges)
+		}
+		bar := strings.Repeat("+", plus)
+		bars := strings.Repeat("-", minus)
+		if color {
+			bar = diff.Green + bar + diff.Reset
+			bars = diff.Red + bars + diff.Reset
+		}
+		fmt.Fprintf(w, " %-*s | %*d %s%s\n", nameWidth, name, countWidth, f.added+f.deleted, bar, bars)
+	}
+
+	fmt.Fprintf(w, " %d %s changed", len(files), format.Plural(len(files), "file", "files"))
+	if added > 0 || deleted == 0 {
+		fmt.Fprintf(w, ", %d %s(+)", added, format.Plural(added, "insertion", "insertions"))
+	}
+	if deleted > 0 {
+		fmt.Fprintf(w, ", %d %s(-)", deleted, format.Plural(deleted, "deletion", "deletions"))
+	}
+	fmt.Fprintln(w)
+}
+
+// scale shrinks n so that the largest change, total, fits in statWidth columns
+func scale(n, total int) int {
+	if n == 0 {
+		return 0
+	}
+	return 1 + n*(statWidth-1)/total
+}
+
+// shortenPath keeps long paths readable in the diffstat, like git: ".../dir/file"
+func shortenPath(p string) string {
+	if len(p) <= maxNameSize {
+		return p
+	}
+	short := p[len(p)-maxNameSize+3:]
+	if i := strings.Index(short, "/"); i >= 0 {
+		short = short[i:]
+	}
+	return "..." + short
+}
@@ -1,52 +1,149 @@
 package main
 
 import (
-	"encoding/json"
+	"context"
+	"flag"
 	"fmt"
 	"os"
-	"os/exec"
 	"strconv"
 
 	"cli-tools/internal/auth"
 	"cli-tools/internal/browser"
+	"cli-tools/internal/cli"
 	"cli-tools/internal/git"
 	"cli-tools/internal/github"
+	"cli-tools/internal/pager"
+	"cli-tools/internal/prompt"
 )
 
 func main() {
-	if !git.IsInsideRepo() {
+	ctx, cancel := cli.Init()
+	defer cancel()
+
+	fs := flag.NewFlagSet("pr-diff", flag.ExitOnError)
+	terminal := fs.Bool("terminal", false, "show the colored diff in the terminal, through $PAGER")
+	patch := fs.Bool("patch", false, "print the plain unified diff")
+	nameOnly := fs.Bool("name-only", false, "print only the names of changed files")
+	stat := fs.Bool("stat", false, "print a diffstat")
+	fs.Usage = func() {
+		fmt.Fprintln(os.Stderr, "Usage: pr-diff [pr-number] [--terminal|--patch|--name-only|--stat] [path...]")
+		fmt.Fprintln(os.Stderr, "Opens the PR's Files tab in the browser unless an output mode is given.")
+		fmt.Fprintln(os.Stderr, "Paths (relative to the repository root) may be directories or globs.")
+		fmt.Fprintln(os.Stderr, "Examples:")
+		fmt.Fprintln(os.Stderr, "  pr-diff 123")
+		fmt.Fprintln(os.Stderr, "  pr-diff 123 --terminal")
+		fmt.Fprintln(os.Stderr, "  pr-diff --stat")
+		fmt.Fprintln(os.Stderr, "  pr-diff 123 --terminal internal/ '*.go'")
+		fmt.Fprintln(os.Stderr, "  pr-diff 123 --patch | git apply")
+	}
+	args := cli.Parse(fs)
+
+	if !git.IsInsideRepo(ctx) {
 		fmt.Fprintln(os.Stderr, "Error: not inside a git repository")
 		os.Exit(1)
 	}
 
-	var prNum int
-	var err error
+	modes := 0
+	for _, m := range []bool{*terminal, *patch, *nameOnly, *stat} {
+		if m {
+			modes++
+		}
+	}
+	if modes > 1 {
+		fmt.Fprintln(os.Stderr, "Error: use only one of --terminal, --patch, --name-only and --stat")
+		os.Exit(1)
+	}
+
+	// An optional PR number, then paths
+	prNum := 0
+	if len(args) > 0 {
+		if n, err := strconv.Atoi(args[0]); err == nil {
+			if n <= 0 {
+				fmt.Fprintln(os.Stderr, "Error: invalid PR number")
+				os.Exit(1)
+			}
+			prNum = n
+			args = args[1:]
+		}
+	}
+	if len(args) > 0 && modes == 0 {
+		fmt.Fprintln(os.Stderr, "Error: paths need --terminal, --patch, --name-only or --stat")
+		os.Exit(1)
+	}
+
+	if modes == 0 {
+		openInBrowser(ctx, prNum)
+		return
+	}
 
-	// Check if PR number was provided as argument
-	if len(os.Args) >= 2 {
-		prNum, err = strconv.Atoi(os.Args[1])
-		if err != nil || prNum <= 0 {
-			fmt.Fprintln(os.Stderr, "Error: invalid PR number")
+	ownerRepo, number, err := resolvePR(ctx, prNum)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+
+	diff, err := auth.GetPRDiff(ctx, ownerRepo, number)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+
+	files := filterFiles(parseDiff(diff), args)
+	if len(files) == 0 {
+		if len(args) > 0 {
+			fmt.Fprintln(os.Stderr, "No changed files match the given paths")
 			os.Exit(1)
 		}
-	} else {
-		// Try to get PR for current branch
-		prNum, err = getCurrentPRNumber()
+		fmt.Fprintln(os.Stderr, "The PR has no changes")
+		return
+	}
+
+	switch {
+	case *patch:
+		writePatch(os.Stdout, files, false)
+	case *nameOnly:
+		writeNames(os.Stdout, files)
+	case *stat:
+		writeStat(os.Stdout, files, pager.IsTerminal(os.Stdout) && os.Getenv("NO_COLOR") == "")
+	case *terminal:
+		p := pager.Start(ctx)
+		writePatch(p, files, p.Color())
+		p.Close()
+	}
+}
+
+// resolvePR returns the repository and number of the PR to diff: prNum on
+// origin, or the current branch's PR, or one the user picks
+func resolvePR(ctx context.Context, prNum int) (string, int, error) {
+	if prNum > 0 {
+		ownerRepo, err := github.GetOwnerRepo(ctx)
+		return ownerRepo, prNum, err
+	}
+
+	pr, err := currentOrPickedPR(ctx)
+	if err != nil {
+		return "", 0, err
+	}
+	return pr.BaseRepo(), pr.Number, nil
+}
+
+// openInBrowser opens the Files tab of PR prNum, or of the current branch's PR
+func openInBrowser(ctx context.Context, prNum int) {
+	var url string
+	if prNum > 0 {
+		var err error
+		url, err = github.BuildURL(ctx, fmt.Sprintf("/pull/%d/files", prNum))
 		if err != nil {
 			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
 			os.Exit(1)
 		}
-		if prNum == 0 {
-			fmt.Fprintln(os.Stderr, "Error: no PR found for current branch")
-			fmt.Fprintln(os.Stderr, "Usage: pr-diff [pr-number]")
+	} else {
+		pr, err := currentOrPickedPR(ctx)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
 			os.Exit(1)
 		}
-	}
-
-	url, err := github.BuildURL(fmt.Sprintf("/pull/%d/files", prNum))
-	if err != nil {
-		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
-		os.Exit(1)
+		url = pr.HTMLURL + "/files"
 	}
 
 	if err := browser.Open(url); err != nil {
@@ -55,22 +152,24 @@ func main() {
 	}
 }
 
-func getCurrentPRNumber() (int, error) {
-	if auth.HasGhCLI() {
-		cmd := exec.Command("gh", "pr", "view", "--json", "number")
-		out, err := cmd.Output()
-		if err != nil {
-			return 0, nil // No PR exists
-		}
-
-		var result struct {
-			Number int `json:"number"`
-		}
-		if err := json.Unmarshal(out, &result); err != nil {
-			return 0, fmt.Errorf("failed to parse PR info: %w", err)
-		}
-		return result.Number, nil
+// currentOrPickedPR returns the current branch's PR. If the branch has
+// none, the user picks one of the open PRs.
+func currentOrPickedPR(ctx context.Context) (*auth.PullRequest, error) {
+	pr, err := auth.GetCurrentPullRequest(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if pr != nil {
+		return pr, nil
 	}
 
-	return 0, fmt.Errorf("gh CLI required to detect current PR. Install with: brew install gh")
+	if !prompt.IsInteractive() {
+		return nil, fmt.Errorf("no PR found for current branch; pass a PR number")
+	}
+	// No PR for this branch: pick one of the open PRs instead
+	ownerRepo, err := github.GetOwnerRepo(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return auth.PickOpenPR(ctx, ownerRepo)
 }
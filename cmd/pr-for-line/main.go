@@ -0,0 +1,188 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+
+	"cli-tools/internal/auth"
+	"cli-tools/internal/browser"
+	"cli-tools/internal/cli"
+	"cli-tools/internal/fileref"
+	"cli-tools/internal/git"
+)
+
+// commitLines is a blamed commit and the lines it last touched
+type commitLines struct {
+	line  git.BlameLine
+	lines []int
+}
+
+func main() {
+	ctx, cancel := cli.Init()
+	defer cancel()
+
+	fs := flag.NewFlagSet("pr-for-line", flag.ExitOnError)
+	open := fs.Bool("open", false, "open the PR(s) in the browser")
+	fs.Usage = func() {
+		fmt.Fprintln(os.Stderr, "Usage: pr-for-line <file:line[-end]> [--open]")
+		fmt.Fprintln(os.Stderr, "Examples:")
+		fmt.Fprintln(os.Stderr, "  pr-for-line main.go:42")
+		fmt.Fprintln(os.Stderr, "  pr-for-line main.go:42-60 --open")
+	}
+	args := cli.Parse(fs)
+
+	if !git.IsInsideRepo(ctx) {
+		fmt.Fprintln(os.Stderr, "Error: not inside a git repository")
+		os.Exit(1)
+	}
+
+	if len(args) != 1 {
+		fs.Usage()
+		os.Exit(1)
+	}
+
+	filePath, start, end := fileref.Parse(args[0])
+	if start == 0 {
+		fmt.Fprintln(os.Stderr, "Error: a line or line range is required (e.g. main.go:42 or main.go:42-60)")
+		os.Exit(1)
+	}
+
+	if _, err := os.Stat(filePath); os.IsNotExist(err) {
+		fmt.Fprintf(os.Stderr, "Error: file not found: %s\n", filePath)
+		os.Exit(1)
+	}
+
+	blame, err := git.Blame(ctx, filePath, start, end, "")
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+
+	// Group lines by commit, in order of first appearance
+	var commits []*commitLines
+	bySHA := make(map[string]*commitLines)
+	for _, line := range blame {
+		c, ok := bySHA[line.SHA]
+		if !ok {
+			c = &commitLines{line: line}
+			bySHA[line.SHA] = c
+			commits = append(commits, c)
+		}
+		c.lines = append(c.lines, line.Line)
+	}
+
+	if err := report(ctx, commits, *open); err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+}
+
+// report resolves each commit to the PR that introduced it and prints
+// (or opens) the PRs, listing the commits and lines that belong to each
+func report(ctx context.Context, commits []*commitLines, open bool) error {
+	var prs []*auth.PullRequest
+	prCommits := make(map[string][]*commitLines)
+	var orphans []*commitLines
+
+	for _, c := range commits {
+		if c.line.Uncommitted() {
+			orphans = append(orphans, c)
+			continue
+		}
+
+		found, err := auth.FindPRsForCommit(ctx, c.line.SHA)
+		if err != nil {
+			// Unpushed commits are unknown to GitHub; anything else is fatal
+			if !errors.Is(err, auth.ErrCommitNotFound) {
+				return err
+			}
+			orphans = append(orphans, c)
+			continue
+		}
+
+		found = auth.IntroducedBy(found)
+		if len(found) == 0 {
+			orphans = append(orphans, c)
+			continue
+		}
+		for i := range found {
+			pr := &found[i]
+			if _, seen := prCommits[pr.HTMLURL]; !seen {
+				prs = append(prs, pr)
+			}
+			prCommits[pr.HTMLURL] = append(prCommits[pr.HTMLURL], c)
+		}
+	}
+
+	if open {
+		if len(prs) == 0 {
+			return fmt.Errorf("no PR found for these lines")
+		}
+		for _, pr := range prs {
+			if err := browser.Open(pr.HTMLURL); err != nil {
+				return fmt.Errorf("opening browser: %w", err)
+			}
+		}
+		return nil
+	}
+
+	for _, pr := range prs {
+		fmt.Printf("#%d %s\n", pr.Number, pr.Title)
+		when := pr.Status()
+		if pr.MergedAt != "" {
+			when = "merged " + formatDate(pr.MergedAt)
+		}
+		fmt.Printf("    by @%s, %s\n", pr.User.Login, when)
+		for _, c := range prCommits[pr.HTMLURL] {
+			fmt.Printf("    %s %s (%s)\n", c.line.SHA[:7], c.line.Summary, formatLines(c.lines))
+		}
+		fmt.Printf("    %s\n\n", pr.HTMLURL)
+	}
+
+	for _, c := range orphans {
+		if c.line.Uncommitted() {
+			fmt.Printf("Not committed yet (%s)\n\n", formatLines(c.lines))
+			continue
+		}
+		fmt.Printf("%s %s (no PR)\n", c.line.SHA[:7], c.line.Summary)
+		fmt.Printf("    by %s, %s\n", c.line.Author, c.line.AuthorTime.Format("2006-01-02"))
+		fmt.Printf("    %s\n\n", formatLines(c.lines))
+	}
+	return nil
+}
+
+// formatLines renders line numbers compactly, e.g. "lines 3-5, 9"
+func formatLines(lines []int) string {
+	var parts []string
+	for i := 0; i < len(lines); {
+		j := i
+		for j+1 < len(lines) && lines[j+1] == lines[j]+1 {
+			j++
+		}
+		if i == j {
+			parts = append(parts, strconv.Itoa(lines[i]))
+		} else {
+			parts = append(parts, fmt.Sprintf("%d-%d", lines[i], lines[j]))
+		}
+		i = j + 1
+	}
+
+	label := "line "
+	if len(lines) > 1 {
+		label = "lines "
+	}
+	return label + strings.Join(parts, ", ")
+}
+
+// formatDate turns an API timestamp into YYYY-MM-DD
+func formatDate(ts string) string {
+	if len(ts) >= 10 {
+		return ts[:10]
+	}
+	return ts
+}
@@ -0,0 +1,440 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+
+	"cli-tools/internal/auth"
+	"cli-tools/internal/cli"
+	"cli-tools/internal/git"
+	"cli-tools/internal/github"
+	"cli-tools/internal/picker"
+	"cli-tools/internal/prompt"
+)
+
+// options are the pr-merge flags
+type options struct {
+	method       string
+	subject      string
+	body         string
+	edit         bool
+	auto         bool
+	deleteBranch bool
+	yes          bool
+}
+
+// blocker is a reason a PR can't be merged right now. Soft blockers, such
+// as pending checks or missing reviews, don't stop auto-merge.
+type blocker struct {
+	reason string
+	soft   bool
+}
+
+func main() {
+	ctx, cancel := cli.Init()
+	defer cancel()
+
+	var opts options
+	fs := flag.NewFlagSet("pr-merge", flag.ExitOnError)
+	merge := fs.Bool("merge", false, "create a merge commit")
+	squash := fs.Bool("squash", false, "squash the commits into one")
+	rebase := fs.Bool("rebase", false, "rebase the commits onto the base branch")
+	fs.StringVar(&opts.subject, "subject", "", "commit title for merge and squash")
+	fs.StringVar(&opts.body, "body", "", "commit message body for merge and squash")
+	fs.BoolVar(&opts.edit, "edit", false, "edit the commit message in your editor")
+	fs.BoolVar(&opts.auto, "auto", false, "merge once requirements are met, using auto-merge if needed")
+	fs.BoolVar(&opts.deleteBranch, "delete-branch", false, "delete the remote and local branch after merging")
+	fs.BoolVar(&opts.yes, "yes", false, "don't ask for confirmation")
+	fs.Usage = func() {
+		fmt.Fprintln(os.Stderr, "Usage: pr-merge [number|url] [--merge|--squash|--rebase] [--subject <title>] [--body <text>] [--edit] [--auto] [--delete-branch] [--yes]")
+		fmt.Fprintln(os.Stderr, "Without a number, merges the PR for the current branch.")
+		fmt.Fprintln(os.Stderr, "Examples:")
+		fmt.Fprintln(os.Stderr, "  pr-merge --squash --delete-branch")
+		fmt.Fprintln(os.Stderr, "  pr-merge 123 --merge --edit")
+		fmt.Fprintln(os.Stderr, "  pr-merge 123 --auto")
+	}
+	args := cli.Parse(fs)
+
+	if !git.IsInsideRepo(ctx) {
+		fmt.Fprintln(os.Stderr, "Error: not inside a git repository")
+		os.Exit(1)
+	}
+
+	if len(args) > 1 {
+		fs.Usage()
+		os.Exit(1)
+	}
+
+	for _, m := range []struct {
+		set  bool
+		name string
+	}{{*merge, "merge"}, {*squash, "squash"}, {*rebase, "rebase"}} {
+		if !m.set {
+			continue
+		}
+		if opts.method != "" {
+			fmt.Fprintln(os.Stderr, "Error: use only one of --merge, --squash and --rebase")
+			os.Exit(1)
+		}
+		opts.method = m.name
+	}
+
+	arg := ""
+	if len(args) == 1 {
+		arg = args[0]
+	}
+	repo, number, local, err := resolvePR(ctx, arg)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+
+	if err := run(ctx, repo, number, local, opts); err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+}
+
+// resolvePR works out which PR to merge: a number on origin, a PR URL, or
+// the current branch's PR. local is the local branch of the PR, if known.
+func resolvePR(ctx context.Context, arg string) (repo string, number int, local string, err error) {
+	if arg == "" {
+		pr, err := auth.GetCurrentPullRequest(ctx)
+		if err != nil {
+			return "", 0, "", err
+		}
+		if pr == nil {
+			return "", 0, "", fmt.Errorf("no PR found for current branch; pass a PR number")
+		}
+		branch, err := git.GetCurrentBranch(ctx)
+		if err != nil {
+			return "", 0, "", err
+		}
+		return pr.BaseRepo(), pr.Number, branch, nil
+	}
+
+	if strings.HasPrefix(arg, "https://") || strings.HasPrefix(arg, "http://") {
+		repo, number, err = github.ParsePRURL(arg)
+		return repo, number, "", err
+	}
+
+	number, err = strconv.Atoi(arg)
+	if err != nil || number <= 0 {
+		return "", 0, "", fmt.Errorf("invalid PR number: %s", arg)
+	}
+	repo, err = github.GetOwnerRepo(ctx)
+	return repo, number, "", err
+}
+
+// run merges PR number of repo, or enables auto-merge for it
+func run(ctx context.Context, repo string, number int, local string, opts options) error {
+	info, err := auth.GetMergeInfo(ctx, repo, number)
+	if err != nil {
+		return err
+	}
+
+	method, err := chooseMethod(ctx, info, opts.method)
+	if err != nil {
+		return err
+	}
+	if method == "rebase" && (opts.subject != "" || opts.body != "" || opts.edit) {
+		return fmt.Errorf("rebase merges keep the original commits; --subject, --body and --edit don't apply")
+	}
+
+	blockers := findBlockers(info)
+	auto := false
+	if len(blockers) > 0 {
+		hard := false
+		for _, b := range blockers {
+			hard = hard || !b.soft
+		}
+		if !opts.auto || hard {
+			fmt.Fprintf(os.Stderr, "Cannot merge PR #%d:\n", number)
+			for _, b := range blockers {
+				fmt.Fprintf(os.Stderr, "  - %s\n", b.reason)
+			}
+			if !hard && info.AutoMergeAllowed {
+				fmt.Fprintln(os.Stderr, "Use --auto to merge it once these are resolved.")
+			}
+			return errors.New("merge blocked")
+		}
+		if info.AutoMergeEnabled {
+			fmt.Printf("Auto-merge is already enabled for PR #%d\n", number)
+			return nil
+		}
+		if !info.AutoMergeAllowed {
+			return fmt.Errorf("auto-merge is not enabled for %s", repo)
+		}
+		auto = true
+	}
+
+	mopts := auth.MergeOptions{Method: method, Title: opts.subject, Message: opts.body}
+	if opts.edit {
+		if err := editMessage(ctx, info, &mopts); err != nil {
+			return err
+		}
+	}
+
+	if !opts.yes {
+		question := fmt.Sprintf("Merge PR #%d (%s) into %s with %s?", number, info.Title, info.BaseRefName, method)
+		if auto {
+			question = fmt.Sprintf("Enable auto-merge (%s) for PR #%d (%s)?", method, number, info.Title)
+		}
+		ok, err := prompt.Confirm(ctx, question)
+		if errors.Is(err, prompt.ErrNotInteractive) {
+			return fmt.Errorf("cannot ask for confirmation; pass --yes to merge without asking")
+		}
+		if err != nil {
+			return err
+		}
+		if !ok {
+			fmt.Println("Not merged.")
+			return nil
+		}
+	}
+
+	if auto {
+		if err := auth.EnableAutoMerge(ctx, info.ID, info.HeadRefOid, mopts); err != nil {
+			return err
+		}
+		fmt.Printf("Auto-merge (%s) enabled for PR #%d; GitHub merges it once it's ready\n", method, number)
+		if opts.deleteBranch && !info.DeleteBranchOnMerge {
+			fmt.Println("The branch isn't deleted by auto-merge; run prune-branches after it lands")
+		}
+		return nil
+	}
+
+	sha, err := auth.MergePR(ctx, repo, number, info.HeadRefOid, mopts)
+	if err != nil {
+		return err
+	}
+	fmt.Printf("Merged PR #%d (%s) into %s as %s\n", number, method, info.BaseRefName, shortSHA(sha))
+
+	if opts.deleteBranch {
+		cleanup(ctx, repo, info, local)
+	}
+	return nil
+}
+
+// chooseMethod returns the merge method to use: the one asked for if the
+// repository allows it, the only one allowed, or one the user picks
+func chooseMethod(ctx context.Context, info *auth.MergeInfo, want string) (string, error) {
+	allowed := info.AllowedMethods()
+	if len(allowed) == 0 {
+		return "", fmt.Errorf("the repository doesn't allow any merge method")
+	}
+
+	if want != "" {
+		for _, m := range allowed {
+			if m == want {
+				return m, nil
+			}
+		}
+		return "", fmt.Errorf("the repository doesn't allow %s merges (allowed: %s)", want, strings.Join(allowed, ", "))
+	}
+
+	if len(allowed) == 1 {
+		return allowed[0], nil
+	}
+	idx, err := picker.Pick(ctx, "Merge method:", allowed)
+	if errors.Is(err, prompt.ErrNotInteractive) {
+		return "", fmt.Errorf("pass one of --%s", strings.Join(allowed, ", --"))
+	}
+	if err != nil {
+		return "", err
+	}
+	return allowed[idx], nil
+}
+
+// findBlockers lists why the PR can't be merged right now, if anything
+func findBlockers(info *auth.MergeInfo) []blocker {
+	if info.State != "OPEN" {
+		return []blocker{{reason: fmt.Sprintf("the PR is %s", strings.ToLower(info.State))}}
+	}
+
+	var blockers []blocker
+	if info.IsDraft {
+		blockers = append(blockers, blocker{reason: "the PR is a draft; mark it ready for review first"})
+	}
+	switch {
+	case info.Mergeable == "CONFLICTING" || info.MergeStateStatus == "DIRTY":
+		blockers = append(blockers, blocker{reason: "the branch has conflicts with " + info.BaseRefName + "; run pr-update to resolve them"})
+	case info.Mergeable == "UNKNOWN":
+		blockers = append(blockers, blocker{reason: "GitHub is still checking whether the PR can be merged; try again in a moment"})
+	}
+
+	switch info.ReviewDecision {
+	case "CHANGES_REQUESTED":
+		blockers = append(blockers, blocker{reason: "a reviewer requested changes", soft: true})
+	case "REVIEW_REQUIRED":
+		blockers = append(blockers, blocker{reason: "an approving review is required", soft: true})
+	}
+
+	var failed, pending []string
+	for _, c := range info.Checks {
+		if !c.Required {
+			continue
+		}
+		switch {
+		case c.Pending():
+			pending = append(pending, c.Name)
+		case !c.Passed():
+			failed = append(failed, c.Name)
+		}
+	}
+	if len(failed) > 0 {
+		blockers = append(blockers, blocker{reason: "required checks failed: " + strings.Join(failed, ", "), soft: true})
+	}
+	if len(pending) > 0 {
+		blockers = append(blockers, blocker{reason: "required checks are still running: " + strings.Join(pending, ", "), soft: true})
+	}
+
+	if info.MergeStateStatus == "BEHIND" {
+		blockers = append(blockers, blocker{reason: "the branch must be up to date with " + info.BaseRefName + "; run pr-update", soft: true})
+	}
+	if info.MergeStateStatus == "BLOCKED" && len(blockers) == 0 {
+		blockers = append(blockers, blocker{reason: "branch protection rules block merging", soft: true})
+	}
+	return blockers
+}
+
+// editMessage lets the user edit the commit title and message in their editor
+func editMessage(ctx context.Context, info *auth.MergeInfo, mopts *auth.MergeOptions) error {
+	title, body := mopts.Title, mopts.Message
+	if title == "" {
+		title = fmt.Sprintf("%s (#%d)", info.Title, info.Number)
+		if mopts.Method == "merge" {
+			title = fmt.Sprintf("Merge pull request #%d from %s", info.Number, info.HeadRefName)
+		}
+	}
+	if body == "" {
+		body = info.Body
+		if mopts.Method == "merge" {
+			body = info.Title
+		}
+	}
+
+	help := fmt.Sprintf("Commit message for the %s of PR #%d. The first line is the title.\n", mopts.Method, info.Number) +
+		"An empty message aborts the merge."
+	text, err := prompt.Edit(ctx, "MERGE_MSG", title+"\n\n"+body, help)
+	if err != nil {
+		return err
+	}
+	if text == "" {
+		return fmt.Errorf("empty commit message; not merging")
+	}
+
+	title, body, _ = strings.Cut(text, "\n")
+	mopts.Title = strings.TrimSpace(title)
+	mopts.Message = strings.TrimSpace(body)
+	return nil
+}
+
+// cleanup deletes the merged PR's head branch on GitHub and locally,
+// switching to the default branch first if the local branch is checked out.
+// The local branch is kept if it has commits the PR doesn't. Failures are
+// reported as warnings since the merge itself succeeded.
+func cleanup(ctx context.Context, repo string, info *auth.MergeInfo, local string) {
+	switch {
+	case info.HeadRepo == "":
+		// The fork is gone, and its branch with it
+	case info.HeadRepo == repo && info.HeadRefName == info.DefaultBranch:
+		fmt.Fprintf(os.Stderr, "Warning: not deleting %s, the default branch\n", info.HeadRefName)
+	case info.DeleteBranchOnMerge && info.HeadRepo == repo:
+		// GitHub deletes it on its own
+	default:
+		if err := auth.DeleteRemoteBranch(ctx, info.HeadRepo, info.HeadRefName); err != nil {
+			fmt.Fprintf(os.Stderr, "Warning: failed to delete %s:%s: %v\n", info.HeadRepo, info.HeadRefName, err)
+		} else {
+			fmt.Printf("Deleted branch %s on %s\n", info.HeadRefName, info.HeadRepo)
+		}
+	}
+
+	if local == "" {
+		local = findLocalBranch(ctx, info)
+	}
+	if local == "" {
+		return
+	}
+
+	defaultBranch, err := git.GetDefaultBranch(ctx)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: not deleting local branch %s: %v\n", local, err)
+		return
+	}
+	if local == defaultBranch {
+		return
+	}
+
+	worktrees, err := git.WorktreeBranches(ctx)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: not deleting local branch %s: %v\n", local, err)
+		return
+	}
+	current, _ := git.GetCurrentBranch(ctx)
+	if path, ok := worktrees[local]; ok && local != current {
+		fmt.Fprintf(os.Stderr, "Warning: not deleting local branch %s, it is checked out in %s\n", local, path)
+		return
+	}
+
+	// Commits made after the last push weren't merged, so keep them. This is
+	// checked before switching away, so the user stays on a branch that is kept.
+	if !git.IsAncestor(ctx, local, info.HeadRefOid) {
+		fmt.Fprintf(os.Stderr, "Warning: not deleting local branch %s: it has commits that aren't in PR #%d\n", local, info.Number)
+		return
+	}
+
+	if local == current {
+		if err := git.Run(ctx, "checkout", defaultBranch); err != nil {
+			fmt.Fprintf(os.Stderr, "Warning: not deleting local branch %s: %v\n", local, err)
+			return
+		}
+		if remote, _, _ := git.GetUpstream(ctx, defaultBranch); remote != "" {
+			if err := git.Run(ctx, "pull", "--ff-only"); err != nil {
+				fmt.Fprintf(os.Stderr, "Warning: %v\n", err)
+			}
+		}
+	}
+
+	if err := git.Run(ctx, "branch", "-D", local); err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: %v\n", err)
+	}
+}
+
+// findLocalBranch returns the local branch that tracks the PR's head, or ""
+func findLocalBranch(ctx context.Context, info *auth.MergeInfo) string {
+	branches, err := git.ListBranches(ctx)
+	if err != nil {
+		return ""
+	}
+	pullRef := fmt.Sprintf("refs/pull/%d/head", info.Number)
+	for _, b := range branches {
+		remote, remoteBranch, err := git.GetUpstream(ctx, b.Name)
+		if err != nil || remote == "" {
+			continue
+		}
+		if remoteBranch == pullRef {
+			return b.Name
+		}
+		if remoteBranch != info.HeadRefName {
+			continue
+		}
+		if r, err := github.GetRemoteRepoInfo(ctx, remote); err == nil && strings.EqualFold(r.Owner+"/"+r.Repo, info.HeadRepo) {
+			return b.Name
+		}
+	}
+	return ""
+}
+
+// shortSHA abbreviates a commit SHA for display
+func shortSHA(sha string) string {
+	if len(sha) > 7 {
+		return sha[:7]
+	}
+	return sha
+}
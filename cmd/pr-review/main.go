@@ -0,0 +1,257 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+
+	"cli-tools/internal/auth"
+	"cli-tools/internal/cli"
+	"cli-tools/internal/git"
+	"cli-tools/internal/github"
+	"cli-tools/internal/picker"
+	"cli-tools/internal/prompt"
+)
+
+func main() {
+	ctx, cancel := cli.Init()
+	defer cancel()
+
+	fs := flag.NewFlagSet("pr-review", flag.ExitOnError)
+	approve := fs.Bool("approve", false, "approve the PR")
+	comment := fs.Bool("comment", false, "leave a review comment without approving")
+	requestChanges := fs.Bool("request-changes", false, "request changes")
+	body := fs.String("body", "", "review `text`")
+	bodyFile := fs.String("body-file", "", "read the review text from `file` (- for stdin)")
+	fs.Usage = func() {
+		fmt.Fprintln(os.Stderr, "Usage: pr-review [number|url] [--approve|--comment|--request-changes] [--body <text>|--body-file <file>]")
+		fmt.Fprintln(os.Stderr, "Without an action, lists the PR's reviews. Without a number, choose from the PRs awaiting your review.")
+		fmt.Fprintln(os.Stderr, "Without --body or --body-file, the review text is written in your editor.")
+		fmt.Fprintln(os.Stderr, "Examples:")
+		fmt.Fprintln(os.Stderr, "  pr-review 123")
+		fmt.Fprintln(os.Stderr, "  pr-review 123 --approve")
+		fmt.Fprintln(os.Stderr, "  pr-review 123 --request-changes --body-file review.md")
+		fmt.Fprintln(os.Stderr, "  pr-review --comment")
+	}
+	args := cli.Parse(fs)
+
+	if !git.IsInsideRepo(ctx) {
+		fmt.Fprintln(os.Stderr, "Error: not inside a git repository")
+		os.Exit(1)
+	}
+
+	if len(args) > 1 || *body != "" && *bodyFile != "" {
+		fs.Usage()
+		os.Exit(1)
+	}
+
+	event := ""
+	for _, e := range []struct {
+		set  bool
+		name string
+	}{{*approve, "APPROVE"}, {*comment, "COMMENT"}, {*requestChanges, "REQUEST_CHANGES"}} {
+		if !e.set {
+			continue
+		}
+		if event != "" {
+			fmt.Fprintln(os.Stderr, "Error: use only one of --approve, --comment and --request-changes")
+			os.Exit(1)
+		}
+		event = e.name
+	}
+	if event == "" && (*body != "" || *bodyFile != "") {
+		fmt.Fprintln(os.Stderr, "Error: --body and --body-file need --approve, --comment or --request-changes")
+		os.Exit(1)
+	}
+
+	arg := ""
+	if len(args) == 1 {
+		arg = args[0]
+	}
+	repo, number, err := resolvePR(ctx, arg)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+
+	if event == "" {
+		err = listReviews(ctx, repo, number)
+	} else {
+		err = submit(ctx, repo, number, event, *body, *bodyFile)
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+}
+
+// resolvePR works out which PR to review: a number on origin, a PR URL, or
+// one of the PRs awaiting your review
+func resolvePR(ctx context.Context, arg string) (string, int, error) {
+	if arg == "" {
+		return pickReviewRequest(ctx)
+	}
+
+	if strings.HasPrefix(arg, "https://") || strings.HasPrefix(arg, "http://") {
+		return github.ParsePRURL(arg)
+	}
+
+	number, err := strconv.Atoi(arg)
+	if err != nil || number <= 0 {
+		return "", 0, fmt.Errorf("invalid PR number: %s", arg)
+	}
+	repo, err := github.GetOwnerRepo(ctx)
+	return repo, number, err
+}
+
+// pickReviewRequest lets the user choose one of the PRs awaiting their review
+func pickReviewRequest(ctx context.Context) (string, int, error) {
+	if !prompt.IsInteractive() {
+		return "", 0, errors.New("no PR given and stdin is not a terminal; pass a PR number")
+	}
+
+	prs, _, err := auth.ListReviewRequests(ctx)
+	if err != nil {
+		return "", 0, err
+	}
+	if len(prs) == 0 {
+		return "", 0, errors.New("no PRs awaiting your review; pass a PR number")
+	}
+
+	options := make([]string, len(prs))
+	for i, pr := range prs {
+		options[i] = fmt.Sprintf("%s#%d %s  @%s", pr.Repo, pr.Number, pr.Title, pr.Author)
+	}
+	idx, err := picker.Pick(ctx, "PRs awaiting your review:", options)
+	if err != nil {
+		return "", 0, err
+	}
+	return prs[idx].Repo, prs[idx].Number, nil
+}
+
+// listReviews prints the reviews of a PR and whose reviews are still pending
+func listReviews(ctx context.Context, repo string, number int) error {
+	pr, err := auth.GetPR(ctx, repo, number)
+	if err != nil {
+		return err
+	}
+	reviews, err := auth.ListReviews(ctx, repo, number)
+	if err != nil {
+		return err
+	}
+	requested, err := auth.RequestedReviewers(ctx, repo, number)
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("PR #%d: %s\n", pr.Number, pr.Title)
+	fmt.Printf("URL: %s\n", pr.HTMLURL)
+	fmt.Println()
+
+	if len(reviews) == 0 {
+		fmt.Println("No reviews yet")
+	} else {
+		width := 0
+		for _, r := range reviews {
+			width = max(width, len(r.User.Login)+1)
+		}
+		fmt.Println("Reviews:")
+		for _, r := range reviews {
+			date, _, _ := strings.Cut(r.SubmittedAt, "T")
+			line := fmt.Sprintf("  %-*s  %-17s  %s", width, "@"+r.User.Login, formatState(r.State), date)
+			if summary := firstLine(r.Body); summary != "" {
+				line += "  " + summary
+			}
+			fmt.Println(strings.TrimRight(line, " "))
+		}
+	}
+
+	if len(requested) > 0 {
+		fmt.Println()
+		fmt.Printf("Waiting on: %s\n", strings.Join(requested, ", "))
+	}
+	return nil
+}
+
+// submit submits a review, taking its text from body, bodyFile or the editor
+func submit(ctx context.Context, repo string, number int, event, body, bodyFile string) error {
+	switch {
+	case bodyFile == "-":
+		data, err := io.ReadAll(os.Stdin)
+		if err != nil {
+			return err
+		}
+		body = string(data)
+	case bodyFile != "":
+		data, err := os.ReadFile(bodyFile)
+		if err != nil {
+			return err
+		}
+		body = string(data)
+	case body == "" && event != "APPROVE":
+		text, err := editBody(ctx, repo, number, event)
+		if err != nil {
+			return err
+		}
+		body = text
+	}
+
+	body = strings.TrimSpace(body)
+	if body == "" && event != "APPROVE" {
+		return errors.New("a review comment is required; use --body, --body-file or write it in the editor")
+	}
+
+	review, err := auth.SubmitReview(ctx, repo, number, event, body)
+	if err != nil {
+		return err
+	}
+	fmt.Printf("Submitted review on PR #%d: %s\n", number, formatState(review.State))
+	if review.HTMLURL != "" {
+		fmt.Println(review.HTMLURL)
+	}
+	return nil
+}
+
+// editBody asks for the review text in the user's editor
+func editBody(ctx context.Context, repo string, number int, event string) (string, error) {
+	help := fmt.Sprintf("Review of %s#%d (%s).\n", repo, number, strings.ToLower(strings.ReplaceAll(event, "_", " "))) +
+		"An empty review is not submitted."
+	text, err := prompt.Edit(ctx, "REVIEW.md", "", help)
+	if errors.Is(err, prompt.ErrNotInteractive) {
+		return "", errors.New("a review comment is required; use --body or --body-file")
+	}
+	return text, err
+}
+
+// formatState renders a review state for display
+func formatState(s string) string {
+	switch s {
+	case "APPROVED":
+		return "approved"
+	case "CHANGES_REQUESTED":
+		return "changes requested"
+	case "COMMENTED":
+		return "commented"
+	case "DISMISSED":
+		return "dismissed"
+	case "PENDING":
+		return "pending"
+	default:
+		return strings.ToLower(s)
+	}
+}
+
+// firstLine returns the first line of a review body, shortened for a listing
+func firstLine(s string) string {
+	line, _, _ := strings.Cut(strings.TrimSpace(s), "\n")
+	line = strings.TrimSpace(line)
+	if r := []rune(line); len(r) > 60 {
+		line = string(r[:59]) + "…"
+	}
+	return line
+}
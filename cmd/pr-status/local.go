@@ -0,0 +1,183 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"strings"
+
+	"cli-tools/internal/format"
+	"cli-tools/internal/git"
+	"cli-tools/internal/github"
+)
+
+// localStatus compares a PR's local branch with what is on GitHub
+type localStatus struct {
+	branch  string
+	current bool // the branch is checked out, so the working tree belongs to it
+
+	upstream           string // remote-tracking branch, "" if none
+	upAhead, upBehind  int
+	headFetched        bool // the PR's head commit exists locally
+	unpushed, unpulled int  // commits relative to the PR head
+	base               string
+	baseBehind         int // commits on the base branch missing from the branch; -1 if unknown
+
+	tree    *git.WorkingTree // nil unless current
+	stashes int
+}
+
+// localBranch returns the local branch whose PR is shown: the current
+// branch, or the branch given as target. It is "" for PR numbers and URLs.
+func localBranch(ctx context.Context, target string) string {
+	if target == "" {
+		branch, err := git.GetCurrentBranch(ctx)
+		if err != nil || branch == "HEAD" {
+			return ""
+		}
+		return branch
+	}
+	if git.BranchExists(ctx, target) {
+		return target
+	}
+	return ""
+}
+
+// withLocal wraps fetch to add the state of the PR's local branch
+func withLocal(fetch fetchFunc, branch string) fetchFunc {
+	return func(ctx context.Context) (*PRInfo, error) {
+		pr, err := fetch(ctx)
+		if err != nil {
+			return nil, err
+		}
+		pr.local = getLocalStatus(ctx, branch, pr)
+		return pr, nil
+	}
+}
+
+// getLocalStatus works out how branch differs from the PR. It is best
+// effort: anything git can't tell is left out.
+func getLocalStatus(ctx context.Context, branch string, pr *PRInfo) *localStatus {
+	l := &localStatus{branch: branch, baseBehind: -1}
+	if current, err := git.GetCurrentBranch(ctx); err == nil && current == branch {
+		l.current = true
+	}
+
+	if l.upstream = git.UpstreamRef(ctx, branch); l.upstream != "" {
+		l.upAhead, l.upBehind, _ = git.AheadBehind(ctx, branch, l.upstream)
+	}
+
+	if pr.HeadRefOid != "" {
+		if _, err := git.ResolveCommit(ctx, pr.HeadRefOid); err == nil {
+			l.headFetched = true
+			l.unpushed, l.unpulled, _ = git.AheadBehind(ctx, branch, pr.HeadRefOid)
+		}
+	}
+
+	// The base branch, as fetched from the remote pointing at the PR's repository
+	if repo, _, err := github.ParsePRURL(pr.URL); err == nil && pr.BaseRefName != "" {
+		if remote, err := github.FindRemote(ctx, repo); err == nil && remote != "" {
+			base := remote + "/" + pr.BaseRefName
+			if _, behind, err := git.AheadBehind(ctx, branch, base); err == nil {
+				l.base, l.baseBehind = base, behind
+			}
+		}
+	}
+
+	if l.current {
+		l.tree, _ = git.GetWorkingTree(ctx)
+		l.stashes, _ = git.StashCount(ctx)
+	}
+	return l
+}
+
+// printLocal writes the "Local" section of the status
+func printLocal(w io.Writer, l *localStatus) {
+	fmt.Fprintf(w, "Local branch %s:\n", l.branch)
+
+	if l.upstream == "" {
+		fmt.Fprintln(w, "  Upstream: none")
+	} else {
+		fmt.Fprintf(w, "  Upstream %s: %s\n", l.upstream, formatAheadBehind(l.upAhead, l.upBehind))
+	}
+
+	switch {
+	case !l.headFetched:
+		fmt.Fprintln(w, "  PR head: not fetched; run git fetch to compare")
+	case l.unpushed == 0 && l.unpulled == 0:
+		fmt.Fprintln(w, "  PR head: same as local")
+	default:
+		var parts []string
+		if l.unpushed > 0 {
+			parts = append(parts, fmt.Sprintf("%s not pushed", commits(l.unpushed)))
+		}
+		if l.unpulled > 0 {
+			parts = append(parts, fmt.Sprintf("%s not pulled", commits(l.unpulled)))
+		}
+		fmt.Fprintf(w, "  PR head: %s\n", strings.Join(parts, ", "))
+	}
+
+	if l.baseBehind > 0 {
+		fmt.Fprintf(w, "  Base %s: %s behind; run pr-update to catch up\n", l.base, commits(l.baseBehind))
+	} else if l.baseBehind == 0 {
+		fmt.Fprintf(w, "  Base %s: up to date\n", l.base)
+	}
+
+	if l.tree != nil {
+		fmt.Fprintf(w, "  Working tree: %s\n", formatWorkingTree(l.tree))
+	}
+	if l.stashes > 0 {
+		fmt.Fprintf(w, "  Stash: %d %s\n", l.stashes, format.Plural(l.stashes, "entry", "entries"))
+	}
+
+	// Warn when the PR isn't what the user has on disk
+	var reasons []string
+	if l.unpushed > 0 {
+		reasons = append(reasons, "unpushed commits")
+	}
+	if l.unpulled > 0 {
+		reasons = append(reasons, "commits you haven't pulled")
+	}
+	if l.tree != nil && !l.tree.Clean() {
+		reasons = append(reasons, "uncommitted changes")
+	}
+	if len(reasons) > 0 {
+		fmt.Fprintf(w, "\nWarning: the PR doesn't match your local branch (%s)\n", strings.Join(reasons, ", "))
+	}
+}
+
+// formatAheadBehind describes how far a branch is from its upstream
+func formatAheadBehind(ahead, behind int) string {
+	switch {
+	case ahead == 0 && behind == 0:
+		return "up to date"
+	case behind == 0:
+		return fmt.Sprintf("%d ahead", ahead)
+	case ahead == 0:
+		return fmt.Sprintf("%d behind", behind)
+	default:
+		return fmt.Sprintf("%d ahead, %d behind", ahead, behind)
+	}
+}
+
+// formatWorkingTree summarizes uncommitted changes, e.g. "1 staged, 2 modified"
+func formatWorkingTree(t *git.WorkingTree) string {
+	var parts []string
+	for _, p := range []struct {
+		n    int
+		what string
+	}{{t.Conflicted, "conflicted"}, {t.Staged, "staged"}, {t.Unstaged, "modified"}, {t.Untracked, "untracked"}} {
+		if p.n > 0 {
+			parts = append(parts, fmt.Sprintf("%d %s", p.n, p.what))
+		}
+	}
+	if len(parts) == 0 {
+		return "clean"
+	}
+	return strings.Join(parts, ", ")
+}
+
+// commits formats a number of commits, e.g. "1 commit" or "3 commits"
+func commits(n int) string {
+	return fmt.Sprintf("%d %s", n, format.Plural(n, "commit", "commits"))
+}
@@ -0,0 +1,99 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"regexp"
+	"strings"
+
+	"cli-tools/internal/auth"
+)
+
+// linesAfterError is how many log lines are kept after the first error
+const linesAfterError = 3
+
+var (
+	// timestampRegex matches the timestamp Actions puts before each log line
+	timestampRegex = regexp.MustCompile(`^\d{4}-\d\d-\d\dT[\d:.]+Z ?`)
+	// errorRegex matches lines that look like errors
+	errorRegex = regexp.MustCompile(`(?i)\b(error|fail|failed|failure|panic|fatal)\b`)
+)
+
+// printFailedLogs prints an excerpt of the log of each failed Actions job
+// of the PR
+func printFailedLogs(ctx context.Context, w io.Writer, pr *PRInfo, lines int) {
+	for _, c := range pr.StatusCheckRollup {
+		if checkState(c.Result()) != checksFailed {
+			continue
+		}
+
+		fmt.Fprintln(w)
+		fmt.Fprintf(w, "==> %s (%s)\n", c.DisplayName(), strings.ToLower(c.Result()))
+		repo, jobID, ok := auth.ParseJobURL(c.URL())
+		if !ok {
+			if c.URL() != "" {
+				fmt.Fprintf(w, "Not a GitHub Actions job; see %s\n", c.URL())
+			} else {
+				fmt.Fprintln(w, "Not a GitHub Actions job and no details URL")
+			}
+			continue
+		}
+
+		log, err := auth.GetJobLog(ctx, repo, jobID)
+		if err != nil {
+			fmt.Fprintf(w, "Cannot download the log: %v\n", err)
+			continue
+		}
+		for _, line := range excerpt(log, lines) {
+			fmt.Fprintln(w, line)
+		}
+	}
+}
+
+// firstError returns the index of the first line that looks like an error,
+// or -1. Actions marks a failed step with a "##[error]" line at its end, so
+// the first error is looked for between the start of that step and there.
+func firstError(lines []string) int {
+	marker := -1
+	for i, line := range lines {
+		if strings.HasPrefix(line, "##[error]") {
+			marker = i
+			break
+		}
+	}
+
+	start, end := 0, len(lines)
+	if marker >= 0 {
+		end = marker + 1
+		for i := marker; i >= 0; i-- {
+			if strings.HasPrefix(lines[i], "##[group]Run ") {
+				start = i
+				break
+			}
+		}
+	}
+	for i := start; i < end; i++ {
+		if i == marker || errorRegex.MatchString(lines[i]) {
+			return i
+		}
+	}
+	return -1
+}
+
+// excerpt returns up to n lines of a job log, ending a few lines after the
+// first error, or the last n lines if no error stands out
+func excerpt(log string, n int) []string {
+	lines := strings.Split(strings.TrimRight(strings.ReplaceAll(log, "\r\n", "\n"), "\n"), "\n")
+	for i, line := range lines {
+		lines[i] = timestampRegex.ReplaceAllString(line, "")
+	}
+
+	first := firstError(lines)
+	end := len(lines)
+	if first >= 0 {
+		// Keep the error itself in view even when n is small
+		end = min(len(lines), first+1+min(linesAfterError, n/2))
+	}
+	return lines[max(0, end-n):end]
+}
@@ -1,143 +1,528 @@
 package main
 
 import (
+	"context"
 	"encoding/json"
+	"errors"
+	"flag"
 	"fmt"
+	"io"
 	"os"
 	"os/exec"
+	"strconv"
 	"strings"
+	"time"
 
 	"cli-tools/internal/auth"
+	"cli-tools/internal/cli"
 	"cli-tools/internal/git"
 	"cli-tools/internal/github"
+	"cli-tools/internal/trace"
 )
 
+// errNoPR is returned when the current branch has no PR
+var errNoPR = errors.New("no PR found for current branch")
+
+// fetchFunc gets the current status of the PR being shown
+type fetchFunc func(ctx context.Context) (*PRInfo, error)
+
+// PRInfo is the output of `gh pr view --json`. Without gh it is put
+// together from the REST API.
 type PRInfo struct {
-	Number    int    `json:"number"`
-	Title     string `json:"title"`
-	State     string `json:"state"`
-	URL       string `json:"url"`
-	Mergeable string `json:"mergeable"`
-	Reviews   struct {
-		TotalCount int `json:"totalCount"`
-	} `json:"reviews"`
-	ReviewDecision   string `json:"reviewDecision"`
-	StatusCheckRollup struct {
-		Contexts []struct {
-			State      string `json:"state"`
-			Conclusion string `json:"conclusion"`
-			Name       string `json:"name"`
-		} `json:"contexts"`
-	} `json:"statusCheckRollup"`
+	Number            int            `json:"number"`
+	Title             string         `json:"title"`
+	State             string         `json:"state"` // OPEN, CLOSED or MERGED
+	URL               string         `json:"url"`
+	Mergeable         string         `json:"mergeable"`        // MERGEABLE, CONFLICTING or UNKNOWN
+	MergeStateStatus  string         `json:"mergeStateStatus"` // CLEAN, BEHIND, BLOCKED, DIRTY, UNSTABLE, ...
+	Labels            []PRLabel      `json:"labels"`
+	Reviews           []PRReview     `json:"reviews"`
+	ReviewDecision    string         `json:"reviewDecision"`
+	StatusCheckRollup []CheckContext `json:"statusCheckRollup"`
+	HeadRefOid        string         `json:"headRefOid"`
+	BaseRefName       string         `json:"baseRefName"`
+
+	// requiredKnown is set once the checks' Required flags are filled in
+	requiredKnown bool
+	// local is the state of the PR's local branch, if there is one
+	local *localStatus
+}
+
+// PRLabel is a label on the PR
+type PRLabel struct {
+	Name string `json:"name"`
+}
+
+// PRReview is a submitted review
+type PRReview struct {
+	Author struct {
+		Login string `json:"login"`
+	} `json:"author"`
+	State string `json:"state"` // APPROVED, CHANGES_REQUESTED, COMMENTED or DISMISSED
+}
+
+// CheckContext is a check run or a commit status of the PR's head commit
+type CheckContext struct {
+	Typename    string `json:"__typename"` // CheckRun or StatusContext
+	Name        string `json:"name"`
+	Context     string `json:"context"` // name of a commit status
+	Status      string `json:"status"`  // QUEUED, IN_PROGRESS or COMPLETED for check runs
+	Conclusion  string `json:"conclusion"`
+	State       string `json:"state"` // state of a commit status
+	StartedAt   string `json:"startedAt"`
+	CompletedAt string `json:"completedAt"`
+	DetailsURL  string `json:"detailsUrl"` // check runs
+	TargetURL   string `json:"targetUrl"`  // commit statuses
+	Required    bool   `json:"-"`
+}
+
+// DisplayName returns the check's name
+func (c CheckContext) DisplayName() string {
+	if c.Name != "" {
+		return c.Name
+	}
+	return c.Context
+}
+
+// Result returns the conclusion of a finished check run, the state of a
+// commit status, or the status of a check run that is still going
+func (c CheckContext) Result() string {
+	if c.Conclusion != "" {
+		return c.Conclusion
+	}
+	if c.State != "" {
+		return c.State
+	}
+	return c.Status
+}
+
+// URL returns the page with the check's details, if any
+func (c CheckContext) URL() string {
+	if c.DetailsURL != "" {
+		return c.DetailsURL
+	}
+	return c.TargetURL
+}
+
+// Duration returns how long the check ran, or has been running so far.
+// It is empty for checks that haven't started or don't report times.
+func (c CheckContext) Duration(now time.Time) string {
+	start, err := time.Parse(time.RFC3339, c.StartedAt)
+	if err != nil || start.IsZero() || start.Year() < 2000 {
+		return ""
+	}
+	end, err := time.Parse(time.RFC3339, c.CompletedAt)
+	if err != nil || end.Before(start) {
+		end = now
+		if c.Typename == "StatusContext" {
+			// Commit statuses only report when they were last updated
+			return ""
+		}
+	}
+	return end.Sub(start).Round(time.Second).String()
 }
 
 func main() {
-	if !git.IsInsideRepo() {
-		fmt.Fprintln(os.Stderr, "Error: not inside a git repository")
+	ctx, cancel := cli.Init()
+	defer cancel()
+
+	fs := flag.NewFlagSet("pr-status", flag.ExitOnError)
+	watch := fs.Bool("watch", false, "refresh until all checks finish; exit non-zero if any fail")
+	interval := fs.Duration("interval", 10*time.Second, "time between refreshes with --watch")
+	bell := fs.Bool("bell", false, "ring the terminal bell when --watch finishes")
+	notify := fs.Bool("notify", false, "send a desktop notification (notify-send) when --watch finishes")
+	logs := fs.Bool("logs", false, "print the log excerpts of failed GitHub Actions jobs")
+	logLines := fs.Int("log-lines", 40, "lines of each failed job's log to print with --logs")
+	allMine := fs.Bool("all-mine", false, "list the status of all your open PRs, across repositories")
+	fs.Usage = func() {
+		fmt.Fprintln(os.Stderr, "Usage: pr-status [number|url|branch] [--logs [--log-lines <n>]] [--watch [--interval <duration>] [--bell] [--notify]]")
+		fmt.Fprintln(os.Stderr, "       pr-status --all-mine")
+		fmt.Fprintln(os.Stderr, "Shows the status of a PR: mergeability, reviews and checks. Without a target, uses the current branch's PR.")
+		fmt.Fprintln(os.Stderr, "With --watch, exits 0 once all checks pass and 1 if any fail.")
+		fmt.Fprintln(os.Stderr, "With --logs, prints the log of each failed Actions job up to its first error.")
+		fmt.Fprintln(os.Stderr, "Examples:")
+		fmt.Fprintln(os.Stderr, "  pr-status")
+		fmt.Fprintln(os.Stderr, "  pr-status 123")
+		fmt.Fprintln(os.Stderr, "  pr-status feature/login --logs")
+		fmt.Fprintln(os.Stderr, "  pr-status --watch --notify")
+		fmt.Fprintln(os.Stderr, "  pr-status --watch --interval 30s && pr-merge --squash")
+		fmt.Fprintln(os.Stderr, "  pr-status --all-mine")
+	}
+	args := cli.Parse(fs)
+	if len(args) > 1 {
+		fs.Usage()
 		os.Exit(1)
 	}
+	target := ""
+	if len(args) == 1 {
+		target = args[0]
+	}
 
-	if auth.HasGhCLI() {
-		showPRStatusWithGh()
+	if *allMine {
+		if target != "" || *watch || *logs {
+			fmt.Fprintln(os.Stderr, "Error: --all-mine can't be combined with a target, --watch or --logs")
+			os.Exit(1)
+		}
+		if err := showAllMine(ctx); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
 		return
 	}
+	if *logLines < 1 {
+		fmt.Fprintln(os.Stderr, "Error: --log-lines must be at least 1")
+		os.Exit(1)
+	}
+	if *interval < time.Second {
+		fmt.Fprintln(os.Stderr, "Error: --interval must be at least 1s")
+		os.Exit(1)
+	}
 
-	// Fallback without gh CLI
-	showPRStatusWithAPI()
-}
+	if !git.IsInsideRepo(ctx) {
+		fmt.Fprintln(os.Stderr, "Error: not inside a git repository")
+		os.Exit(1)
+	}
 
-func showPRStatusWithGh() {
-	// Get detailed PR info
-	cmd := exec.Command("gh", "pr", "view", "--json",
-		"number,title,state,url,mergeable,reviews,reviewDecision,statusCheckRollup")
-	out, err := cmd.Output()
+	url, err := resolveTarget(ctx, target)
 	if err != nil {
-		if exitErr, ok := err.(*exec.ExitError); ok {
-			fmt.Fprintln(os.Stderr, strings.TrimSpace(string(exitErr.Stderr)))
-		} else {
-			fmt.Fprintln(os.Stderr, "No PR found for current branch")
-		}
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
 		os.Exit(1)
 	}
 
-	var pr PRInfo
-	if err := json.Unmarshal(out, &pr); err != nil {
-		fmt.Fprintf(os.Stderr, "Error parsing PR info: %v\n", err)
+	fetch := ghFetcher(url)
+	hasGh := auth.HasGhCLI(ctx)
+	if !hasGh {
+		// Fallback without gh CLI
+		fetch = apiFetcher(url)
+	}
+	if branch := localBranch(ctx, target); branch != "" {
+		fetch = withLocal(fetch, branch)
+	}
+
+	opts := watchOptions{interval: *interval, bell: *bell, notify: *notify}
+	if *logs {
+		opts.logLines = *logLines
+	}
+	if *watch {
+		os.Exit(watchPR(ctx, fetch, opts))
+	}
+
+	pr, err := fetch(ctx)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		if !hasGh && !errors.Is(err, errNoPR) && ctx.Err() == nil {
+			fmt.Fprintln(os.Stderr, "")
+			fmt.Fprint(os.Stderr, auth.AuthSetupMessage())
+		}
 		os.Exit(1)
 	}
+	printStatus(os.Stdout, pr, nil, false)
+	if opts.logLines > 0 {
+		printFailedLogs(ctx, os.Stdout, pr, opts.logLines)
+	}
+}
 
-	// Display PR status
-	fmt.Printf("PR #%d: %s\n", pr.Number, pr.Title)
-	fmt.Printf("State: %s\n", pr.State)
-	fmt.Printf("URL: %s\n", pr.URL)
-	fmt.Println()
+// resolveTarget turns a PR number, URL or branch name into the PR's URL.
+// An empty target stays empty, meaning the current branch's PR.
+func resolveTarget(ctx context.Context, target string) (string, error) {
+	if target == "" {
+		return "", nil
+	}
+	if strings.HasPrefix(target, "https://") || strings.HasPrefix(target, "http://") {
+		_, _, err := github.ParsePRURL(target)
+		return target, err
+	}
+	if num, err := strconv.Atoi(target); err == nil {
+		if num <= 0 {
+			return "", fmt.Errorf("PR number must be a positive integer")
+		}
+		return github.BuildURL(ctx, fmt.Sprintf("/pull/%d", num))
+	}
 
-	// Mergeable status
-	fmt.Printf("Mergeable: %s\n", formatMergeable(pr.Mergeable))
+	prs, err := auth.FindPRsForBranch(ctx, target)
+	if err != nil {
+		return "", err
+	}
+	pr, err := auth.ChoosePR(ctx, prs)
+	if err != nil {
+		return "", err
+	}
+	if pr == nil {
+		return "", fmt.Errorf("no PR found for branch %s", target)
+	}
+	return pr.HTMLURL, nil
+}
 
-	// Review status
-	fmt.Printf("Reviews: %d (%s)\n", pr.Reviews.TotalCount, formatReviewDecision(pr.ReviewDecision))
+// ghFetcher returns a fetchFunc that asks gh for the status of the PR at
+// url, or of the current branch's PR if url is empty
+func ghFetcher(url string) fetchFunc {
+	return func(ctx context.Context) (*PRInfo, error) {
+		args := []string{"pr", "view"}
+		if url != "" {
+			args = append(args, url)
+		}
+		args = append(args, "--json", "number,title,state,url,mergeable,mergeStateStatus,labels,reviews,reviewDecision,statusCheckRollup,headRefOid,baseRefName")
+		cmd := exec.CommandContext(ctx, "gh", args...)
+		out, err := trace.Output(cmd)
+		if err != nil {
+			if ctx.Err() != nil {
+				return nil, context.Cause(ctx)
+			}
+			if exitErr, ok := err.(*exec.ExitError); ok && len(exitErr.Stderr) > 0 {
+				return nil, errors.New(strings.TrimSpace(string(exitErr.Stderr)))
+			}
+			return nil, errNoPR
+		}
 
-	// CI status
-	if len(pr.StatusCheckRollup.Contexts) > 0 {
-		fmt.Println()
-		fmt.Println("Checks:")
-		for _, check := range pr.StatusCheckRollup.Contexts {
-			status := check.Conclusion
-			if status == "" {
-				status = check.State
+		var pr PRInfo
+		if err := json.Unmarshal(out, &pr); err != nil {
+			return nil, fmt.Errorf("failed to parse PR info: %w", err)
+		}
+		addMergeInfo(ctx, &pr)
+		return &pr, nil
+	}
+}
+
+// apiFetcher returns a fetchFunc that uses the REST API, for the PR at url
+// or the current branch's PR. The current branch's PR is looked up once,
+// so --watch doesn't ask again which PR to show when several match.
+func apiFetcher(url string) fetchFunc {
+	var repo string
+	var number int
+	return func(ctx context.Context) (*PRInfo, error) {
+		switch {
+		case number > 0:
+		case url != "":
+			var err error
+			if repo, number, err = github.ParsePRURL(url); err != nil {
+				return nil, err
+			}
+		default:
+			pr, err := auth.GetCurrentPullRequest(ctx)
+			if err != nil {
+				return nil, err
+			}
+			if pr == nil {
+				return nil, errNoPR
 			}
-			fmt.Printf("  %s %s\n", formatCheckStatus(status), check.Name)
+			repo, number = pr.BaseRepo(), pr.Number
 		}
+		return fetchPRInfoWithAPI(ctx, repo, number)
 	}
 }
 
-func showPRStatusWithAPI() {
-	// Get current branch
-	branch, err := git.GetCurrentBranch()
+// fetchPRInfoWithAPI gets the status of PR number in repo from the REST API:
+// the PR itself, its reviews, and the check runs and commit statuses of its
+// head commit
+func fetchPRInfoWithAPI(ctx context.Context, repo string, number int) (*PRInfo, error) {
+	pr, err := auth.GetPR(ctx, repo, number)
 	if err != nil {
-		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
-		os.Exit(1)
+		return nil, err
 	}
-
-	ownerRepo, err := github.GetOwnerRepo()
+	reviews, err := auth.ListReviews(ctx, repo, number)
 	if err != nil {
-		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
-		os.Exit(1)
+		return nil, err
+	}
+	runs, err := auth.ListCheckRuns(ctx, repo, pr.Head.SHA)
+	if err != nil {
+		return nil, err
+	}
+	statuses, err := auth.ListCommitStatuses(ctx, repo, pr.Head.SHA)
+	if err != nil {
+		return nil, err
 	}
 
-	// Search for PR
-	endpoint := fmt.Sprintf("/repos/%s/pulls?head=%s&state=open", ownerRepo, branch)
-	data, err := auth.APIRequest("GET", endpoint, nil)
+	info := &PRInfo{
+		Number:      pr.Number,
+		Title:       pr.Title,
+		State:       strings.ToUpper(pr.State),
+		URL:         pr.HTMLURL,
+		Mergeable:   "UNKNOWN",
+		HeadRefOid:  pr.Head.SHA,
+		BaseRefName: pr.Base.Ref,
+	}
+	if pr.MergedAt != "" {
+		info.State = "MERGED"
+	}
+	if pr.Mergeable != nil {
+		// GitHub only reports a PR as unmergeable when it has conflicts
+		info.Mergeable = "CONFLICTING"
+		if *pr.Mergeable {
+			info.Mergeable = "MERGEABLE"
+		}
+	}
+	for _, l := range pr.Labels {
+		info.Labels = append(info.Labels, PRLabel{Name: l.Name})
+	}
+
+	for _, r := range reviews {
+		if r.State == "PENDING" {
+			continue
+		}
+		review := PRReview{State: r.State}
+		review.Author.Login = r.User.Login
+		info.Reviews = append(info.Reviews, review)
+	}
+
+	for _, r := range runs {
+		c := CheckContext{
+			Typename:    "CheckRun",
+			Name:        r.Name,
+			Status:      strings.ToUpper(r.Status),
+			Conclusion:  strings.ToUpper(r.Conclusion),
+			StartedAt:   r.StartedAt,
+			CompletedAt: r.CompletedAt,
+			DetailsURL:  r.DetailsURL,
+		}
+		if c.DetailsURL == "" {
+			c.DetailsURL = r.HTMLURL
+		}
+		info.StatusCheckRollup = append(info.StatusCheckRollup, c)
+	}
+	for _, s := range statuses {
+		info.StatusCheckRollup = append(info.StatusCheckRollup, CheckContext{
+			Typename:  "StatusContext",
+			Context:   s.Context,
+			State:     strings.ToUpper(s.State),
+			StartedAt: s.CreatedAt,
+			TargetURL: s.TargetURL,
+		})
+	}
+
+	addMergeInfo(ctx, info)
+	return info, nil
+}
+
+// latestReviews returns each reviewer's current review, in the order they
+// first reviewed. Approvals, change requests and dismissals replace
+// earlier reviews; comments only count if the reviewer left nothing else.
+func latestReviews(reviews []PRReview) []PRReview {
+	var latest []PRReview
+	index := make(map[string]int)
+	for _, r := range reviews {
+		i, seen := index[r.Author.Login]
+		switch {
+		case !seen:
+			index[r.Author.Login] = len(latest)
+			latest = append(latest, r)
+		case r.State != "COMMENTED":
+			latest[i] = r
+		}
+	}
+	return latest
+}
+
+// addMergeInfo flags the checks that branch protection requires, and fills
+// in the review decision and merge state, which the REST API doesn't report.
+// gh doesn't report required checks either, so they are looked up
+// separately; if that fails the flags are simply not shown.
+func addMergeInfo(ctx context.Context, pr *PRInfo) {
+	repo, number, err := github.ParsePRURL(pr.URL)
 	if err != nil {
-		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
-		fmt.Fprintln(os.Stderr, "")
-		fmt.Print(auth.AuthSetupMessage())
-		os.Exit(1)
+		return
 	}
+	info, err := auth.GetMergeInfo(ctx, repo, number)
+	if err != nil {
+		return
+	}
+	pr.Mergeable = info.Mergeable
+	pr.MergeStateStatus = info.MergeStateStatus
+	pr.ReviewDecision = info.ReviewDecision
 
-	var prs []struct {
-		Number int    `json:"number"`
-		Title  string `json:"title"`
-		State  string `json:"state"`
-		URL    string `json:"html_url"`
+	required := make(map[string]bool)
+	for _, c := range info.Checks {
+		if c.Required {
+			required[c.Name] = true
+		}
 	}
-	if err := json.Unmarshal(data, &prs); err != nil {
-		fmt.Fprintf(os.Stderr, "Error parsing response: %v\n", err)
-		os.Exit(1)
+	for i := range pr.StatusCheckRollup {
+		pr.StatusCheckRollup[i].Required = required[pr.StatusCheckRollup[i].DisplayName()]
+	}
+	pr.requiredKnown = true
+}
+
+// printStatus writes the PR's status. Checks named in changed are
+// highlighted along with their previous result.
+func printStatus(w io.Writer, pr *PRInfo, changed map[string]string, color bool) {
+	fmt.Fprintf(w, "PR #%d: %s\n", pr.Number, pr.Title)
+	fmt.Fprintf(w, "State: %s\n", pr.State)
+	fmt.Fprintf(w, "URL: %s\n", pr.URL)
+	if len(pr.Labels) > 0 {
+		names := make([]string, len(pr.Labels))
+		for i, l := range pr.Labels {
+			names[i] = l.Name
+		}
+		fmt.Fprintf(w, "Labels: %s\n", strings.Join(names, ", "))
+	}
+	fmt.Fprintln(w)
+
+	// Mergeable status
+	fmt.Fprintf(w, "Mergeable: %s\n", formatMergeState(pr))
+	switch {
+	case pr.Mergeable == "CONFLICTING" || pr.MergeStateStatus == "DIRTY":
+		fmt.Fprintln(w, "  Run pr-update to merge in the base branch and resolve them")
+	case pr.MergeStateStatus == "BEHIND":
+		fmt.Fprintln(w, "  Run pr-update to bring it up to date")
 	}
 
-	if len(prs) == 0 {
-		fmt.Println("No open PR found for current branch")
-		os.Exit(0)
+	// Review status
+	fmt.Fprintf(w, "Reviews: %d (%s)\n", len(pr.Reviews), formatReviewDecision(pr.ReviewDecision))
+	latest := latestReviews(pr.Reviews)
+	width := 0
+	for _, r := range latest {
+		width = max(width, len(r.Author.Login)+1)
+	}
+	for _, r := range latest {
+		fmt.Fprintf(w, "  %-*s  %s\n", width, "@"+r.Author.Login, formatReviewState(r.State))
 	}
 
-	pr := prs[0]
-	fmt.Printf("PR #%d: %s\n", pr.Number, pr.Title)
-	fmt.Printf("State: %s\n", pr.State)
-	fmt.Printf("URL: %s\n", pr.URL)
-	fmt.Println()
-	fmt.Println("(Install gh CLI for more detailed status)")
+	// CI status
+	if len(pr.StatusCheckRollup) > 0 {
+		fmt.Fprintln(w)
+		fmt.Fprintln(w, "Checks:")
+		printChecks(w, pr, changed, color)
+	}
+
+	if pr.local != nil {
+		fmt.Fprintln(w)
+		printLocal(w, pr.local)
+	}
+}
+
+// printChecks lists the PR's checks in columns: result, name, duration,
+// whether the check is required, and its details URL
+func printChecks(w io.Writer, pr *PRInfo, changed map[string]string, color bool) {
+	now := time.Now()
+	nameWidth, durationWidth := 0, 0
+	for _, c := range pr.StatusCheckRollup {
+		nameWidth = max(nameWidth, len(c.DisplayName()))
+		durationWidth = max(durationWidth, len(c.Duration(now)))
+	}
+
+	for _, c := range pr.StatusCheckRollup {
+		line := fmt.Sprintf("  %-6s %-*s", formatCheckStatus(c.Result()), nameWidth, c.DisplayName())
+		if durationWidth > 0 {
+			line += fmt.Sprintf("  %-*s", durationWidth, c.Duration(now))
+		}
+		if pr.requiredKnown {
+			if c.Required {
+				line += "  required"
+			} else {
+				line += "  optional"
+			}
+		}
+		if url := c.URL(); url != "" {
+			line += "  " + url
+		}
+		if prev, ok := changed[c.DisplayName()]; ok && prev != "" {
+			line += fmt.Sprintf("  (was %s)", formatCheckStatus(prev))
+		}
+		line = strings.TrimRight(line, " ")
+		if _, ok := changed[c.DisplayName()]; ok {
+			line = highlight(line, c.Result(), color)
+		}
+		fmt.Fprintln(w, line)
+	}
 }
 
 func formatMergeable(s string) string {
@@ -153,6 +538,24 @@ func formatMergeable(s string) string {
 	}
 }
 
+// formatMergeState describes whether the PR can be merged, using GitHub's
+// merge state when known
+func formatMergeState(pr *PRInfo) string {
+	switch pr.MergeStateStatus {
+	case "DIRTY":
+		return "No (conflicts)"
+	case "BEHIND":
+		return fmt.Sprintf("No (behind %s)", pr.BaseRefName)
+	case "BLOCKED":
+		return "No (blocked by branch protection)"
+	case "DRAFT":
+		return "No (draft)"
+	case "UNSTABLE":
+		return "Yes (with failing checks)"
+	}
+	return formatMergeable(pr.Mergeable)
+}
+
 func formatReviewDecision(s string) string {
 	switch s {
 	case "APPROVED":
@@ -166,6 +569,21 @@ func formatReviewDecision(s string) string {
 	}
 }
 
+func formatReviewState(s string) string {
+	switch s {
+	case "APPROVED":
+		return "approved"
+	case "CHANGES_REQUESTED":
+		return "changes requested"
+	case "COMMENTED":
+		return "commented"
+	case "DISMISSED":
+		return "dismissed"
+	default:
+		return strings.ToLower(s)
+	}
+}
+
 func formatCheckStatus(s string) string {
 	switch strings.ToUpper(s) {
 	case "SUCCESS":
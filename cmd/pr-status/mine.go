@@ -0,0 +1,57 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+
+	"cli-tools/internal/auth"
+	"cli-tools/internal/format"
+)
+
+// maxMine is how many PRs --all-mine lists
+const maxMine = 50
+
+// maxTitle is the longest title --all-mine shows before shortening it
+const maxTitle = 50
+
+// showAllMine prints a table with the status of each of your open PRs
+func showAllMine(ctx context.Context) error {
+	prs, total, err := auth.ListMyOpenPRs(ctx, maxMine)
+	if err != nil {
+		return err
+	}
+	if len(prs) == 0 {
+		fmt.Println("No open PRs found")
+		return nil
+	}
+
+	rows := [][]string{{"PR", "TITLE", "MERGEABLE", "REVIEW", "CHECKS"}}
+	for _, pr := range prs {
+		title := pr.Title
+		if pr.IsDraft {
+			title = "[draft] " + title
+		}
+		if r := []rune(title); len(r) > maxTitle {
+			title = string(r[:maxTitle-1]) + "…"
+		}
+		checks := "-"
+		if pr.CheckState != "" {
+			checks = formatCheckStatus(pr.CheckState)
+		}
+		rows = append(rows, []string{
+			fmt.Sprintf("%s#%d", pr.Repo, pr.Number),
+			title,
+			formatMergeable(pr.Mergeable),
+			formatReviewDecision(pr.ReviewDecision),
+			checks,
+		})
+	}
+
+	format.Table(os.Stdout, rows)
+
+	if total > len(prs) {
+		fmt.Printf("\nShowing %d of %d open PRs\n", len(prs), total)
+	}
+	return nil
+}
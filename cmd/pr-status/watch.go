@@ -0,0 +1,231 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+	"time"
+
+	"cli-tools/internal/pager"
+	"cli-tools/internal/trace"
+)
+
+// noChecksGrace is how long --watch waits for checks to show up on a PR
+// that has none, e.g. right after a push
+const noChecksGrace = time.Minute
+
+// maxChanges is how many of the latest check transitions --watch lists
+const maxChanges = 10
+
+// ANSI sequences for redrawing and highlighting
+const (
+	clearScreen = "\x1b[H\x1b[2J"
+	colorReset  = "\x1b[0m"
+	colorBold   = "\x1b[1m"
+	colorRed    = "\x1b[31m"
+	colorGreen  = "\x1b[32m"
+	colorYellow = "\x1b[33m"
+)
+
+// watchOptions controls pr-status --watch
+type watchOptions struct {
+	interval time.Duration
+	bell     bool
+	notify   bool
+	logLines int // print failed job logs with this many lines; 0 to skip
+}
+
+// checkOutcome summarizes the checks of a PR
+type checkOutcome int
+
+const (
+	checksPending checkOutcome = iota
+	checksPassed
+	checksFailed
+	checksNone
+)
+
+// watchPR refreshes the PR status every opts.interval until all checks have
+// finished, and returns the exit code: 0 if they passed, 1 otherwise. On a
+// terminal the status is redrawn in place; otherwise only changes are
+// printed after the first status.
+func watchPR(ctx context.Context, fetch fetchFunc, opts watchOptions) int {
+	terminal := pager.IsTerminal(os.Stdout)
+	color := terminal && os.Getenv("NO_COLOR") == ""
+
+	var prev map[string]string
+	var changes []string
+	var fetchErr error
+	var pr *PRInfo
+	start := time.Now()
+
+	for {
+		latest, err := fetch(ctx)
+		switch {
+		case ctx.Err() != nil:
+			fmt.Fprintf(os.Stderr, "Error: %v\n", context.Cause(ctx))
+			return 1
+		case err != nil && pr == nil:
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			return 1
+		case err != nil:
+			// Keep showing the last status through network hiccups
+			fetchErr = err
+		default:
+			pr, fetchErr = latest, nil
+		}
+
+		results := make(map[string]string)
+		changed := make(map[string]string)
+		for _, c := range pr.StatusCheckRollup {
+			name := c.DisplayName()
+			results[name] = c.Result()
+			if old, seen := prev[name]; prev != nil && (!seen || old != c.Result()) {
+				changed[name] = old
+				changes = append(changes, formatChange(name, old, c.Result()))
+			}
+		}
+		if len(changes) > maxChanges {
+			changes = changes[len(changes)-maxChanges:]
+		}
+
+		outcome := summarize(pr)
+		if outcome == checksNone && time.Since(start) < noChecksGrace {
+			outcome = checksPending
+		}
+		done := outcome != checksPending || pr.State != "OPEN"
+
+		if terminal {
+			var buf bytes.Buffer
+			buf.WriteString(clearScreen)
+			printStatus(&buf, pr, changed, color)
+			if len(changes) > 0 {
+				fmt.Fprintln(&buf)
+				fmt.Fprintln(&buf, "Changes:")
+				for _, c := range changes {
+					fmt.Fprintf(&buf, "  %s\n", c)
+				}
+			}
+			fmt.Fprintln(&buf)
+			if fetchErr != nil {
+				fmt.Fprintf(&buf, "Refresh failed: %v\n", fetchErr)
+			}
+			if !done {
+				fmt.Fprintf(&buf, "Updated %s, refreshing every %s. Press Ctrl-C to stop.\n", time.Now().Format("15:04:05"), opts.interval)
+			}
+			os.Stdout.Write(buf.Bytes())
+		} else if prev == nil {
+			printStatus(os.Stdout, pr, nil, false)
+			fmt.Println()
+		} else {
+			for _, c := range pr.StatusCheckRollup {
+				if old, ok := changed[c.DisplayName()]; ok {
+					fmt.Printf("%s  %s\n", time.Now().Format("15:04:05"), formatChange(c.DisplayName(), old, c.Result()))
+				}
+			}
+		}
+		prev = results
+
+		if done {
+			return finish(ctx, pr, outcome, opts)
+		}
+
+		select {
+		case <-ctx.Done():
+			fmt.Fprintf(os.Stderr, "Error: %v\n", context.Cause(ctx))
+			return 1
+		case <-time.After(opts.interval):
+		}
+	}
+}
+
+// finish reports how the watch ended and returns the exit code
+func finish(ctx context.Context, pr *PRInfo, outcome checkOutcome, opts watchOptions) int {
+	var msg string
+	code := 0
+	switch {
+	case pr.State == "MERGED":
+		msg = "was merged"
+	case pr.State == "CLOSED":
+		msg, code = "was closed", 1
+	case outcome == checksPassed:
+		msg = "checks passed"
+	case outcome == checksNone:
+		msg = "has no checks"
+	default:
+		msg, code = "checks failed", 1
+	}
+	summary := fmt.Sprintf("PR #%d %s", pr.Number, msg)
+	fmt.Println(summary)
+
+	if code != 0 && opts.logLines > 0 {
+		printFailedLogs(ctx, os.Stdout, pr, opts.logLines)
+	}
+	if opts.bell {
+		fmt.Fprint(os.Stderr, "\a")
+	}
+	if opts.notify {
+		// Best effort: not every desktop has a notification daemon
+		cmd := exec.CommandContext(ctx, "notify-send", "--app-name=pr-status", summary, pr.Title)
+		if err := trace.Run(cmd); err != nil {
+			fmt.Fprintf(os.Stderr, "Warning: notify-send failed: %v\n", err)
+		}
+	}
+	return code
+}
+
+// summarize reports whether the PR's checks are still running, all passed,
+// or any failed. A failure is only final once every check has finished.
+func summarize(pr *PRInfo) checkOutcome {
+	if len(pr.StatusCheckRollup) == 0 {
+		return checksNone
+	}
+	outcome := checksPassed
+	for _, c := range pr.StatusCheckRollup {
+		switch checkState(c.Result()) {
+		case checksPending:
+			return checksPending
+		case checksFailed:
+			outcome = checksFailed
+		}
+	}
+	return outcome
+}
+
+// checkState classifies a check result as pending, passed or failed
+func checkState(result string) checkOutcome {
+	switch strings.ToUpper(result) {
+	case "SUCCESS", "NEUTRAL", "SKIPPED":
+		return checksPassed
+	case "", "PENDING", "EXPECTED", "QUEUED", "IN_PROGRESS", "WAITING", "REQUESTED":
+		return checksPending
+	default:
+		return checksFailed
+	}
+}
+
+// formatChange describes a check's transition from old to result
+func formatChange(name, old, result string) string {
+	if old == "" {
+		return fmt.Sprintf("%s: %s", name, formatCheckStatus(result))
+	}
+	return fmt.Sprintf("%s: %s -> %s", name, formatCheckStatus(old), formatCheckStatus(result))
+}
+
+// highlight colors a line by the check result it shows
+func highlight(line, result string, color bool) string {
+	if !color {
+		return line
+	}
+	code := colorYellow
+	switch checkState(result) {
+	case checksPassed:
+		code = colorGreen
+	case checksFailed:
+		code = colorRed
+	}
+	return colorBold + code + line + colorReset
+}
@@ -0,0 +1,213 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+
+	"cli-tools/internal/auth"
+	"cli-tools/internal/cli"
+	"cli-tools/internal/git"
+	"cli-tools/internal/github"
+)
+
+// base is the branch the current branch is brought up to date with
+type base struct {
+	remote string // local remote of the base repository
+	branch string
+}
+
+func main() {
+	ctx, cancel := cli.Init()
+	defer cancel()
+
+	fs := flag.NewFlagSet("pr-update", flag.ExitOnError)
+	merge := fs.Bool("merge", false, "merge the base branch into the current branch")
+	rebase := fs.Bool("rebase", false, "rebase the current branch onto the base branch")
+	remote := fs.Bool("remote", false, "update the PR branch on GitHub instead of locally")
+	fs.Usage = func() {
+		fmt.Fprintln(os.Stderr, "Usage: pr-update [--merge|--rebase] [--remote]")
+		fmt.Fprintln(os.Stderr, "Brings the current branch up to date with its PR's base branch.")
+		fmt.Fprintln(os.Stderr, "Merges by default, or rebases if git's pull.rebase is set.")
+		fmt.Fprintln(os.Stderr, "With --remote, GitHub updates the PR branch and merges unless --rebase is given.")
+		fmt.Fprintln(os.Stderr, "Examples:")
+		fmt.Fprintln(os.Stderr, "  pr-update")
+		fmt.Fprintln(os.Stderr, "  pr-update --rebase")
+		fmt.Fprintln(os.Stderr, "  pr-update --remote")
+	}
+	args := cli.Parse(fs)
+
+	if !git.IsInsideRepo(ctx) {
+		fmt.Fprintln(os.Stderr, "Error: not inside a git repository")
+		os.Exit(1)
+	}
+
+	if len(args) > 0 || *merge && *rebase {
+		fs.Usage()
+		os.Exit(1)
+	}
+
+	branch, err := git.GetCurrentBranch(ctx)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: failed to get current branch: %v\n", err)
+		os.Exit(1)
+	}
+	if branch == "HEAD" {
+		fmt.Fprintln(os.Stderr, "Error: not on a branch (detached HEAD)")
+		os.Exit(1)
+	}
+
+	pr, err := auth.GetCurrentPullRequest(ctx)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+
+	if *remote {
+		if pr == nil {
+			fmt.Fprintln(os.Stderr, "Error: no PR found for current branch; --remote needs one")
+			os.Exit(1)
+		}
+		if err := updateRemote(ctx, pr, *rebase); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+		return
+	}
+
+	b, err := findBase(ctx, pr)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+	useRebase := *rebase
+	if !*merge && !*rebase {
+		useRebase = pullRebase(ctx, branch)
+	}
+	if err := updateLocal(ctx, branch, b, useRebase); err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+}
+
+// pullRebase reports whether the user prefers rebasing, going by the same
+// settings `git pull` uses
+func pullRebase(ctx context.Context, branch string) bool {
+	for _, key := range []string{"branch." + branch + ".rebase", "pull.rebase"} {
+		if v := git.GetConfig(ctx, key); v != "" {
+			return v != "false"
+		}
+	}
+	return false
+}
+
+// findBase works out the PR's base branch and the remote it lives on. Without
+// a PR, the default branch on origin is used.
+func findBase(ctx context.Context, pr *auth.PullRequest) (*base, error) {
+	if pr == nil {
+		branch, err := git.GetDefaultBranch(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get default branch: %w", err)
+		}
+		fmt.Fprintf(os.Stderr, "No PR found for current branch; using origin/%s\n", branch)
+		return &base{remote: "origin", branch: branch}, nil
+	}
+
+	repo := pr.BaseRepo()
+	remote, err := github.FindRemote(ctx, repo)
+	if err != nil {
+		return nil, err
+	}
+	if remote == "" {
+		origin, err := github.GetRepoInfo(ctx)
+		if err != nil {
+			return nil, err
+		}
+		return nil, fmt.Errorf("no local remote points to %s, the base of PR #%d; add one with: git remote add upstream %s",
+			repo, pr.Number, origin.RemoteURLFor(repo))
+	}
+	return &base{remote: remote, branch: pr.Base.Ref}, nil
+}
+
+// updateLocal fetches the base branch and merges it into, or rebases,
+// the current branch
+func updateLocal(ctx context.Context, branch string, b *base, rebase bool) error {
+	dirty, err := git.HasLocalChanges(ctx)
+	if err != nil {
+		return err
+	}
+	if dirty {
+		return fmt.Errorf("you have uncommitted changes; commit or stash them first")
+	}
+
+	tracking := b.remote + "/" + b.branch
+	refspec := fmt.Sprintf("+refs/heads/%s:refs/remotes/%s", b.branch, tracking)
+	if err := git.Run(ctx, "fetch", b.remote, refspec); err != nil {
+		return err
+	}
+
+	if git.IsAncestor(ctx, "refs/remotes/"+tracking, "HEAD") {
+		fmt.Printf("%s is already up to date with %s\n", branch, tracking)
+		return nil
+	}
+
+	if rebase {
+		if err := git.Run(ctx, "rebase", tracking); err != nil {
+			return conflictError(ctx, err, "rebase")
+		}
+		fmt.Printf("Rebased %s onto %s\n", branch, tracking)
+		fmt.Println("Push with: git push --force-with-lease")
+		return nil
+	}
+
+	if err := git.Run(ctx, "merge", "--no-edit", tracking); err != nil {
+		return conflictError(ctx, err, "merge")
+	}
+	fmt.Printf("Merged %s into %s\n", tracking, branch)
+	fmt.Println("Push with: git push")
+	return nil
+}
+
+// conflictError explains how to finish or undo a merge or rebase that
+// stopped on conflicts. Other failures are returned as they are.
+func conflictError(ctx context.Context, err error, op string) error {
+	if ctx.Err() != nil {
+		return context.Cause(ctx)
+	}
+	files, _ := git.ConflictedFiles(ctx)
+	if len(files) == 0 {
+		return err
+	}
+
+	fmt.Fprintln(os.Stderr)
+	fmt.Fprintf(os.Stderr, "The %s stopped with conflicts in:\n", op)
+	for _, f := range files {
+		fmt.Fprintf(os.Stderr, "  %s\n", f)
+	}
+	fmt.Fprintln(os.Stderr)
+	if op == "rebase" {
+		fmt.Fprintln(os.Stderr, "Resolve them, `git add` the files and run `git rebase --continue`,")
+	} else {
+		fmt.Fprintln(os.Stderr, "Resolve them, `git add` the files and run `git commit`,")
+	}
+	fmt.Fprintf(os.Stderr, "or undo with `git %s --abort`.\n", op)
+	return fmt.Errorf("%s has conflicts", op)
+}
+
+// updateRemote asks GitHub to update the PR branch, leaving the local
+// branch alone
+func updateRemote(ctx context.Context, pr *auth.PullRequest, rebase bool) error {
+	if err := auth.UpdatePRBranch(ctx, pr, rebase); err != nil {
+		return fmt.Errorf("GitHub could not update PR #%d (conflicts must be resolved locally with pr-update): %w", pr.Number, err)
+	}
+
+	if rebase {
+		fmt.Printf("Rebased the branch of PR #%d onto %s on GitHub\n", pr.Number, pr.Base.Ref)
+		fmt.Println("Update your local branch with: git pull --rebase")
+		return nil
+	}
+	fmt.Printf("Merged %s into the branch of PR #%d on GitHub\n", pr.Base.Ref, pr.Number)
+	fmt.Println("Update your local branch with: git pull")
+	return nil
+}
@@ -0,0 +1,209 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+
+	"cli-tools/internal/auth"
+	"cli-tools/internal/cli"
+	"cli-tools/internal/git"
+	"cli-tools/internal/prompt"
+)
+
+// verdict is what prune-branches decided about a local branch, and why
+type verdict struct {
+	branch git.Branch
+	prune  bool
+	reason string
+}
+
+func main() {
+	ctx, cancel := cli.Init()
+	defer cancel()
+
+	fs := flag.NewFlagSet("prune-branches", flag.ExitOnError)
+	del := fs.Bool("delete", false, "delete the branches instead of only listing them")
+	yes := fs.Bool("yes", false, "with --delete, don't ask for confirmation")
+	fetch := fs.Bool("fetch", false, "run git fetch --all --prune first, so deleted upstreams are noticed")
+	fs.Usage = func() {
+		fmt.Fprintln(os.Stderr, "Usage: prune-branches [--delete] [--yes] [--fetch]")
+		fmt.Fprintln(os.Stderr, "Lists local branches whose PRs are merged or closed, or whose upstream is gone.")
+		fmt.Fprintln(os.Stderr, "Nothing is deleted without --delete.")
+		fmt.Fprintln(os.Stderr, "Examples:")
+		fmt.Fprintln(os.Stderr, "  prune-branches")
+		fmt.Fprintln(os.Stderr, "  prune-branches --fetch --delete")
+	}
+	args := cli.Parse(fs)
+
+	if !git.IsInsideRepo(ctx) {
+		fmt.Fprintln(os.Stderr, "Error: not inside a git repository")
+		os.Exit(1)
+	}
+
+	if len(args) > 0 {
+		fs.Usage()
+		os.Exit(1)
+	}
+
+	if *fetch {
+		if err := git.Run(ctx, "fetch", "--all", "--prune"); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+	}
+
+	verdicts, err := review(ctx)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+
+	var prune []verdict
+	width := 0
+	for _, v := range verdicts {
+		width = max(width, len(v.branch.Name))
+		if v.prune {
+			prune = append(prune, v)
+		}
+	}
+	for _, v := range verdicts {
+		action := "keep  "
+		if v.prune {
+			action = "delete"
+		}
+		fmt.Printf("%s  %-*s  %s\n", action, width, v.branch.Name, v.reason)
+	}
+
+	if len(prune) == 0 {
+		fmt.Println("\nNothing to prune.")
+		return
+	}
+	if !*del {
+		fmt.Printf("\nDry run: %s would be deleted. Re-run with --delete to delete them.\n", branches(len(prune)))
+		return
+	}
+
+	if !*yes {
+		ok, err := prompt.Confirm(ctx, fmt.Sprintf("\nDelete %s?", branches(len(prune))))
+		if errors.Is(err, prompt.ErrNotInteractive) {
+			fmt.Fprintln(os.Stderr, "Error: cannot ask for confirmation; pass --yes to delete without asking")
+			os.Exit(1)
+		}
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+		if !ok {
+			fmt.Println("Nothing deleted.")
+			return
+		}
+	}
+
+	failed := 0
+	for _, v := range prune {
+		// -D because squash- and rebase-merged branches aren't merged as far as git knows.
+		// git prints the old SHA, so a branch deleted by mistake can be restored.
+		if err := git.Run(ctx, "branch", "-D", v.branch.Name); err != nil {
+			if ctx.Err() != nil {
+				fmt.Fprintf(os.Stderr, "Error: %v\n", context.Cause(ctx))
+				os.Exit(1)
+			}
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			failed++
+		}
+	}
+	if failed > 0 {
+		os.Exit(1)
+	}
+}
+
+// review decides for every local branch whether it can be pruned.
+// The default branch and branches checked out in a worktree are never pruned.
+func review(ctx context.Context) ([]verdict, error) {
+	list, err := git.ListBranches(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list branches: %w", err)
+	}
+	defaultBranch, err := git.GetDefaultBranch(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get default branch: %w", err)
+	}
+	worktrees, err := git.WorktreeBranches(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list worktrees: %w", err)
+	}
+	root, _ := git.GetRepoRoot(ctx)
+
+	fmt.Fprintf(os.Stderr, "Checking %s...\n", branches(len(list)))
+
+	verdicts := make([]verdict, 0, len(list))
+	for _, b := range list {
+		v := verdict{branch: b}
+		if b.Name == defaultBranch {
+			v.reason = "default branch"
+		} else if path, ok := worktrees[b.Name]; ok {
+			v.reason = "checked out"
+			if path != root {
+				v.reason += " in " + path
+			}
+		} else {
+			v.prune, v.reason, err = judge(ctx, b)
+			if err != nil {
+				return nil, fmt.Errorf("%s: %w", b.Name, err)
+			}
+		}
+		verdicts = append(verdicts, v)
+	}
+	return verdicts, nil
+}
+
+// judge decides whether a branch that isn't protected can be pruned, based
+// on its PRs and its upstream
+func judge(ctx context.Context, b git.Branch) (bool, string, error) {
+	prs, err := auth.FindPRsForBranch(ctx, b.Name)
+	if err != nil {
+		return false, "", err
+	}
+
+	// PRs are sorted open first, then newest first
+	if len(prs) > 0 {
+		pr := prs[0]
+		status := pr.Status()
+		if pr.State == "open" {
+			return false, fmt.Sprintf("PR #%d is %s", pr.Number, status), nil
+		}
+		// Commits made after the PR was closed would be lost
+		if b.SHA != pr.Head.SHA && !git.IsAncestor(ctx, b.SHA, pr.Head.SHA) {
+			return false, fmt.Sprintf("PR #%d is %s, but the branch has commits that aren't in it", pr.Number, status), nil
+		}
+		return true, fmt.Sprintf("PR #%d is %s", pr.Number, status), nil
+	}
+
+	switch {
+	case b.UpstreamGone:
+		// Without a PR, the remote branches are the only record of what was pushed
+		pushed, err := git.OnRemote(ctx, b.SHA)
+		if err != nil {
+			return false, "", err
+		}
+		if !pushed {
+			return false, fmt.Sprintf("upstream %s is gone, but the branch has commits that aren't on any remote", b.Upstream), nil
+		}
+		return true, fmt.Sprintf("upstream %s is gone", b.Upstream), nil
+	case b.Upstream == "":
+		return false, "no PR, not pushed", nil
+	default:
+		return false, "no PR", nil
+	}
+}
+
+// branches formats a branch count, e.g. "1 branch" or "3 branches"
+func branches(n int) string {
+	if n == 1 {
+		return "1 branch"
+	}
+	return fmt.Sprintf("%d branches", n)
+}
@@ -0,0 +1,235 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+
+	"cli-tools/internal/auth"
+	"cli-tools/internal/cli"
+	"cli-tools/internal/format"
+	"cli-tools/internal/git"
+	"cli-tools/internal/github"
+)
+
+// maxLanguages is how many languages are listed before the rest are
+// lumped together
+const maxLanguages = 5
+
+func main() {
+	ctx, cancel := cli.Init()
+	defer cancel()
+
+	fs := flag.NewFlagSet("repo-info", flag.ExitOnError)
+	asJSON := fs.Bool("json", false, "print the details as JSON")
+	fs.Usage = func() {
+		fmt.Fprintln(os.Stderr, "Usage: repo-info [--json]")
+		fmt.Fprintln(os.Stderr, "Shows the current repository's stats and settings: stars, forks, open issues and PRs,")
+		fmt.Fprintln(os.Stderr, "topics, license, languages, the latest release, merge methods and default branch protection.")
+		fmt.Fprintln(os.Stderr, "Examples:")
+		fmt.Fprintln(os.Stderr, "  repo-info")
+		fmt.Fprintln(os.Stderr, "  repo-info --json | jq .stars")
+	}
+	args := cli.Parse(fs)
+
+	if !git.IsInsideRepo(ctx) {
+		fmt.Fprintln(os.Stderr, "Error: not inside a git repository")
+		os.Exit(1)
+	}
+	if len(args) > 0 {
+		fs.Usage()
+		os.Exit(1)
+	}
+
+	repo, err := github.GetOwnerRepo(ctx)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+	details, err := auth.GetRepoDetails(ctx, repo)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+	for _, w := range details.Warnings {
+		fmt.Fprintf(os.Stderr, "Warning: %s\n", w)
+	}
+
+	if *asJSON {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		if err := enc.Encode(details); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+		return
+	}
+	printDetails(os.Stdout, details)
+}
+
+// printDetails writes the details as text
+func printDetails(w io.Writer, d *auth.RepoDetails) {
+	fmt.Fprintln(w, d.Name)
+	if d.Description != "" {
+		fmt.Fprintln(w, d.Description)
+	}
+	fmt.Fprintln(w, d.URL)
+	fmt.Fprintln(w)
+
+	fmt.Fprintf(w, "Stars: %d  Forks: %d  Watchers: %d\n", d.Stars, d.Forks, d.Watchers)
+	fmt.Fprintf(w, "Open issues: %d  Open PRs: %d\n", d.OpenIssues, d.OpenPRs)
+
+	visibility := d.Visibility
+	if d.Archived {
+		visibility += ", archived"
+	}
+	if d.Fork {
+		visibility += ", fork"
+	}
+	fmt.Fprintf(w, "Visibility: %s\n", visibility)
+	fmt.Fprintf(w, "Default branch: %s\n", d.DefaultBranch)
+	if len(d.Topics) > 0 {
+		fmt.Fprintf(w, "Topics: %s\n", strings.Join(d.Topics, ", "))
+	}
+	license := d.License
+	if license == "" {
+		license = "none"
+	}
+	fmt.Fprintf(w, "License: %s\n", license)
+	if len(d.Languages) > 0 {
+		fmt.Fprintf(w, "Languages: %s\n", formatLanguages(d.Languages))
+	}
+	if r := d.LatestRelease; r != nil {
+		name := r.Tag
+		if r.Name != "" && r.Name != r.Tag {
+			name += " " + r.Name
+		}
+		date, _, _ := strings.Cut(r.PublishedAt, "T")
+		fmt.Fprintf(w, "Latest release: %s (%s) %s\n", name, date, r.URL)
+	} else {
+		fmt.Fprintln(w, "Latest release: none")
+	}
+
+	methods := strings.Join(d.MergeMethods, ", ")
+	var extras []string
+	if d.AutoMergeAllowed {
+		extras = append(extras, "auto-merge allowed")
+	}
+	if d.DeleteBranchOnMerge {
+		extras = append(extras, "head branches deleted on merge")
+	}
+	if len(extras) > 0 {
+		methods += " (" + strings.Join(extras, ", ") + ")"
+	}
+	fmt.Fprintf(w, "Merge methods: %s\n", methods)
+
+	fmt.Fprintln(w)
+	printProtection(w, d)
+}
+
+// formatLanguages lists the biggest languages with their share of the code,
+// e.g. "Go 91.2%, Shell 8.8%"
+func formatLanguages(langs []auth.Language) string {
+	var parts []string
+	other := 0.0
+	for i, l := range langs {
+		if i < maxLanguages {
+			parts = append(parts, fmt.Sprintf("%s %.1f%%", l.Name, l.Percent))
+		} else {
+			other += l.Percent
+		}
+	}
+	if other > 0 {
+		parts = append(parts, fmt.Sprintf("other %.1f%%", other))
+	}
+	return strings.Join(parts, ", ")
+}
+
+// printProtection summarizes the protection of the default branch
+func printProtection(w io.Writer, d *auth.RepoDetails) {
+	// Anyone can see rulesets, but only admins can read classic rules
+	admin := d.ViewerPermission == "ADMIN"
+	p := d.Protection
+	if p == nil {
+		if admin {
+			fmt.Fprintf(w, "Branch protection (%s): none\n", d.DefaultBranch)
+		} else {
+			fmt.Fprintf(w, "Branch protection (%s): none visible; only admins can see classic protection rules\n", d.DefaultBranch)
+		}
+		return
+	}
+
+	sources := []string{d.DefaultBranch}
+	if p.Pattern != "" {
+		sources = append(sources, "rule "+p.Pattern)
+	}
+	if len(p.Rulesets) > 0 {
+		ids := make([]string, len(p.Rulesets))
+		for i, id := range p.Rulesets {
+			ids[i] = strconv.FormatInt(id, 10)
+		}
+		sources = append(sources, format.Plural(len(ids), "ruleset ", "rulesets ")+strings.Join(ids, ", "))
+	}
+	fmt.Fprintf(w, "Branch protection (%s):\n", strings.Join(sources, ", "))
+	reviews := "not required"
+	if p.RequiredApprovals > 0 {
+		reviews = fmt.Sprintf("%d %s", p.RequiredApprovals, format.Plural(p.RequiredApprovals, "approval", "approvals"))
+		if p.RequiresCodeOwnerReviews {
+			reviews += ", including code owners"
+		}
+		if p.DismissesStaleReviews {
+			reviews += "; new commits dismiss approvals"
+		}
+	}
+	fmt.Fprintf(w, "  Reviews: %s\n", reviews)
+
+	checks := "none required"
+	if len(p.RequiredChecks) > 0 {
+		checks = strings.Join(p.RequiredChecks, ", ")
+		if p.RequiresUpToDate {
+			checks += " (branch must be up to date)"
+		}
+	}
+	fmt.Fprintf(w, "  Required checks: %s\n", checks)
+
+	var rules []string
+	for _, r := range []struct {
+		on   bool
+		what string
+	}{
+		{p.RequiresLinearHistory, "linear history"},
+		{p.RequiresSignatures, "signed commits"},
+		{p.RequiresConversationResolution, "resolved conversations"},
+	} {
+		if r.on {
+			rules = append(rules, r.what)
+		}
+	}
+	if len(rules) > 0 {
+		fmt.Fprintf(w, "  Also requires: %s\n", strings.Join(rules, ", "))
+	}
+
+	var allowed []string
+	if p.AllowsForcePushes {
+		allowed = append(allowed, "force pushes")
+	}
+	if p.AllowsDeletions {
+		allowed = append(allowed, "deletion")
+	}
+	if len(allowed) > 0 {
+		fmt.Fprintf(w, "  Allows: %s\n", strings.Join(allowed, ", "))
+	}
+	switch {
+	case p.Pattern == "" && !admin:
+		fmt.Fprintln(w, "  Only admins can see classic protection rules, which may add to these")
+	case p.Pattern == "":
+	case p.EnforcedForAdmins:
+		fmt.Fprintln(w, "  The classic rule applies to admins too")
+	default:
+		fmt.Fprintln(w, "  Admins can bypass the classic rule")
+	}
+}
@@ -0,0 +1,108 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+
+	"cli-tools/internal/auth"
+	"cli-tools/internal/cli"
+	"cli-tools/internal/git"
+	"cli-tools/internal/github"
+	"cli-tools/internal/runs"
+)
+
+func main() {
+	// --debug turns on debug logging for the run; --verbose still traces
+	ctx, cancel := cli.Init("--debug")
+	defer cancel()
+
+	fs := flag.NewFlagSet("rerun", flag.ExitOnError)
+	failedOnly := fs.Bool("failed-only", false, "re-run only the failed jobs and the jobs that depend on them")
+	debug := fs.Bool("debug", false, "re-run with runner and step debug logging")
+	fs.Usage = func() {
+		fmt.Fprintln(os.Stderr, "Usage: rerun [run-id|url] [--failed-only] [--debug]")
+		fmt.Fprintln(os.Stderr, "Re-runs a completed GitHub Actions workflow run.")
+		fmt.Fprintln(os.Stderr, "Without a run ID, re-runs the latest failed run for the commit you have checked out.")
+		fmt.Fprintln(os.Stderr, "--debug turns on runner and step debug logging for the run; use --verbose to trace this command's calls.")
+		fmt.Fprintln(os.Stderr, "Examples:")
+		fmt.Fprintln(os.Stderr, "  rerun --failed-only")
+		fmt.Fprintln(os.Stderr, "  rerun 1234567890 --debug")
+	}
+	args := cli.Parse(fs)
+
+	if !git.IsInsideRepo(ctx) {
+		fmt.Fprintln(os.Stderr, "Error: not inside a git repository")
+		os.Exit(1)
+	}
+	if len(args) > 1 {
+		fs.Usage()
+		os.Exit(1)
+	}
+
+	arg := ""
+	if len(args) == 1 {
+		arg = args[0]
+	}
+	if err := rerun(ctx, arg, *failedOnly, *debug); err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+}
+
+// rerun re-runs the run given as arg, or the latest failed run for HEAD
+func rerun(ctx context.Context, arg string, failedOnly, debug bool) error {
+	var repo string
+	var run *auth.WorkflowRun
+	if arg != "" {
+		var id int64
+		var err error
+		if repo, id, err = runs.Parse(ctx, arg); err != nil {
+			return err
+		}
+		if run, err = auth.GetRun(ctx, repo, id); err != nil {
+			return err
+		}
+	} else {
+		var err error
+		if repo, err = github.GetOwnerRepo(ctx); err != nil {
+			return err
+		}
+		list, sha, err := runs.ForHead(ctx, repo)
+		if err != nil {
+			return err
+		}
+		for i := range list {
+			if list[i].Status == "completed" && runs.Failed(list[i].Conclusion) {
+				run = &list[i]
+				break
+			}
+		}
+		if run == nil {
+			return fmt.Errorf("no failed runs for commit %s", runs.ShortSHA(sha))
+		}
+	}
+
+	if run.Status != "completed" {
+		return fmt.Errorf("%s is still %s; cancel it first", runs.Describe(run), run.Status)
+	}
+	if failedOnly && runs.Passed(run.Conclusion) {
+		return fmt.Errorf("%s has no failed jobs", runs.Describe(run))
+	}
+
+	if err := auth.RerunRun(ctx, repo, run.ID, failedOnly, debug); err != nil {
+		return err
+	}
+	what := "all jobs"
+	if failedOnly {
+		what = "failed jobs"
+	}
+	what += " of " + runs.Describe(run)
+	if debug {
+		what += " with debug logging"
+	}
+	fmt.Printf("Re-running %s: %s\n", what, run.HTMLURL)
+	fmt.Printf("Follow it with: runs %d --watch\n", run.ID)
+	return nil
+}
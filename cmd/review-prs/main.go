@@ -1,6 +1,7 @@
 package main
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 	"os"
@@ -8,13 +9,15 @@ import (
 	"strings"
 
 	"cli-tools/internal/auth"
+	"cli-tools/internal/cli"
+	"cli-tools/internal/trace"
 )
 
 type PR struct {
-	Number     int    `json:"number"`
-	Title      string `json:"title"`
-	URL        string `json:"url"`
-	Author     struct {
+	Number int    `json:"number"`
+	Title  string `json:"title"`
+	URL    string `json:"url"`
+	Author struct {
 		Login string `json:"login"`
 	} `json:"author"`
 	Repository struct {
@@ -23,21 +26,24 @@ type PR struct {
 }
 
 func main() {
-	if auth.HasGhCLI() {
-		showReviewPRsWithGh()
+	ctx, cancel := cli.Init()
+	defer cancel()
+
+	if auth.HasGhCLI(ctx) {
+		showReviewPRsWithGh(ctx)
 		return
 	}
 
-	showReviewPRsWithAPI()
+	showReviewPRsWithAPI(ctx)
 }
 
-func showReviewPRsWithGh() {
+func showReviewPRsWithGh(ctx context.Context) {
 	// Search for PRs where review is requested
-	cmd := exec.Command("gh", "search", "prs",
+	cmd := exec.CommandContext(ctx, "gh", "search", "prs",
 		"--review-requested", "@me",
 		"--state", "open",
 		"--json", "number,title,url,author,repository")
-	out, err := cmd.Output()
+	out, err := trace.Output(cmd)
 	if err != nil {
 		if exitErr, ok := err.(*exec.ExitError); ok {
 			fmt.Fprintln(os.Stderr, strings.TrimSpace(string(exitErr.Stderr)))
@@ -66,9 +72,8 @@ func showReviewPRsWithGh() {
 	}
 }
 
-func showReviewPRsWithAPI() {
-	// Get current user
-	userData, err := auth.APIRequest("GET", "/user", nil)
+func showReviewPRsWithAPI(ctx context.Context) {
+	prs, total, err := auth.ListReviewRequests(ctx)
 	if err != nil {
 		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
 		fmt.Fprintln(os.Stderr, "")
@@ -76,59 +81,15 @@ func showReviewPRsWithAPI() {
 		os.Exit(1)
 	}
 
-	var user struct {
-		Login string `json:"login"`
-	}
-	if err := json.Unmarshal(userData, &user); err != nil {
-		fmt.Fprintf(os.Stderr, "Error parsing user: %v\n", err)
-		os.Exit(1)
-	}
-
-	// Search for PRs where review is requested
-	query := fmt.Sprintf("is:pr is:open review-requested:%s", user.Login)
-	endpoint := fmt.Sprintf("/search/issues?q=%s&sort=updated&per_page=20", strings.ReplaceAll(query, " ", "+"))
-
-	data, err := auth.APIRequest("GET", endpoint, nil)
-	if err != nil {
-		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
-		os.Exit(1)
-	}
-
-	var result struct {
-		TotalCount int `json:"total_count"`
-		Items      []struct {
-			Number        int    `json:"number"`
-			Title         string `json:"title"`
-			HTMLURL       string `json:"html_url"`
-			RepositoryURL string `json:"repository_url"`
-			User          struct {
-				Login string `json:"login"`
-			} `json:"user"`
-		} `json:"items"`
-	}
-	if err := json.Unmarshal(data, &result); err != nil {
-		fmt.Fprintf(os.Stderr, "Error parsing response: %v\n", err)
-		os.Exit(1)
-	}
-
-	if result.TotalCount == 0 {
+	if total == 0 {
 		fmt.Println("No PRs awaiting your review")
 		return
 	}
 
-	fmt.Printf("PRs awaiting your review (%d):\n\n", result.TotalCount)
-	for _, pr := range result.Items {
-		repo := extractRepoFromURL(pr.RepositoryURL)
+	fmt.Printf("PRs awaiting your review (%d):\n\n", total)
+	for _, pr := range prs {
 		fmt.Printf("#%d %s\n", pr.Number, pr.Title)
-		fmt.Printf("    by @%s in %s\n", pr.User.Login, repo)
-		fmt.Printf("    %s\n\n", pr.HTMLURL)
-	}
-}
-
-func extractRepoFromURL(url string) string {
-	parts := strings.Split(url, "/repos/")
-	if len(parts) > 1 {
-		return parts[1]
+		fmt.Printf("    by @%s in %s\n", pr.Author, pr.Repo)
+		fmt.Printf("    %s\n\n", pr.URL)
 	}
-	return url
 }
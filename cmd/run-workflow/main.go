@@ -0,0 +1,331 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"cli-tools/internal/auth"
+	"cli-tools/internal/cli"
+	"cli-tools/internal/git"
+	"cli-tools/internal/github"
+	"cli-tools/internal/pager"
+	"cli-tools/internal/picker"
+	"cli-tools/internal/prompt"
+	"cli-tools/internal/runs"
+	"cli-tools/internal/workflows"
+)
+
+// runTimeout is how long to wait for a dispatched run to show up
+const runTimeout = 30 * time.Second
+
+func main() {
+	ctx, cancel := cli.Init()
+	defer cancel()
+
+	fs := flag.NewFlagSet("run-workflow", flag.ExitOnError)
+	given := make(map[string]string)
+	fs.Func("f", "set input `key=value` (repeatable)", func(s string) error {
+		key, value, ok := strings.Cut(s, "=")
+		if !ok || key == "" {
+			return fmt.Errorf("expected key=value, got %q", s)
+		}
+		given[key] = value
+		return nil
+	})
+	ref := fs.String("ref", "", "run on this `branch` or tag (default: current branch)")
+	noWatch := fs.Bool("no-watch", false, "don't follow the run once it starts")
+	interval := fs.Duration("interval", 5*time.Second, "refresh interval while following the run")
+	fs.Usage = func() {
+		fmt.Fprintln(os.Stderr, "Usage: run-workflow [workflow] [-f key=value]... [--ref <branch>] [--no-watch]")
+		fmt.Fprintln(os.Stderr, "Triggers a workflow with a workflow_dispatch trigger, then follows the run until it completes.")
+		fmt.Fprintln(os.Stderr, "The workflow is a file name, path or name from .github/workflows; without one, pick from the list.")
+		fmt.Fprintln(os.Stderr, "Inputs not given with -f are asked for, or take their defaults when not interactive.")
+		fmt.Fprintln(os.Stderr, "Examples:")
+		fmt.Fprintln(os.Stderr, "  run-workflow")
+		fmt.Fprintln(os.Stderr, "  run-workflow deploy.yml -f environment=staging -f dry-run=true")
+		fmt.Fprintln(os.Stderr, "  run-workflow Release --ref main --no-watch")
+	}
+	args := cli.Parse(fs)
+
+	if !git.IsInsideRepo(ctx) {
+		fmt.Fprintln(os.Stderr, "Error: not inside a git repository")
+		os.Exit(1)
+	}
+	if len(args) > 1 {
+		fs.Usage()
+		os.Exit(1)
+	}
+	if *interval < time.Second {
+		*interval = time.Second
+	}
+
+	name := ""
+	if len(args) == 1 {
+		name = args[0]
+	}
+	code, err := run(ctx, name, given, *ref, !*noWatch, *interval)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+	os.Exit(code)
+}
+
+// run dispatches the workflow and returns the exit code
+func run(ctx context.Context, name string, given map[string]string, ref string, watch bool, interval time.Duration) (int, error) {
+	repo, err := github.GetOwnerRepo(ctx)
+	if err != nil {
+		return 1, err
+	}
+	w, err := findWorkflow(ctx, name)
+	if err != nil {
+		return 1, err
+	}
+	specs, ok := w.DispatchInputs()
+	if !ok {
+		return 1, fmt.Errorf("%s has no workflow_dispatch trigger, so it can't be run manually", w.Path)
+	}
+	inputs, err := collectInputs(ctx, specs, given)
+	if err != nil {
+		return 1, err
+	}
+
+	if ref == "" {
+		ref, err = git.GetCurrentBranch(ctx)
+		if err != nil {
+			return 1, err
+		}
+		if ref == "HEAD" {
+			return 1, errors.New("not on a branch; pass --ref")
+		}
+		warnUnpushed(ctx, ref)
+	}
+
+	// Note the runs that already exist, so the new one can be told apart
+	filter := auth.RunFilter{Workflow: w.File(), Branch: ref, Event: "workflow_dispatch", Limit: 10}
+	before, err := auth.ListRuns(ctx, repo, filter)
+	if err != nil {
+		return 1, err
+	}
+	seen := make(map[int64]bool)
+	for _, r := range before {
+		seen[r.ID] = true
+	}
+
+	if err := auth.DispatchWorkflow(ctx, repo, w.File(), ref, inputs); err != nil {
+		return 1, err
+	}
+	fmt.Printf("Dispatched %s on %s\n", w.Name, ref)
+
+	run, err := waitForRun(ctx, repo, filter, seen)
+	if err != nil {
+		return 1, err
+	}
+	if run == nil {
+		fmt.Printf("The run hasn't started yet; see runs --workflow %s\n", w.File())
+		return 0, nil
+	}
+	fmt.Printf("Run %d: %s\n", run.ID, run.HTMLURL)
+	if !watch {
+		return 0, nil
+	}
+
+	fmt.Println()
+	run, err = runs.Follow(ctx, os.Stdout, repo, run.ID, interval, pager.IsTerminal(os.Stdout))
+	if err != nil {
+		return 1, err
+	}
+	fmt.Printf("Run %d completed: %s\n", run.ID, strings.ReplaceAll(run.Conclusion, "_", " "))
+	if !runs.Passed(run.Conclusion) {
+		return 1, nil
+	}
+	return 0, nil
+}
+
+// findWorkflow loads the workflow named by its file name, path or name, or
+// lets the user pick one of those that can be dispatched
+func findWorkflow(ctx context.Context, name string) (*workflows.Workflow, error) {
+	root, err := git.GetRepoRoot(ctx)
+	if err != nil {
+		return nil, err
+	}
+	paths, err := workflows.List(root)
+	if err != nil {
+		return nil, err
+	}
+	if len(paths) == 0 {
+		return nil, fmt.Errorf("no workflows found in %s", workflows.Dir)
+	}
+
+	var candidates []*workflows.Workflow
+	for _, path := range paths {
+		byFile := name == filepath.Base(path) || filepath.ToSlash(name) == path
+		w, err := workflows.Load(root, path)
+		switch {
+		case err != nil && byFile:
+			return nil, err
+		case err != nil:
+			if name == "" {
+				fmt.Fprintf(os.Stderr, "Warning: skipping %v\n", err)
+			}
+			continue
+		case byFile || name != "" && strings.EqualFold(name, w.Name):
+			return w, nil
+		}
+		if _, ok := w.DispatchInputs(); ok {
+			candidates = append(candidates, w)
+		}
+	}
+	if name != "" {
+		return nil, fmt.Errorf("no workflow named %s in %s", name, workflows.Dir)
+	}
+	if len(candidates) == 0 {
+		return nil, fmt.Errorf("no workflows in %s have a workflow_dispatch trigger", workflows.Dir)
+	}
+
+	items := make([]string, len(candidates))
+	for i, w := range candidates {
+		items[i] = fmt.Sprintf("%s (%s)", w.Name, w.File())
+	}
+	idx, err := picker.Pick(ctx, "Select a workflow to run", items)
+	if errors.Is(err, prompt.ErrNotInteractive) {
+		return nil, fmt.Errorf("name the workflow to run: %s", strings.Join(items, ", "))
+	}
+	if err != nil {
+		return nil, err
+	}
+	return candidates[idx], nil
+}
+
+// collectInputs validates the inputs given with -f and asks for the rest,
+// or uses their defaults when not interactive
+func collectInputs(ctx context.Context, specs []workflows.Input, given map[string]string) (map[string]string, error) {
+	known := make(map[string]bool)
+	for _, in := range specs {
+		known[in.Name] = true
+	}
+	for key := range given {
+		if !known[key] {
+			names := make([]string, len(specs))
+			for i, in := range specs {
+				names[i] = in.Name
+			}
+			if len(names) == 0 {
+				return nil, fmt.Errorf("unknown input %s; the workflow takes no inputs", key)
+			}
+			return nil, fmt.Errorf("unknown input %s; the workflow takes %s", key, strings.Join(names, ", "))
+		}
+	}
+
+	inputs := make(map[string]string)
+	interactive := prompt.IsInteractive()
+	for _, in := range specs {
+		value, ok := given[in.Name]
+		switch {
+		case ok:
+		case interactive:
+			var err error
+			if value, err = ask(ctx, in); err != nil {
+				return nil, err
+			}
+		default:
+			value = in.Default
+		}
+		if err := in.Validate(value); err != nil {
+			if !ok && !interactive {
+				return nil, fmt.Errorf("%w; pass -f %s=<value>", err, in.Name)
+			}
+			return nil, err
+		}
+		if value != "" {
+			inputs[in.Name] = value
+		}
+	}
+	return inputs, nil
+}
+
+// ask prompts for an input until the answer is valid
+func ask(ctx context.Context, in workflows.Input) (string, error) {
+	question := in.Name
+	if in.Description != "" {
+		question += " (" + in.Description + ")"
+	}
+
+	if in.Type == "choice" && len(in.Options) > 0 {
+		options := make([]string, len(in.Options))
+		for i, o := range in.Options {
+			options[i] = o
+			if o == in.Default {
+				options[i] += " (default)"
+			}
+		}
+		idx, err := picker.Pick(ctx, question, options)
+		if err != nil {
+			return "", err
+		}
+		return in.Options[idx], nil
+	}
+
+	def := in.Default
+	if in.Type == "boolean" {
+		question += " [true/false]"
+		if def == "" {
+			def = "false"
+		}
+	}
+	for {
+		value, err := prompt.Input(ctx, question, def)
+		if err != nil {
+			return "", err
+		}
+		if err := in.Validate(value); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			continue
+		}
+		return value, nil
+	}
+}
+
+// warnUnpushed warns when the run won't include local commits
+func warnUnpushed(ctx context.Context, branch string) {
+	upstream := git.UpstreamRef(ctx, branch)
+	if upstream == "" {
+		fmt.Fprintf(os.Stderr, "Warning: %s has no upstream branch; the run uses the branch as it is on GitHub\n", branch)
+		return
+	}
+	if ahead, _, err := git.AheadBehind(ctx, branch, upstream); err == nil && ahead > 0 {
+		fmt.Fprintf(os.Stderr, "Warning: %s has %d unpushed commit(s) that the run won't include\n", branch, ahead)
+	}
+}
+
+// waitForRun polls for the run started by the dispatch: the newest one not
+// in seen. It returns nil if none shows up within runTimeout.
+func waitForRun(ctx context.Context, repo string, filter auth.RunFilter, seen map[int64]bool) (*auth.WorkflowRun, error) {
+	deadline := time.Now().Add(runTimeout)
+	for {
+		list, err := auth.ListRuns(ctx, repo, filter)
+		if err != nil {
+			return nil, err
+		}
+		for _, r := range list {
+			if !seen[r.ID] {
+				return &r, nil
+			}
+		}
+		if time.Now().After(deadline) {
+			return nil, nil
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil, context.Cause(ctx)
+		case <-time.After(2 * time.Second):
+		}
+	}
+}
@@ -0,0 +1,124 @@
+package main
+
+import (
+	"archive/zip"
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"cli-tools/internal/auth"
+	"cli-tools/internal/format"
+)
+
+// downloadRun saves the logs of a run to dir/logs and each of its artifacts
+// to dir/artifacts/<name>
+func downloadRun(ctx context.Context, repo string, runID int64, dir string) error {
+	n, err := fetchZip(filepath.Join(dir, "logs"), func(w io.Writer) error {
+		return auth.DownloadRunLogs(ctx, repo, runID, w)
+	})
+	if err != nil {
+		return fmt.Errorf("logs: %w", err)
+	}
+	fmt.Printf("Saved %d log %s to %s\n", n, format.Plural(n, "file", "files"), filepath.Join(dir, "logs"))
+
+	artifacts, err := auth.ListArtifacts(ctx, repo, runID)
+	if err != nil {
+		return err
+	}
+	for _, a := range artifacts {
+		if a.Expired {
+			fmt.Printf("Skipped artifact %s: expired\n", a.Name)
+			continue
+		}
+		dest := filepath.Join(dir, "artifacts", filepath.Base(a.Name))
+		n, err := fetchZip(dest, func(w io.Writer) error {
+			return auth.DownloadArtifact(ctx, repo, a.ID, w)
+		})
+		if err != nil {
+			return fmt.Errorf("artifact %s: %w", a.Name, err)
+		}
+		fmt.Printf("Saved artifact %s (%d %s) to %s\n", a.Name, n, format.Plural(n, "file", "files"), dest)
+	}
+	return nil
+}
+
+// fetchZip downloads a zip archive with get to a temporary file, since
+// archives can be large, then extracts it into dir. It returns the number
+// of files extracted.
+func fetchZip(dir string, get func(w io.Writer) error) (int, error) {
+	tmp, err := os.CreateTemp("", "cli-tools-*.zip")
+	if err != nil {
+		return 0, err
+	}
+	defer os.Remove(tmp.Name())
+	defer tmp.Close()
+
+	if err := get(tmp); err != nil {
+		return 0, fmt.Errorf("failed to download: %w", err)
+	}
+	n, err := unzip(tmp, dir)
+	if err != nil {
+		return n, fmt.Errorf("failed to extract: %w", err)
+	}
+	return n, nil
+}
+
+// unzip extracts the zip archive in f into dir and returns the number of files
+func unzip(f *os.File, dir string) (int, error) {
+	info, err := f.Stat()
+	if err != nil {
+		return 0, err
+	}
+	r, err := zip.NewReader(f, info.Size())
+	if err != nil {
+		return 0, err
+	}
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return 0, err
+	}
+
+	n := 0
+	for _, f := range r.File {
+		// Refuse entries that would land outside dir
+		path := filepath.Join(dir, f.Name)
+		if !strings.HasPrefix(path, filepath.Clean(dir)+string(os.PathSeparator)) {
+			return n, fmt.Errorf("invalid file name in archive: %s", f.Name)
+		}
+		if f.FileInfo().IsDir() {
+			if err := os.MkdirAll(path, 0o755); err != nil {
+				return n, err
+			}
+			continue
+		}
+		if err := extractFile(f, path); err != nil {
+			return n, err
+		}
+		n++
+	}
+	return n, nil
+}
+
+// extractFile writes one archive entry to path
+func extractFile(f *zip.File, path string) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return err
+	}
+	src, err := f.Open()
+	if err != nil {
+		return err
+	}
+	defer src.Close()
+
+	dst, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(dst, src); err != nil {
+		dst.Close()
+		return err
+	}
+	return dst.Close()
+}
@@ -0,0 +1,176 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"cli-tools/internal/auth"
+	"cli-tools/internal/cli"
+	"cli-tools/internal/format"
+	"cli-tools/internal/git"
+	"cli-tools/internal/github"
+	"cli-tools/internal/pager"
+	"cli-tools/internal/runs"
+)
+
+// maxTitle is the longest run title the list shows before shortening it
+const maxTitle = 40
+
+func main() {
+	ctx, cancel := cli.Init()
+	defer cancel()
+
+	fs := flag.NewFlagSet("runs", flag.ExitOnError)
+	all := fs.Bool("all", false, "list runs on all branches")
+	branch := fs.String("branch", "", "list runs on this `branch` (default: current branch)")
+	workflow := fs.String("workflow", "", "list runs of this workflow `file`, e.g. ci.yml")
+	limit := fs.Int("limit", 20, "list at most `n` runs (max 100)")
+	watch := fs.Bool("watch", false, "follow the run until it completes")
+	interval := fs.Duration("interval", 5*time.Second, "refresh interval for --watch")
+	download := fs.String("download", "", "download the run's logs and artifacts to `dir`")
+	fs.Usage = func() {
+		fmt.Fprintln(os.Stderr, "Usage: runs [--all|--branch <branch>] [--workflow <file>] [--limit <n>]")
+		fmt.Fprintln(os.Stderr, "       runs <run-id|url> [--watch] [--download <dir>]")
+		fmt.Fprintln(os.Stderr, "Lists recent GitHub Actions workflow runs for the current branch, or shows the jobs and steps of one run.")
+		fmt.Fprintln(os.Stderr, "--watch follows the run until it completes and exits non-zero if it didn't succeed.")
+		fmt.Fprintln(os.Stderr, "--download saves the logs to <dir>/logs and each artifact to <dir>/artifacts/<name>.")
+		fmt.Fprintln(os.Stderr, "Examples:")
+		fmt.Fprintln(os.Stderr, "  runs")
+		fmt.Fprintln(os.Stderr, "  runs --all --workflow ci.yml")
+		fmt.Fprintln(os.Stderr, "  runs 1234567890 --watch")
+		fmt.Fprintln(os.Stderr, "  runs https://github.com/owner/repo/actions/runs/1234567890 --download ./ci")
+	}
+	args := cli.Parse(fs)
+
+	if !git.IsInsideRepo(ctx) {
+		fmt.Fprintln(os.Stderr, "Error: not inside a git repository")
+		os.Exit(1)
+	}
+
+	if len(args) > 1 || *all && *branch != "" || *limit <= 0 {
+		fs.Usage()
+		os.Exit(1)
+	}
+	if len(args) == 0 && (*watch || *download != "") {
+		fmt.Fprintln(os.Stderr, "Error: --watch and --download need a run ID")
+		os.Exit(1)
+	}
+	if *interval < time.Second {
+		*interval = time.Second
+	}
+
+	if len(args) == 0 {
+		if err := listRuns(ctx, *all, *branch, *workflow, *limit); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+		return
+	}
+
+	repo, runID, err := runs.Parse(ctx, args[0])
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+
+	code := 0
+	if *watch {
+		run, err := runs.Follow(ctx, os.Stdout, repo, runID, *interval, pager.IsTerminal(os.Stdout))
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+		fmt.Printf("Run %d completed: %s\n", run.ID, strings.ReplaceAll(run.Conclusion, "_", " "))
+		if !runs.Passed(run.Conclusion) {
+			code = 1
+		}
+	} else if *download == "" {
+		if err := showRun(ctx, repo, runID); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+	}
+
+	if *download != "" {
+		if err := downloadRun(ctx, repo, runID, *download); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+	}
+	os.Exit(code)
+}
+
+// showRun prints a run with its jobs and steps
+func showRun(ctx context.Context, repo string, runID int64) error {
+	run, err := auth.GetRun(ctx, repo, runID)
+	if err != nil {
+		return err
+	}
+	jobs, err := auth.ListJobs(ctx, repo, runID)
+	if err != nil {
+		return err
+	}
+
+	p := pager.Start(ctx)
+	defer p.Close()
+	runs.PrintRun(p, run, jobs)
+	return nil
+}
+
+// listRuns prints a table of recent runs. By default they are the current
+// branch's; on a detached HEAD, all branches'.
+func listRuns(ctx context.Context, all bool, branch, workflow string, limit int) error {
+	repo, err := github.GetOwnerRepo(ctx)
+	if err != nil {
+		return err
+	}
+	if !all && branch == "" {
+		if current, err := git.GetCurrentBranch(ctx); err == nil && current != "HEAD" {
+			branch = current
+		}
+	}
+
+	list, err := auth.ListRuns(ctx, repo, auth.RunFilter{Branch: branch, Workflow: workflow, Limit: limit})
+	if err != nil {
+		return err
+	}
+	if len(list) == 0 {
+		if branch != "" {
+			fmt.Printf("No workflow runs found for branch %s\n", branch)
+		} else {
+			fmt.Println("No workflow runs found")
+		}
+		return nil
+	}
+
+	now := time.Now()
+	header := []string{"ID", "STATUS", "WORKFLOW", "TITLE", "EVENT", "COMMIT", "DURATION", "STARTED"}
+	if branch == "" {
+		header = []string{"ID", "STATUS", "WORKFLOW", "TITLE", "BRANCH", "EVENT", "COMMIT", "DURATION", "STARTED"}
+	}
+	rows := [][]string{header}
+	for _, run := range list {
+		title := run.DisplayTitle
+		if r := []rune(title); len(r) > maxTitle {
+			title = string(r[:maxTitle-1]) + "…"
+		}
+		row := []string{strconv.FormatInt(run.ID, 10), runs.Mark(run.Status, run.Conclusion), run.Name, title}
+		if branch == "" {
+			row = append(row, run.HeadBranch)
+		}
+		started := run.RunStartedAt
+		if started == "" {
+			started = run.CreatedAt
+		}
+		row = append(row, run.Event, runs.ShortSHA(run.HeadSHA), runs.RunDuration(&run, now), runs.Age(started, now))
+		rows = append(rows, row)
+	}
+
+	format.Table(os.Stdout, rows)
+	return nil
+}
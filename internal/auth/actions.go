@@ -0,0 +1,220 @@
+package auth
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/url"
+	"regexp"
+	"strconv"
+)
+
+// actionsJobRegex matches the details URL of a GitHub Actions job
+var actionsJobRegex = regexp.MustCompile(`^https://[^/]+/([^/]+/[^/]+)/actions/runs/\d+/job/(\d+)`)
+
+// ParseJobURL returns the repository and job ID of a GitHub Actions job from
+// its details URL. ok is false for checks that don't come from Actions.
+func ParseJobURL(detailsURL string) (ownerRepo string, jobID int64, ok bool) {
+	m := actionsJobRegex.FindStringSubmatch(detailsURL)
+	if m == nil {
+		return "", 0, false
+	}
+	id, err := strconv.ParseInt(m[2], 10, 64)
+	if err != nil {
+		return "", 0, false
+	}
+	return m[1], id, true
+}
+
+// GetJobLog downloads the plain-text log of a GitHub Actions job
+func GetJobLog(ctx context.Context, ownerRepo string, jobID int64) (string, error) {
+	// The API redirects to a short-lived download URL, which both gh and
+	// net/http follow
+	data, err := apiRequest(ctx, "GET", fmt.Sprintf("/repos/%s/actions/jobs/%d/logs", ownerRepo, jobID), "", nil)
+	if err != nil {
+		return "", err
+	}
+	return string(data), nil
+}
+
+// WorkflowRun is one run of a GitHub Actions workflow
+type WorkflowRun struct {
+	ID           int64  `json:"id"`
+	Name         string `json:"name"` // workflow name
+	DisplayTitle string `json:"display_title"`
+	Status       string `json:"status"`     // queued, in_progress, completed, waiting, ...
+	Conclusion   string `json:"conclusion"` // success, failure, cancelled, skipped, ... once completed
+	Event        string `json:"event"`
+	HeadBranch   string `json:"head_branch"`
+	HeadSHA      string `json:"head_sha"`
+	RunNumber    int    `json:"run_number"`
+	RunAttempt   int    `json:"run_attempt"`
+	CreatedAt    string `json:"created_at"`
+	RunStartedAt string `json:"run_started_at"`
+	UpdatedAt    string `json:"updated_at"`
+	HTMLURL      string `json:"html_url"`
+	Path         string `json:"path"` // workflow file, e.g. .github/workflows/ci.yml
+	Actor        struct {
+		Login string `json:"login"`
+	} `json:"actor"`
+}
+
+// Job is a job of a workflow run
+type Job struct {
+	ID          int64  `json:"id"`
+	Name        string `json:"name"`
+	Status      string `json:"status"`
+	Conclusion  string `json:"conclusion"`
+	StartedAt   string `json:"started_at"`
+	CompletedAt string `json:"completed_at"`
+	HTMLURL     string `json:"html_url"`
+	Steps       []Step `json:"steps"`
+}
+
+// Step is a step of a job
+type Step struct {
+	Number      int    `json:"number"`
+	Name        string `json:"name"`
+	Status      string `json:"status"`
+	Conclusion  string `json:"conclusion"`
+	StartedAt   string `json:"started_at"`
+	CompletedAt string `json:"completed_at"`
+}
+
+// Artifact is a file archive uploaded by a workflow run
+type Artifact struct {
+	ID          int64  `json:"id"`
+	Name        string `json:"name"`
+	SizeInBytes int64  `json:"size_in_bytes"`
+	Expired     bool   `json:"expired"`
+}
+
+// RunFilter selects workflow runs. Empty fields match everything.
+type RunFilter struct {
+	Branch   string
+	Workflow string // workflow file name (ci.yml) or ID
+	HeadSHA  string
+	Event    string // e.g. "push" or "workflow_dispatch"
+	Status   string // e.g. "failure" or "in_progress"
+	Limit    int    // at most 100; 0 means 20
+}
+
+// ListRuns returns the most recent workflow runs of ownerRepo matching f
+func ListRuns(ctx context.Context, ownerRepo string, f RunFilter) ([]WorkflowRun, error) {
+	limit := f.Limit
+	if limit <= 0 {
+		limit = 20
+	}
+	query := url.Values{"per_page": {strconv.Itoa(min(limit, 100))}}
+	for key, value := range map[string]string{"branch": f.Branch, "head_sha": f.HeadSHA, "event": f.Event, "status": f.Status} {
+		if value != "" {
+			query.Set(key, value)
+		}
+	}
+
+	endpoint := fmt.Sprintf("/repos/%s/actions/runs?%s", ownerRepo, query.Encode())
+	if f.Workflow != "" {
+		endpoint = fmt.Sprintf("/repos/%s/actions/workflows/%s/runs?%s", ownerRepo, url.PathEscape(f.Workflow), query.Encode())
+	}
+	data, err := APIRequest(ctx, "GET", endpoint, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	var result struct {
+		WorkflowRuns []WorkflowRun `json:"workflow_runs"`
+	}
+	if err := json.Unmarshal(data, &result); err != nil {
+		return nil, fmt.Errorf("failed to parse workflow runs: %w", err)
+	}
+	return result.WorkflowRuns, nil
+}
+
+// GetRun fetches a workflow run by ID
+func GetRun(ctx context.Context, ownerRepo string, runID int64) (*WorkflowRun, error) {
+	data, err := APIRequest(ctx, "GET", fmt.Sprintf("/repos/%s/actions/runs/%d", ownerRepo, runID), nil)
+	if err != nil {
+		return nil, err
+	}
+
+	var run WorkflowRun
+	if err := json.Unmarshal(data, &run); err != nil {
+		return nil, fmt.Errorf("failed to parse workflow run: %w", err)
+	}
+	return &run, nil
+}
+
+// ListJobs returns the jobs of the latest attempt of a workflow run
+func ListJobs(ctx context.Context, ownerRepo string, runID int64) ([]Job, error) {
+	data, err := APIRequest(ctx, "GET", fmt.Sprintf("/repos/%s/actions/runs/%d/jobs?per_page=100", ownerRepo, runID), nil)
+	if err != nil {
+		return nil, err
+	}
+
+	var result struct {
+		Jobs []Job `json:"jobs"`
+	}
+	if err := json.Unmarshal(data, &result); err != nil {
+		return nil, fmt.Errorf("failed to parse jobs: %w", err)
+	}
+	return result.Jobs, nil
+}
+
+// ListArtifacts returns the artifacts uploaded by a workflow run
+func ListArtifacts(ctx context.Context, ownerRepo string, runID int64) ([]Artifact, error) {
+	data, err := APIRequest(ctx, "GET", fmt.Sprintf("/repos/%s/actions/runs/%d/artifacts?per_page=100", ownerRepo, runID), nil)
+	if err != nil {
+		return nil, err
+	}
+
+	var result struct {
+		Artifacts []Artifact `json:"artifacts"`
+	}
+	if err := json.Unmarshal(data, &result); err != nil {
+		return nil, fmt.Errorf("failed to parse artifacts: %w", err)
+	}
+	return result.Artifacts, nil
+}
+
+// DownloadRunLogs writes the logs of all jobs of a run to w as a zip archive
+func DownloadRunLogs(ctx context.Context, ownerRepo string, runID int64, w io.Writer) error {
+	return download(ctx, fmt.Sprintf("/repos/%s/actions/runs/%d/logs", ownerRepo, runID), w)
+}
+
+// DownloadArtifact writes an artifact to w as a zip archive
+func DownloadArtifact(ctx context.Context, ownerRepo string, artifactID int64, w io.Writer) error {
+	return download(ctx, fmt.Sprintf("/repos/%s/actions/artifacts/%d/zip", ownerRepo, artifactID), w)
+}
+
+// DispatchWorkflow triggers a workflow_dispatch event for a workflow on ref.
+// The API doesn't say which run it starts.
+func DispatchWorkflow(ctx context.Context, ownerRepo, workflow, ref string, inputs map[string]string) error {
+	body := map[string]interface{}{"ref": ref}
+	if len(inputs) > 0 {
+		body["inputs"] = inputs
+	}
+	_, err := APIRequest(ctx, "POST", fmt.Sprintf("/repos/%s/actions/workflows/%s/dispatches", ownerRepo, url.PathEscape(workflow)), body)
+	return err
+}
+
+// RerunRun re-runs a completed workflow run, or only its failed jobs and
+// the jobs that depend on them. debug turns on step debug logging.
+func RerunRun(ctx context.Context, ownerRepo string, runID int64, failedOnly, debug bool) error {
+	endpoint := fmt.Sprintf("/repos/%s/actions/runs/%d/rerun", ownerRepo, runID)
+	if failedOnly {
+		endpoint += "-failed-jobs"
+	}
+	var body interface{}
+	if debug {
+		body = map[string]bool{"enable_debug_logging": true}
+	}
+	_, err := APIRequest(ctx, "POST", endpoint, body)
+	return err
+}
+
+// CancelRun cancels a queued or in-progress workflow run
+func CancelRun(ctx context.Context, ownerRepo string, runID int64) error {
+	_, err := APIRequest(ctx, "POST", fmt.Sprintf("/repos/%s/actions/runs/%d/cancel", ownerRepo, runID), nil)
+	return err
+}
@@ -2,30 +2,68 @@ package auth
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"net/http"
 	"os"
 	"os/exec"
+	"regexp"
+	"strconv"
 	"strings"
+	"time"
 
 	"cli-tools/internal/github"
+	"cli-tools/internal/trace"
 )
 
+// httpTimeout bounds a single API request made without gh
+const httpTimeout = 30 * time.Second
+
+// APIError is an error response from the GitHub API
+type APIError struct {
+	StatusCode int // 0 if gh didn't say
+	msg        string
+}
+
+func (e *APIError) Error() string {
+	return e.msg
+}
+
+// ghStatusRegex matches the status code gh reports with an API error,
+// e.g. "gh: Not Found (HTTP 404)"
+var ghStatusRegex = regexp.MustCompile(`\(HTTP (\d{3})\)`)
+
+// ghAPIError wraps the stderr of a failed gh api call
+func ghAPIError(stderr string) *APIError {
+	e := &APIError{msg: "gh api error: " + strings.TrimSpace(stderr)}
+	if m := ghStatusRegex.FindStringSubmatch(stderr); m != nil {
+		e.StatusCode, _ = strconv.Atoi(m[1])
+	}
+	return e
+}
+
+// isNotFound reports whether err is a 404 response
+func isNotFound(err error) bool {
+	var apiErr *APIError
+	return errors.As(err, &apiErr) && apiErr.StatusCode == http.StatusNotFound
+}
+
 // HasGhCLI checks if the gh CLI is installed and authenticated
-func HasGhCLI() bool {
-	cmd := exec.Command("gh", "auth", "status")
-	err := cmd.Run()
+func HasGhCLI(ctx context.Context) bool {
+	cmd := exec.CommandContext(ctx, "gh", "auth", "status")
+	err := trace.Run(cmd)
 	return err == nil
 }
 
 // GetToken returns the appropriate GitHub token for the current repository
 // It first checks for a host-specific token (e.g., GITHUB_TOKEN_RHEI),
 // then falls back to GITHUB_TOKEN
-func GetToken() (string, error) {
+func GetToken(ctx context.Context) (string, error) {
 	// Try to get host-specific token first
-	hostAlias, err := github.GetSSHHostAlias()
+	hostAlias, err := github.GetSSHHostAlias(ctx)
 	if err == nil && hostAlias != "" {
 		// Convert host alias to env var name
 		// e.g., "github-rhei" -> "GITHUB_TOKEN_RHEI"
@@ -60,30 +98,46 @@ func hostAliasToEnvVar(hostAlias string) string {
 
 // APIRequest makes an authenticated request to the GitHub API
 // It prefers using gh CLI if available, otherwise uses token auth
-func APIRequest(method, endpoint string, body interface{}) ([]byte, error) {
-	if HasGhCLI() {
-		return ghAPIRequest(method, endpoint, body)
+func APIRequest(ctx context.Context, method, endpoint string, body interface{}) ([]byte, error) {
+	return apiRequest(ctx, method, endpoint, "", body)
+}
+
+// apiRequest is APIRequest with a custom Accept media type, e.g.
+// application/vnd.github.diff; empty means the default JSON
+func apiRequest(ctx context.Context, method, endpoint, accept string, body interface{}) ([]byte, error) {
+	if HasGhCLI(ctx) {
+		return ghAPIRequest(ctx, method, endpoint, accept, body)
 	}
-	return tokenAPIRequest(method, endpoint, body)
+	return tokenAPIRequest(ctx, method, endpoint, accept, body)
 }
 
 // ghAPIRequest uses the gh CLI to make API requests
-func ghAPIRequest(method, endpoint string, body interface{}) ([]byte, error) {
+func ghAPIRequest(ctx context.Context, method, endpoint, accept string, body interface{}) ([]byte, error) {
 	args := []string{"api", "-X", method, endpoint}
+	if accept != "" {
+		args = append(args, "-H", "Accept: "+accept)
+	}
 
+	var stdin io.Reader
 	if body != nil {
 		jsonBody, err := json.Marshal(body)
 		if err != nil {
 			return nil, fmt.Errorf("failed to marshal body: %w", err)
 		}
-		args = append(args, "-f", string(jsonBody))
+		// Send the JSON body as-is rather than as -f key=value fields
+		args = append(args, "--input", "-")
+		stdin = bytes.NewReader(jsonBody)
 	}
 
-	cmd := exec.Command("gh", args...)
-	out, err := cmd.Output()
+	cmd := exec.CommandContext(ctx, "gh", args...)
+	cmd.Stdin = stdin
+	out, err := trace.Output(cmd)
 	if err != nil {
+		if ctx.Err() != nil {
+			return nil, context.Cause(ctx)
+		}
 		if exitErr, ok := err.(*exec.ExitError); ok {
-			return nil, fmt.Errorf("gh api error: %s", string(exitErr.Stderr))
+			return nil, ghAPIError(string(exitErr.Stderr))
 		}
 		return nil, err
 	}
@@ -91,8 +145,37 @@ func ghAPIRequest(method, endpoint string, body interface{}) ([]byte, error) {
 }
 
 // tokenAPIRequest makes a direct HTTP request using token auth
-func tokenAPIRequest(method, endpoint string, body interface{}) ([]byte, error) {
-	token, err := GetToken()
+func tokenAPIRequest(ctx context.Context, method, endpoint, accept string, body interface{}) ([]byte, error) {
+	req, err := newTokenRequest(ctx, method, endpoint, accept, body)
+	if err != nil {
+		return nil, err
+	}
+
+	client := &http.Client{Timeout: httpTimeout, Transport: &trace.Transport{}}
+	resp, err := client.Do(req)
+	if err != nil {
+		if ctx.Err() != nil {
+			return nil, context.Cause(ctx)
+		}
+		return nil, fmt.Errorf("request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	respBody, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read response: %w", err)
+	}
+
+	if resp.StatusCode >= 400 {
+		return nil, &APIError{resp.StatusCode, fmt.Sprintf("API error (%d): %s", resp.StatusCode, string(respBody))}
+	}
+
+	return respBody, nil
+}
+
+// newTokenRequest builds an API request authenticated with a token
+func newTokenRequest(ctx context.Context, method, endpoint, accept string, body interface{}) (*http.Request, error) {
+	token, err := GetToken(ctx)
 	if err != nil {
 		return nil, err
 	}
@@ -111,60 +194,79 @@ func tokenAPIRequest(method, endpoint string, body interface{}) ([]byte, error)
 		bodyReader = bytes.NewReader(jsonBody)
 	}
 
-	req, err := http.NewRequest(method, url, bodyReader)
+	req, err := http.NewRequestWithContext(ctx, method, url, bodyReader)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create request: %w", err)
 	}
 
 	req.Header.Set("Authorization", "Bearer "+token)
-	req.Header.Set("Accept", "application/vnd.github+json")
+	if accept == "" {
+		accept = "application/vnd.github+json"
+	}
+	req.Header.Set("Accept", accept)
 	req.Header.Set("X-GitHub-Api-Version", "2022-11-28")
 	if body != nil {
 		req.Header.Set("Content-Type", "application/json")
 	}
+	return req, nil
+}
 
-	client := &http.Client{}
-	resp, err := client.Do(req)
-	if err != nil {
-		return nil, fmt.Errorf("request failed: %w", err)
+// download streams the response to a GET request to w. Unlike APIRequest
+// it has no time limit besides ctx, so large files can take as long as
+// they need.
+func download(ctx context.Context, endpoint string, w io.Writer) error {
+	if HasGhCLI(ctx) {
+		var stderr bytes.Buffer
+		cmd := exec.CommandContext(ctx, "gh", "api", "-X", "GET", endpoint)
+		cmd.Stdout = w
+		cmd.Stderr = &stderr
+		if err := trace.Run(cmd); err != nil {
+			if ctx.Err() != nil {
+				return context.Cause(ctx)
+			}
+			if stderr.Len() > 0 {
+				return ghAPIError(stderr.String())
+			}
+			return err
+		}
+		return nil
 	}
-	defer resp.Body.Close()
 
-	respBody, err := io.ReadAll(resp.Body)
+	req, err := newTokenRequest(ctx, "GET", endpoint, "", nil)
 	if err != nil {
-		return nil, fmt.Errorf("failed to read response: %w", err)
+		return err
 	}
+	client := &http.Client{Transport: &trace.Transport{}}
+	resp, err := client.Do(req)
+	if err != nil {
+		if ctx.Err() != nil {
+			return context.Cause(ctx)
+		}
+		return fmt.Errorf("request failed: %w", err)
+	}
+	defer resp.Body.Close()
 
 	if resp.StatusCode >= 400 {
-		return nil, fmt.Errorf("API error (%d): %s", resp.StatusCode, string(respBody))
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, 64<<10))
+		return &APIError{resp.StatusCode, fmt.Sprintf("API error (%d): %s", resp.StatusCode, string(msg))}
 	}
-
-	return respBody, nil
-}
-
-// GetCurrentPR returns the PR number for the current branch, or 0 if none exists
-func GetCurrentPR() (int, error) {
-	if HasGhCLI() {
-		cmd := exec.Command("gh", "pr", "view", "--json", "number", "-q", ".number")
-		out, err := cmd.Output()
-		if err != nil {
-			return 0, nil // No PR for this branch
+	if _, err := io.Copy(w, resp.Body); err != nil {
+		if ctx.Err() != nil {
+			return context.Cause(ctx)
 		}
-		var num int
-		fmt.Sscanf(strings.TrimSpace(string(out)), "%d", &num)
-		return num, nil
+		return fmt.Errorf("failed to read response: %w", err)
 	}
-
-	// Fallback: use API directly
-	// This is more complex, so for now return 0 if gh is not available
-	return 0, fmt.Errorf("gh CLI required for PR detection without manual PR number")
+	return nil
 }
 
 // RunGhCommand runs a gh CLI command and returns the output
-func RunGhCommand(args ...string) (string, error) {
-	cmd := exec.Command("gh", args...)
-	out, err := cmd.Output()
+func RunGhCommand(ctx context.Context, args ...string) (string, error) {
+	cmd := exec.CommandContext(ctx, "gh", args...)
+	out, err := trace.Output(cmd)
 	if err != nil {
+		if ctx.Err() != nil {
+			return "", context.Cause(ctx)
+		}
 		if exitErr, ok := err.(*exec.ExitError); ok {
 			return "", fmt.Errorf("%s", string(exitErr.Stderr))
 		}
@@ -0,0 +1,60 @@
+package auth
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+)
+
+// CheckRun is a check run reported by a GitHub App such as Actions
+type CheckRun struct {
+	Name        string `json:"name"`
+	Status      string `json:"status"`     // queued, in_progress or completed
+	Conclusion  string `json:"conclusion"` // success, failure, neutral, cancelled, skipped, ...
+	StartedAt   string `json:"started_at"`
+	CompletedAt string `json:"completed_at"`
+	DetailsURL  string `json:"details_url"`
+	HTMLURL     string `json:"html_url"`
+}
+
+// CommitStatus is the latest status a CI service set for one context
+type CommitStatus struct {
+	Context   string `json:"context"`
+	State     string `json:"state"` // success, failure, error or pending
+	TargetURL string `json:"target_url"`
+	CreatedAt string `json:"created_at"`
+	UpdatedAt string `json:"updated_at"`
+}
+
+// ListCheckRuns returns up to 100 check runs of commit sha in ownerRepo
+func ListCheckRuns(ctx context.Context, ownerRepo, sha string) ([]CheckRun, error) {
+	data, err := APIRequest(ctx, "GET", fmt.Sprintf("/repos/%s/commits/%s/check-runs?per_page=100", ownerRepo, sha), nil)
+	if err != nil {
+		return nil, err
+	}
+
+	var result struct {
+		CheckRuns []CheckRun `json:"check_runs"`
+	}
+	if err := json.Unmarshal(data, &result); err != nil {
+		return nil, fmt.Errorf("failed to parse check runs: %w", err)
+	}
+	return result.CheckRuns, nil
+}
+
+// ListCommitStatuses returns the combined status of commit sha in
+// ownerRepo: the latest status of each context
+func ListCommitStatuses(ctx context.Context, ownerRepo, sha string) ([]CommitStatus, error) {
+	data, err := APIRequest(ctx, "GET", fmt.Sprintf("/repos/%s/commits/%s/status?per_page=100", ownerRepo, sha), nil)
+	if err != nil {
+		return nil, err
+	}
+
+	var result struct {
+		Statuses []CommitStatus `json:"statuses"`
+	}
+	if err := json.Unmarshal(data, &result); err != nil {
+		return nil, fmt.Errorf("failed to parse commit status: %w", err)
+	}
+	return result.Statuses, nil
+}
@@ -0,0 +1,287 @@
+package auth
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// GraphQL runs a GraphQL query against the GitHub API and decodes the
+// "data" field of the response into result
+func GraphQL(ctx context.Context, query string, variables map[string]interface{}, result interface{}) error {
+	body := map[string]interface{}{"query": query}
+	if len(variables) > 0 {
+		body["variables"] = variables
+	}
+
+	data, err := APIRequest(ctx, "POST", "/graphql", body)
+	if err != nil {
+		return err
+	}
+
+	var resp struct {
+		Data   json.RawMessage `json:"data"`
+		Errors []struct {
+			Message string `json:"message"`
+		} `json:"errors"`
+	}
+	if err := json.Unmarshal(data, &resp); err != nil {
+		return fmt.Errorf("failed to parse GraphQL response: %w", err)
+	}
+
+	if len(resp.Errors) > 0 {
+		msgs := make([]string, len(resp.Errors))
+		for i, e := range resp.Errors {
+			msgs[i] = e.Message
+		}
+		return fmt.Errorf("GraphQL error: %s", strings.Join(msgs, "; "))
+	}
+
+	if result == nil {
+		return nil
+	}
+	if err := json.Unmarshal(resp.Data, result); err != nil {
+		return fmt.Errorf("failed to parse GraphQL data: %w", err)
+	}
+	return nil
+}
+
+// commitPRBatchSize is how many commits are looked up per GraphQL query
+const commitPRBatchSize = 50
+
+// FindPRsForCommitsBatch looks up the PRs associated with many commits of
+// ownerRepo using batched GraphQL queries. The result maps each SHA to the
+// PRs that introduced it (see IntroducedBy); SHAs unknown to GitHub, such
+// as unpushed commits, are left out.
+func FindPRsForCommitsBatch(ctx context.Context, ownerRepo string, shas []string) (map[string][]PullRequest, error) {
+	owner, name, ok := strings.Cut(ownerRepo, "/")
+	if !ok {
+		return nil, fmt.Errorf("invalid repository: %s", ownerRepo)
+	}
+
+	result := make(map[string][]PullRequest)
+	for start := 0; start < len(shas); start += commitPRBatchSize {
+		batch := shas[start:min(start+commitPRBatchSize, len(shas))]
+
+		// One aliased object lookup per commit: c0, c1, ...
+		var q strings.Builder
+		q.WriteString("query($owner: String!, $name: String!) { repository(owner: $owner, name: $name) {")
+		for i, sha := range batch {
+			fmt.Fprintf(&q, ` c%d: object(expression: %q) { ... on Commit { associatedPullRequests(first: 5) { nodes { number title url state isDraft mergedAt author { login } } } } }`, i, sha)
+		}
+		q.WriteString(" } }")
+
+		var data struct {
+			Repository map[string]*struct {
+				AssociatedPullRequests struct {
+					Nodes []struct {
+						Number   int    `json:"number"`
+						Title    string `json:"title"`
+						URL      string `json:"url"`
+						State    string `json:"state"`
+						IsDraft  bool   `json:"isDraft"`
+						MergedAt string `json:"mergedAt"`
+						Author   struct {
+							Login string `json:"login"`
+						} `json:"author"`
+					} `json:"nodes"`
+				} `json:"associatedPullRequests"`
+			} `json:"repository"`
+		}
+		vars := map[string]interface{}{"owner": owner, "name": name}
+		if err := GraphQL(ctx, q.String(), vars, &data); err != nil {
+			return nil, err
+		}
+
+		for i, sha := range batch {
+			commit := data.Repository[fmt.Sprintf("c%d", i)]
+			if commit == nil {
+				continue
+			}
+			var prs []PullRequest
+			for _, n := range commit.AssociatedPullRequests.Nodes {
+				pr := PullRequest{
+					Number:   n.Number,
+					Title:    n.Title,
+					State:    "open",
+					Draft:    n.IsDraft,
+					MergedAt: n.MergedAt,
+					HTMLURL:  n.URL,
+				}
+				if n.State != "OPEN" {
+					pr.State = "closed"
+				}
+				pr.User.Login = n.Author.Login
+				prs = append(prs, pr)
+			}
+			if len(prs) > 0 {
+				result[sha] = IntroducedBy(prs)
+			}
+		}
+	}
+	return result, nil
+}
+
+// ListOpenPRs returns up to limit open PRs of ownerRepo, most recently
+// updated first, including the CI state of each PR's head commit. A
+// non-empty head keeps only PRs from a branch of that name, on any fork.
+func ListOpenPRs(ctx context.Context, ownerRepo, head string, limit int) ([]PullRequest, error) {
+	owner, name, ok := strings.Cut(ownerRepo, "/")
+	if !ok {
+		return nil, fmt.Errorf("invalid repository: %s", ownerRepo)
+	}
+
+	params, filter := "", ""
+	vars := map[string]interface{}{"owner": owner, "name": name, "limit": limit}
+	if head != "" {
+		params, filter = ", $head: String!", "headRefName: $head, "
+		vars["head"] = head
+	}
+	query := `query($owner: String!, $name: String!, $limit: Int!` + params + `) {
+  repository(owner: $owner, name: $name) {
+    pullRequests(` + filter + `states: OPEN, first: $limit, orderBy: {field: UPDATED_AT, direction: DESC}) {
+      nodes {
+        number title url isDraft headRefName
+        author { login }
+        headRepository { nameWithOwner owner { login } }
+        commits(last: 1) { nodes { commit { statusCheckRollup { state } } } }
+      }
+    }
+  }
+}`
+
+	var data struct {
+		Repository struct {
+			PullRequests struct {
+				Nodes []struct {
+					Number      int    `json:"number"`
+					Title       string `json:"title"`
+					URL         string `json:"url"`
+					IsDraft     bool   `json:"isDraft"`
+					HeadRefName string `json:"headRefName"`
+					Author      struct {
+						Login string `json:"login"`
+					} `json:"author"`
+					HeadRepository *struct {
+						NameWithOwner string `json:"nameWithOwner"`
+						Owner         struct {
+							Login string `json:"login"`
+						} `json:"owner"`
+					} `json:"headRepository"`
+					Commits struct {
+						Nodes []struct {
+							Commit struct {
+								StatusCheckRollup *struct {
+									State string `json:"state"`
+								} `json:"statusCheckRollup"`
+							} `json:"commit"`
+						} `json:"nodes"`
+					} `json:"commits"`
+				} `json:"nodes"`
+			} `json:"pullRequests"`
+		} `json:"repository"`
+	}
+	if err := GraphQL(ctx, query, vars, &data); err != nil {
+		return nil, err
+	}
+
+	var prs []PullRequest
+	for _, n := range data.Repository.PullRequests.Nodes {
+		pr := PullRequest{
+			Number:  n.Number,
+			Title:   n.Title,
+			State:   "open",
+			Draft:   n.IsDraft,
+			HTMLURL: n.URL,
+		}
+		pr.User.Login = n.Author.Login
+		pr.Head.Ref = n.HeadRefName
+		if n.HeadRepository != nil {
+			pr.Head.Repo = &PRRepo{FullName: n.HeadRepository.NameWithOwner}
+			pr.Head.Repo.Owner.Login = n.HeadRepository.Owner.Login
+		}
+		if len(n.Commits.Nodes) > 0 && n.Commits.Nodes[0].Commit.StatusCheckRollup != nil {
+			pr.CheckState = n.Commits.Nodes[0].Commit.StatusCheckRollup.State
+		}
+		prs = append(prs, pr)
+	}
+	return prs, nil
+}
+
+// PRSummary is the state of one PR as listed by ListMyOpenPRs
+type PRSummary struct {
+	Repo           string // "owner/repo"
+	Number         int
+	Title          string
+	URL            string
+	IsDraft        bool
+	Mergeable      string // MERGEABLE, CONFLICTING or UNKNOWN
+	ReviewDecision string // APPROVED, CHANGES_REQUESTED, REVIEW_REQUIRED or empty
+	CheckState     string // combined state of the head commit's checks, empty if none
+}
+
+// ListMyOpenPRs returns up to limit open PRs you authored, across all
+// repositories, most recently updated first, and the total count
+func ListMyOpenPRs(ctx context.Context, limit int) ([]PRSummary, int, error) {
+	query := `query($q: String!, $limit: Int!) {
+  search(query: $q, type: ISSUE, first: $limit) {
+    issueCount
+    nodes {
+      ... on PullRequest {
+        number title url isDraft mergeable reviewDecision
+        repository { nameWithOwner }
+        commits(last: 1) { nodes { commit { statusCheckRollup { state } } } }
+      }
+    }
+  }
+}`
+
+	var data struct {
+		Search struct {
+			IssueCount int `json:"issueCount"`
+			Nodes      []struct {
+				Number         int    `json:"number"`
+				Title          string `json:"title"`
+				URL            string `json:"url"`
+				IsDraft        bool   `json:"isDraft"`
+				Mergeable      string `json:"mergeable"`
+				ReviewDecision string `json:"reviewDecision"`
+				Repository     struct {
+					NameWithOwner string `json:"nameWithOwner"`
+				} `json:"repository"`
+				Commits struct {
+					Nodes []struct {
+						Commit struct {
+							StatusCheckRollup *struct {
+								State string `json:"state"`
+							} `json:"statusCheckRollup"`
+						} `json:"commit"`
+					} `json:"nodes"`
+				} `json:"commits"`
+			} `json:"nodes"`
+		} `json:"search"`
+	}
+	vars := map[string]interface{}{"q": "is:pr is:open author:@me archived:false sort:updated-desc", "limit": limit}
+	if err := GraphQL(ctx, query, vars, &data); err != nil {
+		return nil, 0, err
+	}
+
+	var prs []PRSummary
+	for _, n := range data.Search.Nodes {
+		pr := PRSummary{
+			Repo:           n.Repository.NameWithOwner,
+			Number:         n.Number,
+			Title:          n.Title,
+			URL:            n.URL,
+			IsDraft:        n.IsDraft,
+			Mergeable:      n.Mergeable,
+			ReviewDecision: n.ReviewDecision,
+		}
+		if len(n.Commits.Nodes) > 0 && n.Commits.Nodes[0].Commit.StatusCheckRollup != nil {
+			pr.CheckState = n.Commits.Nodes[0].Commit.StatusCheckRollup.State
+		}
+		prs = append(prs, pr)
+	}
+	return prs, data.Search.IssueCount, nil
+}
@@ -0,0 +1,49 @@
+package auth
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/url"
+)
+
+// Issue is the subset of the REST API issue object used by the tools
+type Issue struct {
+	Number  int    `json:"number"`
+	Title   string `json:"title"`
+	State   string `json:"state"`
+	HTMLURL string `json:"html_url"`
+	User    struct {
+		Login string `json:"login"`
+	} `json:"user"`
+	Labels []struct {
+		Name string `json:"name"`
+	} `json:"labels"`
+
+	// PullRequest is set when the issue is really a pull request; the
+	// issues API returns both
+	PullRequest *json.RawMessage `json:"pull_request,omitempty"`
+}
+
+// ListOpenIssues lists up to 100 open issues of ownerRepo, most recently
+// updated first. Pull requests are left out.
+func ListOpenIssues(ctx context.Context, ownerRepo string) ([]Issue, error) {
+	query := url.Values{"state": {"open"}, "sort": {"updated"}, "per_page": {"100"}}
+	data, err := APIRequest(ctx, "GET", fmt.Sprintf("/repos/%s/issues?%s", ownerRepo, query.Encode()), nil)
+	if err != nil {
+		return nil, err
+	}
+
+	var all []Issue
+	if err := json.Unmarshal(data, &all); err != nil {
+		return nil, fmt.Errorf("failed to parse issue list: %w", err)
+	}
+
+	var issues []Issue
+	for _, issue := range all {
+		if issue.PullRequest == nil {
+			issues = append(issues, issue)
+		}
+	}
+	return issues, nil
+}
@@ -0,0 +1,277 @@
+package auth
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"strings"
+)
+
+// MergeInfo is what deciding whether and how a PR can be merged needs to
+// know about the PR and its repository
+type MergeInfo struct {
+	ID               string // GraphQL node ID
+	Number           int
+	Title            string
+	Body             string
+	URL              string
+	State            string // OPEN, CLOSED or MERGED
+	IsDraft          bool
+	Mergeable        string // MERGEABLE, CONFLICTING or UNKNOWN
+	MergeStateStatus string // CLEAN, BEHIND, BLOCKED, DIRTY, UNSTABLE, ...
+	ReviewDecision   string // APPROVED, CHANGES_REQUESTED, REVIEW_REQUIRED or ""
+	HeadRefName      string
+	HeadRefOid       string
+	HeadRepo         string // "owner/repo"; empty if the fork was deleted
+	BaseRefName      string
+	AutoMergeEnabled bool
+	Checks           []Check
+
+	// Repository settings
+	DefaultBranch       string
+	MergeCommitAllowed  bool
+	SquashMergeAllowed  bool
+	RebaseMergeAllowed  bool
+	AutoMergeAllowed    bool
+	DeleteBranchOnMerge bool
+}
+
+// Check is a check run or commit status on a PR's head commit
+type Check struct {
+	Name string
+	// State is the conclusion of a finished check (SUCCESS, FAILURE,
+	// SKIPPED, ...) or the status of an unfinished one (PENDING, QUEUED,
+	// IN_PROGRESS, ...)
+	State    string
+	Required bool // required by branch protection for this PR
+}
+
+// Passed reports whether the check finished without failing
+func (c *Check) Passed() bool {
+	switch c.State {
+	case "SUCCESS", "NEUTRAL", "SKIPPED":
+		return true
+	}
+	return false
+}
+
+// Pending reports whether the check has not finished yet
+func (c *Check) Pending() bool {
+	switch c.State {
+	case "PENDING", "EXPECTED", "QUEUED", "IN_PROGRESS", "WAITING", "REQUESTED":
+		return true
+	}
+	return false
+}
+
+// AllowedMethods returns the merge methods ("merge", "squash", "rebase")
+// the repository allows, in that order
+func (m *MergeInfo) AllowedMethods() []string {
+	return mergeMethods(m.MergeCommitAllowed, m.SquashMergeAllowed, m.RebaseMergeAllowed)
+}
+
+// GetMergeInfo fetches the merge state of PR number in ownerRepo along with
+// the repository's merge settings
+func GetMergeInfo(ctx context.Context, ownerRepo string, number int) (*MergeInfo, error) {
+	owner, name, ok := strings.Cut(ownerRepo, "/")
+	if !ok {
+		return nil, fmt.Errorf("invalid repository: %s", ownerRepo)
+	}
+
+	query := `query($owner: String!, $name: String!, $number: Int!) {
+  repository(owner: $owner, name: $name) {
+    mergeCommitAllowed squashMergeAllowed rebaseMergeAllowed autoMergeAllowed deleteBranchOnMerge
+    defaultBranchRef { name }
+    pullRequest(number: $number) {
+      id number title body url state isDraft mergeable mergeStateStatus reviewDecision
+      headRefName headRefOid baseRefName
+      headRepository { nameWithOwner }
+      autoMergeRequest { enabledAt }
+      commits(last: 1) { nodes { commit { statusCheckRollup { contexts(first: 100) { nodes {
+        __typename
+        ... on CheckRun { name status conclusion isRequired(pullRequestNumber: $number) }
+        ... on StatusContext { context state isRequired(pullRequestNumber: $number) }
+      } } } } } }
+    }
+  }
+}`
+
+	var data struct {
+		Repository struct {
+			MergeCommitAllowed  bool `json:"mergeCommitAllowed"`
+			SquashMergeAllowed  bool `json:"squashMergeAllowed"`
+			RebaseMergeAllowed  bool `json:"rebaseMergeAllowed"`
+			AutoMergeAllowed    bool `json:"autoMergeAllowed"`
+			DeleteBranchOnMerge bool `json:"deleteBranchOnMerge"`
+			DefaultBranchRef    *struct {
+				Name string `json:"name"`
+			} `json:"defaultBranchRef"`
+			PullRequest *struct {
+				ID               string `json:"id"`
+				Number           int    `json:"number"`
+				Title            string `json:"title"`
+				Body             string `json:"body"`
+				URL              string `json:"url"`
+				State            string `json:"state"`
+				IsDraft          bool   `json:"isDraft"`
+				Mergeable        string `json:"mergeable"`
+				MergeStateStatus string `json:"mergeStateStatus"`
+				ReviewDecision   string `json:"reviewDecision"`
+				HeadRefName      string `json:"headRefName"`
+				HeadRefOid       string `json:"headRefOid"`
+				BaseRefName      string `json:"baseRefName"`
+				HeadRepository   *struct {
+					NameWithOwner string `json:"nameWithOwner"`
+				} `json:"headRepository"`
+				AutoMergeRequest *struct {
+					EnabledAt string `json:"enabledAt"`
+				} `json:"autoMergeRequest"`
+				Commits struct {
+					Nodes []struct {
+						Commit struct {
+							StatusCheckRollup *struct {
+								Contexts struct {
+									Nodes []struct {
+										Typename   string `json:"__typename"`
+										Name       string `json:"name"`
+										Status     string `json:"status"`
+										Conclusion string `json:"conclusion"`
+										Context    string `json:"context"`
+										State      string `json:"state"`
+										IsRequired bool   `json:"isRequired"`
+									} `json:"nodes"`
+								} `json:"contexts"`
+							} `json:"statusCheckRollup"`
+						} `json:"commit"`
+					} `json:"nodes"`
+				} `json:"commits"`
+			} `json:"pullRequest"`
+		} `json:"repository"`
+	}
+	vars := map[string]interface{}{"owner": owner, "name": name, "number": number}
+	if err := GraphQL(ctx, query, vars, &data); err != nil {
+		return nil, err
+	}
+
+	repo := data.Repository
+	pr := repo.PullRequest
+	if pr == nil {
+		return nil, fmt.Errorf("PR #%d not found in %s", number, ownerRepo)
+	}
+
+	info := &MergeInfo{
+		ID:                  pr.ID,
+		Number:              pr.Number,
+		Title:               pr.Title,
+		Body:                pr.Body,
+		URL:                 pr.URL,
+		State:               pr.State,
+		IsDraft:             pr.IsDraft,
+		Mergeable:           pr.Mergeable,
+		MergeStateStatus:    pr.MergeStateStatus,
+		ReviewDecision:      pr.ReviewDecision,
+		HeadRefName:         pr.HeadRefName,
+		HeadRefOid:          pr.HeadRefOid,
+		BaseRefName:         pr.BaseRefName,
+		AutoMergeEnabled:    pr.AutoMergeRequest != nil,
+		MergeCommitAllowed:  repo.MergeCommitAllowed,
+		SquashMergeAllowed:  repo.SquashMergeAllowed,
+		RebaseMergeAllowed:  repo.RebaseMergeAllowed,
+		AutoMergeAllowed:    repo.AutoMergeAllowed,
+		DeleteBranchOnMerge: repo.DeleteBranchOnMerge,
+	}
+	if pr.HeadRepository != nil {
+		info.HeadRepo = pr.HeadRepository.NameWithOwner
+	}
+	if repo.DefaultBranchRef != nil {
+		info.DefaultBranch = repo.DefaultBranchRef.Name
+	}
+	if len(pr.Commits.Nodes) > 0 && pr.Commits.Nodes[0].Commit.StatusCheckRollup != nil {
+		for _, n := range pr.Commits.Nodes[0].Commit.StatusCheckRollup.Contexts.Nodes {
+			c := Check{Name: n.Name, State: n.Conclusion, Required: n.IsRequired}
+			if n.Typename == "StatusContext" {
+				c.Name, c.State = n.Context, n.State
+			} else if c.State == "" {
+				c.State = n.Status
+			}
+			info.Checks = append(info.Checks, c)
+		}
+	}
+	return info, nil
+}
+
+// MergeOptions controls how a PR is merged. Title and Message are left to
+// GitHub's defaults when empty; they are ignored by the rebase method.
+type MergeOptions struct {
+	Method  string // "merge", "squash" or "rebase"
+	Title   string
+	Message string
+}
+
+// MergePR merges the PR, provided its head is still at headSHA, and returns
+// the SHA of the resulting commit
+func MergePR(ctx context.Context, ownerRepo string, number int, headSHA string, opts MergeOptions) (string, error) {
+	body := map[string]string{"merge_method": opts.Method, "sha": headSHA}
+	if opts.Title != "" {
+		body["commit_title"] = opts.Title
+	}
+	if opts.Message != "" {
+		body["commit_message"] = opts.Message
+	}
+
+	data, err := APIRequest(ctx, "PUT", fmt.Sprintf("/repos/%s/pulls/%d/merge", ownerRepo, number), body)
+	if err != nil {
+		return "", err
+	}
+
+	var result struct {
+		SHA     string `json:"sha"`
+		Merged  bool   `json:"merged"`
+		Message string `json:"message"`
+	}
+	if err := json.Unmarshal(data, &result); err != nil {
+		return "", fmt.Errorf("failed to parse merge result: %w", err)
+	}
+	if !result.Merged {
+		return "", fmt.Errorf("not merged: %s", result.Message)
+	}
+	return result.SHA, nil
+}
+
+// EnableAutoMerge turns on auto-merge for the PR with GraphQL node ID prID,
+// so GitHub merges it once its requirements are met
+func EnableAutoMerge(ctx context.Context, prID, headSHA string, opts MergeOptions) error {
+	input := map[string]interface{}{
+		"pullRequestId":   prID,
+		"mergeMethod":     strings.ToUpper(opts.Method),
+		"expectedHeadOid": headSHA,
+	}
+	if opts.Title != "" {
+		input["commitHeadline"] = opts.Title
+	}
+	if opts.Message != "" {
+		input["commitBody"] = opts.Message
+	}
+
+	query := `mutation($input: EnablePullRequestAutoMergeInput!) {
+  enablePullRequestAutoMerge(input: $input) { clientMutationId }
+}`
+	return GraphQL(ctx, query, map[string]interface{}{"input": input}, nil)
+}
+
+// DeleteRemoteBranch deletes a branch of ownerRepo on GitHub
+func DeleteRemoteBranch(ctx context.Context, ownerRepo, branch string) error {
+	_, err := APIRequest(ctx, "DELETE", fmt.Sprintf("/repos/%s/git/refs/heads/%s", ownerRepo, escapeBranch(branch)), nil)
+	return err
+}
+
+// escapeBranch escapes a branch name for an API path. Slashes in it stay as
+// they are, e.g. heads/feature/login.
+func escapeBranch(branch string) string {
+	parts := strings.Split(branch, "/")
+	for i, p := range parts {
+		parts[i] = url.PathEscape(p)
+	}
+	return strings.Join(parts, "/")
+}
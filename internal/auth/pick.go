@@ -0,0 +1,95 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"cli-tools/internal/picker"
+	"cli-tools/internal/prompt"
+)
+
+// PickOpenPR lists the open PRs of ownerRepo in the fuzzy picker and
+// returns the one the user chooses
+func PickOpenPR(ctx context.Context, ownerRepo string) (*PullRequest, error) {
+	if !prompt.IsInteractive() {
+		return nil, errors.New("no PR given and stdin is not a terminal; pass a PR number")
+	}
+
+	prs, err := ListOpenPRs(ctx, ownerRepo, "", 100)
+	if err != nil {
+		return nil, err
+	}
+	if len(prs) == 0 {
+		return nil, fmt.Errorf("no open PRs in %s", ownerRepo)
+	}
+
+	options := make([]string, len(prs))
+	for i, pr := range prs {
+		options[i] = fmt.Sprintf("#%d %s  @%s", pr.Number, pr.Title, pr.User.Login)
+		if state := FormatCheckState(pr.CheckState); state != "" {
+			options[i] += " " + state
+		}
+		if pr.Draft {
+			options[i] += " (draft)"
+		}
+	}
+
+	idx, err := picker.Pick(ctx, fmt.Sprintf("Open PRs in %s:", ownerRepo), options)
+	if err != nil {
+		return nil, err
+	}
+	return &prs[idx], nil
+}
+
+// PickOpenIssue lists the open issues of ownerRepo in the fuzzy picker and
+// returns the one the user chooses
+func PickOpenIssue(ctx context.Context, ownerRepo string) (*Issue, error) {
+	if !prompt.IsInteractive() {
+		return nil, errors.New("no issue given and stdin is not a terminal; pass an issue number")
+	}
+
+	issues, err := ListOpenIssues(ctx, ownerRepo)
+	if err != nil {
+		return nil, err
+	}
+	if len(issues) == 0 {
+		return nil, fmt.Errorf("no open issues in %s", ownerRepo)
+	}
+
+	options := make([]string, len(issues))
+	for i, issue := range issues {
+		options[i] = fmt.Sprintf("#%d %s  @%s", issue.Number, issue.Title, issue.User.Login)
+		if len(issue.Labels) > 0 {
+			names := make([]string, len(issue.Labels))
+			for j, l := range issue.Labels {
+				names[j] = l.Name
+			}
+			options[i] += " [" + strings.Join(names, ", ") + "]"
+		}
+	}
+
+	idx, err := picker.Pick(ctx, fmt.Sprintf("Open issues in %s:", ownerRepo), options)
+	if err != nil {
+		return nil, err
+	}
+	return &issues[idx], nil
+}
+
+// FormatCheckState renders a CI rollup state (SUCCESS, FAILURE, ...) as a
+// short marker like pr-status does
+func FormatCheckState(s string) string {
+	switch s {
+	case "SUCCESS":
+		return "[OK]"
+	case "FAILURE", "ERROR":
+		return "[FAIL]"
+	case "PENDING", "EXPECTED":
+		return "[...]"
+	case "":
+		return ""
+	default:
+		return "[" + s + "]"
+	}
+}
@@ -0,0 +1,428 @@
+package auth
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/url"
+	"os/exec"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"cli-tools/internal/git"
+	"cli-tools/internal/github"
+	"cli-tools/internal/picker"
+	"cli-tools/internal/prompt"
+	"cli-tools/internal/trace"
+)
+
+// PullRequest is the subset of the REST API pull request object used by the tools
+type PullRequest struct {
+	NodeID   string `json:"node_id"`
+	Number   int    `json:"number"`
+	Title    string `json:"title"`
+	State    string `json:"state"` // "open" or "closed"
+	Draft    bool   `json:"draft"`
+	MergedAt string `json:"merged_at"`
+	HTMLURL  string `json:"html_url"`
+	User     struct {
+		Login string `json:"login"`
+	} `json:"user"`
+	Head                PRBranch `json:"head"`
+	Base                PRBranch `json:"base"`
+	MaintainerCanModify bool     `json:"maintainer_can_modify"`
+	Labels              []struct {
+		Name string `json:"name"`
+	} `json:"labels"`
+
+	// Mergeable is nil while GitHub is still computing it. It is only
+	// returned when fetching a single PR.
+	Mergeable *bool `json:"mergeable"`
+
+	// CheckState is the combined CI state of the head commit (SUCCESS,
+	// FAILURE, PENDING, ...). Only ListOpenPRs fills it in.
+	CheckState string `json:"-"`
+}
+
+// PRBranch is the head or base side of a pull request
+type PRBranch struct {
+	Ref  string  `json:"ref"`
+	SHA  string  `json:"sha"`
+	Repo *PRRepo `json:"repo"` // nil when the head fork was deleted
+}
+
+// PRRepo is the repository on one side of a pull request
+type PRRepo struct {
+	FullName string `json:"full_name"`
+	Owner    struct {
+		Login string `json:"login"`
+	} `json:"owner"`
+}
+
+// Status returns "open", "draft", "merged" or "closed"
+func (pr *PullRequest) Status() string {
+	switch {
+	case pr.MergedAt != "":
+		return "merged"
+	case pr.State == "open" && pr.Draft:
+		return "draft"
+	default:
+		return pr.State
+	}
+}
+
+// BaseRepo returns "owner/repo" of the repository the PR was opened against
+func (pr *PullRequest) BaseRepo() string {
+	if pr.Base.Repo != nil {
+		return pr.Base.Repo.FullName
+	}
+	// https://github.com/owner/repo/pull/123 -> owner/repo
+	parts := strings.Split(strings.TrimPrefix(pr.HTMLURL, "https://"), "/")
+	if len(parts) >= 3 {
+		return parts[1] + "/" + parts[2]
+	}
+	return ""
+}
+
+// GetPR fetches a pull request by number from ownerRepo ("owner/repo")
+func GetPR(ctx context.Context, ownerRepo string, number int) (*PullRequest, error) {
+	data, err := APIRequest(ctx, "GET", fmt.Sprintf("/repos/%s/pulls/%d", ownerRepo, number), nil)
+	if err != nil {
+		return nil, err
+	}
+
+	var pr PullRequest
+	if err := json.Unmarshal(data, &pr); err != nil {
+		return nil, fmt.Errorf("failed to parse PR: %w", err)
+	}
+	return &pr, nil
+}
+
+// GetPRDiff fetches the unified diff of a pull request
+func GetPRDiff(ctx context.Context, ownerRepo string, number int) (string, error) {
+	data, err := apiRequest(ctx, "GET", fmt.Sprintf("/repos/%s/pulls/%d", ownerRepo, number), "application/vnd.github.diff", nil)
+	if err != nil {
+		return "", err
+	}
+	return string(data), nil
+}
+
+// ListPRs lists up to 100 PRs of ownerRepo in the given state ("open",
+// "closed" or "all"), optionally only those whose head is "owner:branch"
+func ListPRs(ctx context.Context, ownerRepo, state, head string) ([]PullRequest, error) {
+	query := url.Values{"state": {state}, "per_page": {"100"}}
+	if head != "" {
+		query.Set("head", head)
+	}
+
+	data, err := APIRequest(ctx, "GET", fmt.Sprintf("/repos/%s/pulls?%s", ownerRepo, query.Encode()), nil)
+	if err != nil {
+		return nil, err
+	}
+
+	var prs []PullRequest
+	if err := json.Unmarshal(data, &prs); err != nil {
+		return nil, fmt.Errorf("failed to parse PR list: %w", err)
+	}
+	return prs, nil
+}
+
+// pullRefRegex matches the ref GitHub publishes each PR's head under
+var pullRefRegex = regexp.MustCompile(`^refs/pull/(\d+)/head$`)
+
+// FindPRsForBranch returns the PRs, open or closed, whose head is branch.
+// It matches the branch's upstream (which may live on a fork, i.e.
+// "fork-owner:branch") as well as the branch name on origin, and looks
+// in both origin and the "upstream" remote's repository if one exists.
+// A branch tracking refs/pull/N/head maps straight to PR N.
+// Open PRs are listed first, newest first.
+func FindPRsForBranch(ctx context.Context, branch string) ([]PullRequest, error) {
+	repo, bases, err := baseRepos(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var heads []string
+	remote, remoteBranch, err := git.GetUpstream(ctx, branch)
+	if err != nil {
+		return nil, err
+	}
+
+	// Branches checked out from refs/pull/N/head track the PR directly
+	if m := pullRefRegex.FindStringSubmatch(remoteBranch); m != nil {
+		if info, err := github.GetRemoteRepoInfo(ctx, remote); err == nil {
+			number, _ := strconv.Atoi(m[1])
+			pr, err := GetPR(ctx, info.Owner+"/"+info.Repo, number)
+			if err != nil {
+				return nil, err
+			}
+			return []PullRequest{*pr}, nil
+		}
+	}
+
+	if remote != "" {
+		if info, err := github.GetRemoteRepoInfo(ctx, remote); err == nil {
+			heads = append(heads, info.Owner+":"+remoteBranch)
+		}
+	}
+	if head := repo.Owner + ":" + branch; len(heads) == 0 || heads[0] != head {
+		heads = append(heads, head)
+	}
+
+	seen := make(map[string]bool)
+	var prs []PullRequest
+	for _, base := range bases {
+		for _, head := range heads {
+			page, err := ListPRs(ctx, base, "all", head)
+			if err != nil {
+				return nil, err
+			}
+			for _, pr := range page {
+				if !seen[pr.HTMLURL] {
+					seen[pr.HTMLURL] = true
+					prs = append(prs, pr)
+				}
+			}
+		}
+	}
+
+	sortPRs(prs)
+	return prs, nil
+}
+
+// FindPRsForHead returns the PRs whose head is head, looking in origin and
+// the "upstream" remote's repository. "owner:branch" matches PRs in any
+// state; a bare branch name matches open PRs from that branch on any fork.
+func FindPRsForHead(ctx context.Context, head string) ([]PullRequest, error) {
+	_, bases, err := baseRepos(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	seen := make(map[string]bool)
+	var prs []PullRequest
+	for _, base := range bases {
+		var page []PullRequest
+		if strings.Contains(head, ":") {
+			page, err = ListPRs(ctx, base, "all", head)
+		} else {
+			page, err = ListOpenPRs(ctx, base, head, 100)
+		}
+		if err != nil {
+			return nil, err
+		}
+		for _, pr := range page {
+			if !seen[pr.HTMLURL] {
+				seen[pr.HTMLURL] = true
+				prs = append(prs, pr)
+			}
+		}
+	}
+
+	sortPRs(prs)
+	return prs, nil
+}
+
+// ErrCommitNotFound is returned by FindPRsForCommit when GitHub doesn't know
+// the commit, usually because it hasn't been pushed
+var ErrCommitNotFound = errors.New("commit not found on GitHub")
+
+// FindPRsForCommit returns the PRs associated with a commit: the PR that
+// merged it, or open PRs that contain it. sha must be a full commit SHA.
+func FindPRsForCommit(ctx context.Context, sha string) ([]PullRequest, error) {
+	_, bases, err := baseRepos(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	seen := make(map[string]bool)
+	var prs []PullRequest
+	var firstErr error
+	answered, notFound := 0, 0
+	for _, base := range bases {
+		data, err := APIRequest(ctx, "GET", fmt.Sprintf("/repos/%s/commits/%s/pulls", base, sha), nil)
+		if err != nil {
+			if ctx.Err() != nil {
+				return nil, context.Cause(ctx)
+			}
+			// The commit may only exist in one of the repos, e.g. the fork
+			if strings.Contains(err.Error(), "No commit found") {
+				notFound++
+			} else if firstErr == nil {
+				firstErr = err
+			}
+			continue
+		}
+		answered++
+
+		var page []PullRequest
+		if err := json.Unmarshal(data, &page); err != nil {
+			return nil, fmt.Errorf("failed to parse PR list: %w", err)
+		}
+		for _, pr := range page {
+			if !seen[pr.HTMLURL] {
+				seen[pr.HTMLURL] = true
+				prs = append(prs, pr)
+			}
+		}
+	}
+	if answered == 0 {
+		if notFound == len(bases) {
+			return nil, fmt.Errorf("%w: %s", ErrCommitNotFound, sha)
+		}
+		return nil, firstErr
+	}
+
+	sortPRs(prs)
+	return prs, nil
+}
+
+// UpdatePRBranch brings a PR's head branch up to date with its base on
+// GitHub, by merging the base into it or, if rebase is set, rebasing it.
+// It fails if the head moved since pr was fetched or the update conflicts.
+func UpdatePRBranch(ctx context.Context, pr *PullRequest, rebase bool) error {
+	if rebase {
+		// Only the GraphQL API can rebase
+		query := `mutation($id: ID!, $sha: GitObjectID) {
+  updatePullRequestBranch(input: {pullRequestId: $id, expectedHeadOid: $sha, updateMethod: REBASE}) {
+    pullRequest { number }
+  }
+}`
+		vars := map[string]interface{}{"id": pr.NodeID, "sha": pr.Head.SHA}
+		return GraphQL(ctx, query, vars, nil)
+	}
+
+	body := map[string]string{"expected_head_sha": pr.Head.SHA}
+	_, err := APIRequest(ctx, "PUT", fmt.Sprintf("/repos/%s/pulls/%d/update-branch", pr.BaseRepo(), pr.Number), body)
+	return err
+}
+
+// IntroducedBy narrows a commit's PRs to the merged ones, since the PR that
+// merged a commit is what introduced it; open PRs are kept only if none merged
+func IntroducedBy(prs []PullRequest) []PullRequest {
+	var merged []PullRequest
+	for _, pr := range prs {
+		if pr.MergedAt != "" {
+			merged = append(merged, pr)
+		}
+	}
+	if len(merged) > 0 {
+		return merged
+	}
+	return prs
+}
+
+// baseRepos returns origin's repo info and the "owner/repo" names PRs may be
+// opened against: origin, plus the "upstream" remote when origin is a fork
+func baseRepos(ctx context.Context) (*github.RepoInfo, []string, error) {
+	repo, err := github.GetRepoInfo(ctx)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	bases := []string{repo.Owner + "/" + repo.Repo}
+	if up, err := github.GetRemoteRepoInfo(ctx, "upstream"); err == nil {
+		if name := up.Owner + "/" + up.Repo; name != bases[0] {
+			bases = append(bases, name)
+		}
+	} else if ctx.Err() != nil {
+		return nil, nil, context.Cause(ctx)
+	}
+	return repo, bases, nil
+}
+
+// sortPRs orders open PRs first, then newest first
+func sortPRs(prs []PullRequest) {
+	sort.SliceStable(prs, func(i, j int) bool {
+		if oi, oj := prs[i].State == "open", prs[j].State == "open"; oi != oj {
+			return oi
+		}
+		return prs[i].Number > prs[j].Number
+	})
+}
+
+// ChoosePR picks the PR a command should act on from the candidates.
+// A single open PR wins; otherwise the user is asked to choose.
+// It returns nil if there are no candidates.
+func ChoosePR(ctx context.Context, prs []PullRequest) (*PullRequest, error) {
+	candidates := prs
+	var open []PullRequest
+	for _, pr := range prs {
+		if pr.State == "open" {
+			open = append(open, pr)
+		}
+	}
+	if len(open) > 0 {
+		candidates = open
+	}
+
+	switch len(candidates) {
+	case 0:
+		return nil, nil
+	case 1:
+		return &candidates[0], nil
+	}
+
+	options := make([]string, len(candidates))
+	for i, pr := range candidates {
+		options[i] = fmt.Sprintf("#%d %s (%s, %s)", pr.Number, pr.Title, pr.Status(), pr.BaseRepo())
+	}
+	idx, err := picker.Pick(ctx, "Multiple PRs match:", options)
+	if err != nil {
+		if errors.Is(err, prompt.ErrNotInteractive) {
+			nums := make([]string, len(candidates))
+			for i, pr := range candidates {
+				nums[i] = fmt.Sprintf("#%d", pr.Number)
+			}
+			return nil, fmt.Errorf("multiple PRs match (%s); pass a PR number", strings.Join(nums, ", "))
+		}
+		return nil, err
+	}
+	return &candidates[idx], nil
+}
+
+// GetCurrentPullRequest returns the PR for the current branch, or nil if none exists.
+// It asks gh when available and otherwise searches the API.
+func GetCurrentPullRequest(ctx context.Context) (*PullRequest, error) {
+	if HasGhCLI(ctx) {
+		cmd := exec.CommandContext(ctx, "gh", "pr", "view", "--json", "url", "-q", ".url")
+		out, err := trace.Output(cmd)
+		if err != nil {
+			if ctx.Err() != nil {
+				return nil, context.Cause(ctx)
+			}
+			return nil, nil // No PR for this branch
+		}
+		ownerRepo, number, err := github.ParsePRURL(strings.TrimSpace(string(out)))
+		if err != nil {
+			return nil, err
+		}
+		return GetPR(ctx, ownerRepo, number)
+	}
+
+	branch, err := git.GetCurrentBranch(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get current branch: %w", err)
+	}
+	if branch == "HEAD" {
+		return nil, fmt.Errorf("not on a branch (detached HEAD)")
+	}
+
+	prs, err := FindPRsForBranch(ctx, branch)
+	if err != nil {
+		return nil, err
+	}
+	return ChoosePR(ctx, prs)
+}
+
+// GetCurrentPR returns the PR number for the current branch, or 0 if none exists
+func GetCurrentPR(ctx context.Context) (int, error) {
+	pr, err := GetCurrentPullRequest(ctx)
+	if err != nil || pr == nil {
+		return 0, err
+	}
+	return pr.Number, nil
+}
@@ -0,0 +1,348 @@
+package auth
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"slices"
+	"strings"
+)
+
+// RepoDetails describes a repository: its stats, settings and the
+// protection of its default branch
+type RepoDetails struct {
+	Name                string            `json:"name"` // "owner/repo"
+	Description         string            `json:"description"`
+	URL                 string            `json:"url"`
+	Stars               int               `json:"stars"`
+	Forks               int               `json:"forks"`
+	Watchers            int               `json:"watchers"`
+	OpenIssues          int               `json:"open_issues"`
+	OpenPRs             int               `json:"open_pull_requests"`
+	DefaultBranch       string            `json:"default_branch"`
+	Visibility          string            `json:"visibility"` // public, private or internal
+	Archived            bool              `json:"archived"`
+	Fork                bool              `json:"fork"`
+	Topics              []string          `json:"topics"`
+	License             string            `json:"license"` // SPDX ID, or name if it has none; "" if none
+	Languages           []Language        `json:"languages"`
+	LatestRelease       *Release          `json:"latest_release"`
+	MergeMethods        []string          `json:"merge_methods"`
+	AutoMergeAllowed    bool              `json:"auto_merge_allowed"`
+	DeleteBranchOnMerge bool              `json:"delete_branch_on_merge"`
+	Protection          *BranchProtection `json:"branch_protection"` // nil if none or not visible
+	ViewerPermission    string            `json:"viewer_permission"` // ADMIN, MAINTAIN, WRITE, TRIAGE or READ
+	Warnings            []string          `json:"-"`                 // details that couldn't be fetched
+}
+
+// Language is a language used in a repository, by size of its code
+type Language struct {
+	Name    string  `json:"name"`
+	Bytes   int     `json:"bytes"`
+	Percent float64 `json:"percent"`
+}
+
+// Release is a published release
+type Release struct {
+	Tag         string `json:"tag"`
+	Name        string `json:"name"`
+	URL         string `json:"url"`
+	PublishedAt string `json:"published_at"`
+}
+
+// BranchProtection summarizes the classic protection rule and the rulesets
+// that apply to a branch. Where both set something, the stricter one wins.
+type BranchProtection struct {
+	Pattern                        string   `json:"pattern"`  // of the classic rule; "" if there is none or it isn't visible
+	Rulesets                       []int64  `json:"rulesets"` // IDs of the rulesets with rules for the branch
+	RequiredApprovals              int      `json:"required_approvals"`
+	RequiresCodeOwnerReviews       bool     `json:"requires_code_owner_reviews"`
+	DismissesStaleReviews          bool     `json:"dismisses_stale_reviews"`
+	RequiredChecks                 []string `json:"required_checks"`
+	RequiresUpToDate               bool     `json:"requires_up_to_date"`
+	RequiresLinearHistory          bool     `json:"requires_linear_history"`
+	RequiresSignatures             bool     `json:"requires_signatures"`
+	RequiresConversationResolution bool     `json:"requires_conversation_resolution"`
+	AllowsForcePushes              bool     `json:"allows_force_pushes"`
+	AllowsDeletions                bool     `json:"allows_deletions"`
+	EnforcedForAdmins              bool     `json:"enforced_for_admins"` // by the classic rule; who can bypass rulesets isn't visible
+}
+
+// branchRule is a rule that a ruleset applies to a branch
+type branchRule struct {
+	Type       string `json:"type"`
+	RulesetID  int64  `json:"ruleset_id"`
+	Parameters struct {
+		RequiredApprovingReviewCount   int  `json:"required_approving_review_count"`
+		RequireCodeOwnerReview         bool `json:"require_code_owner_review"`
+		DismissStaleReviewsOnPush      bool `json:"dismiss_stale_reviews_on_push"`
+		RequiredReviewThreadResolution bool `json:"required_review_thread_resolution"`
+		RequiredStatusChecks           []struct {
+			Context string `json:"context"`
+		} `json:"required_status_checks"`
+		StrictRequiredStatusChecksPolicy bool `json:"strict_required_status_checks_policy"`
+	} `json:"parameters"`
+}
+
+// getBranchRules fetches the ruleset rules that apply to a branch. Unlike
+// classic protection rules, anyone who can read the repository sees them.
+func getBranchRules(ctx context.Context, ownerRepo, branch string) ([]branchRule, error) {
+	data, err := APIRequest(ctx, "GET", fmt.Sprintf("/repos/%s/rules/branches/%s?per_page=100", ownerRepo, escapeBranch(branch)), nil)
+	if err != nil {
+		return nil, err
+	}
+	var rules []branchRule
+	if err := json.Unmarshal(data, &rules); err != nil {
+		return nil, fmt.Errorf("failed to parse branch rules: %w", err)
+	}
+	return rules, nil
+}
+
+// addRules merges ruleset rules into p
+func (p *BranchProtection) addRules(rules []branchRule) {
+	for _, r := range rules {
+		if !slices.Contains(p.Rulesets, r.RulesetID) {
+			p.Rulesets = append(p.Rulesets, r.RulesetID)
+		}
+		params := r.Parameters
+		switch r.Type {
+		case "pull_request":
+			p.RequiredApprovals = max(p.RequiredApprovals, params.RequiredApprovingReviewCount)
+			p.RequiresCodeOwnerReviews = p.RequiresCodeOwnerReviews || params.RequireCodeOwnerReview
+			p.DismissesStaleReviews = p.DismissesStaleReviews || params.DismissStaleReviewsOnPush
+			p.RequiresConversationResolution = p.RequiresConversationResolution || params.RequiredReviewThreadResolution
+		case "required_status_checks":
+			for _, c := range params.RequiredStatusChecks {
+				if !slices.Contains(p.RequiredChecks, c.Context) {
+					p.RequiredChecks = append(p.RequiredChecks, c.Context)
+				}
+			}
+			p.RequiresUpToDate = p.RequiresUpToDate || params.StrictRequiredStatusChecksPolicy
+		case "required_linear_history":
+			p.RequiresLinearHistory = true
+		case "required_signatures":
+			p.RequiresSignatures = true
+		case "non_fast_forward":
+			p.AllowsForcePushes = false
+		case "deletion":
+			p.AllowsDeletions = false
+		}
+	}
+}
+
+// mergeMethods returns the allowed merge methods in the order the API and
+// gh list them
+func mergeMethods(merge, squash, rebase bool) []string {
+	var methods []string
+	if merge {
+		methods = append(methods, "merge")
+	}
+	if squash {
+		methods = append(methods, "squash")
+	}
+	if rebase {
+		methods = append(methods, "rebase")
+	}
+	return methods
+}
+
+// GetRepoDetails fetches the stats and settings of ownerRepo
+func GetRepoDetails(ctx context.Context, ownerRepo string) (*RepoDetails, error) {
+	owner, name, ok := strings.Cut(ownerRepo, "/")
+	if !ok {
+		return nil, fmt.Errorf("invalid repository: %s", ownerRepo)
+	}
+
+	query := `query($owner: String!, $name: String!) {
+  repository(owner: $owner, name: $name) {
+    nameWithOwner description url stargazerCount forkCount visibility isArchived isFork viewerPermission
+    watchers { totalCount }
+    issues(states: OPEN) { totalCount }
+    pullRequests(states: OPEN) { totalCount }
+    repositoryTopics(first: 20) { nodes { topic { name } } }
+    licenseInfo { name spdxId }
+    languages(first: 20, orderBy: {field: SIZE, direction: DESC}) { totalSize edges { size node { name } } }
+    latestRelease { tagName name url publishedAt }
+    mergeCommitAllowed squashMergeAllowed rebaseMergeAllowed autoMergeAllowed deleteBranchOnMerge
+    defaultBranchRef {
+      name
+      branchProtectionRule {
+        pattern requiresApprovingReviews requiredApprovingReviewCount requiresCodeOwnerReviews dismissesStaleReviews
+        requiresStatusChecks requiresStrictStatusChecks requiredStatusCheckContexts
+        requiresLinearHistory requiresCommitSignatures requiresConversationResolution
+        allowsForcePushes allowsDeletions isAdminEnforced
+      }
+    }
+  }
+}`
+
+	var data struct {
+		Repository *struct {
+			NameWithOwner    string `json:"nameWithOwner"`
+			Description      string `json:"description"`
+			URL              string `json:"url"`
+			StargazerCount   int    `json:"stargazerCount"`
+			ForkCount        int    `json:"forkCount"`
+			Visibility       string `json:"visibility"`
+			IsArchived       bool   `json:"isArchived"`
+			IsFork           bool   `json:"isFork"`
+			ViewerPermission string `json:"viewerPermission"`
+			Watchers         struct {
+				TotalCount int `json:"totalCount"`
+			} `json:"watchers"`
+			Issues struct {
+				TotalCount int `json:"totalCount"`
+			} `json:"issues"`
+			PullRequests struct {
+				TotalCount int `json:"totalCount"`
+			} `json:"pullRequests"`
+			RepositoryTopics struct {
+				Nodes []struct {
+					Topic struct {
+						Name string `json:"name"`
+					} `json:"topic"`
+				} `json:"nodes"`
+			} `json:"repositoryTopics"`
+			LicenseInfo *struct {
+				Name   string `json:"name"`
+				SpdxID string `json:"spdxId"`
+			} `json:"licenseInfo"`
+			Languages struct {
+				TotalSize int `json:"totalSize"`
+				Edges     []struct {
+					Size int `json:"size"`
+					Node struct {
+						Name string `json:"name"`
+					} `json:"node"`
+				} `json:"edges"`
+			} `json:"languages"`
+			LatestRelease *struct {
+				TagName     string `json:"tagName"`
+				Name        string `json:"name"`
+				URL         string `json:"url"`
+				PublishedAt string `json:"publishedAt"`
+			} `json:"latestRelease"`
+			MergeCommitAllowed  bool `json:"mergeCommitAllowed"`
+			SquashMergeAllowed  bool `json:"squashMergeAllowed"`
+			RebaseMergeAllowed  bool `json:"rebaseMergeAllowed"`
+			AutoMergeAllowed    bool `json:"autoMergeAllowed"`
+			DeleteBranchOnMerge bool `json:"deleteBranchOnMerge"`
+			DefaultBranchRef    *struct {
+				Name                 string `json:"name"`
+				BranchProtectionRule *struct {
+					Pattern                        string   `json:"pattern"`
+					RequiresApprovingReviews       bool     `json:"requiresApprovingReviews"`
+					RequiredApprovingReviewCount   int      `json:"requiredApprovingReviewCount"`
+					RequiresCodeOwnerReviews       bool     `json:"requiresCodeOwnerReviews"`
+					DismissesStaleReviews          bool     `json:"dismissesStaleReviews"`
+					RequiresStatusChecks           bool     `json:"requiresStatusChecks"`
+					RequiresStrictStatusChecks     bool     `json:"requiresStrictStatusChecks"`
+					RequiredStatusCheckContexts    []string `json:"requiredStatusCheckContexts"`
+					RequiresLinearHistory          bool     `json:"requiresLinearHistory"`
+					RequiresCommitSignatures       bool     `json:"requiresCommitSignatures"`
+					RequiresConversationResolution bool     `json:"requiresConversationResolution"`
+					AllowsForcePushes              bool     `json:"allowsForcePushes"`
+					AllowsDeletions                bool     `json:"allowsDeletions"`
+					IsAdminEnforced                bool     `json:"isAdminEnforced"`
+				} `json:"branchProtectionRule"`
+			} `json:"defaultBranchRef"`
+		} `json:"repository"`
+	}
+	vars := map[string]interface{}{"owner": owner, "name": name}
+	if err := GraphQL(ctx, query, vars, &data); err != nil {
+		return nil, err
+	}
+	repo := data.Repository
+	if repo == nil {
+		return nil, fmt.Errorf("repository %s not found", ownerRepo)
+	}
+
+	d := &RepoDetails{
+		Name:                repo.NameWithOwner,
+		Description:         repo.Description,
+		URL:                 repo.URL,
+		Stars:               repo.StargazerCount,
+		Forks:               repo.ForkCount,
+		Watchers:            repo.Watchers.TotalCount,
+		OpenIssues:          repo.Issues.TotalCount,
+		OpenPRs:             repo.PullRequests.TotalCount,
+		Visibility:          strings.ToLower(repo.Visibility),
+		Archived:            repo.IsArchived,
+		Fork:                repo.IsFork,
+		Topics:              []string{},
+		Languages:           []Language{},
+		MergeMethods:        mergeMethods(repo.MergeCommitAllowed, repo.SquashMergeAllowed, repo.RebaseMergeAllowed),
+		AutoMergeAllowed:    repo.AutoMergeAllowed,
+		DeleteBranchOnMerge: repo.DeleteBranchOnMerge,
+		ViewerPermission:    repo.ViewerPermission,
+	}
+	for _, n := range repo.RepositoryTopics.Nodes {
+		d.Topics = append(d.Topics, n.Topic.Name)
+	}
+	if l := repo.LicenseInfo; l != nil {
+		d.License = l.SpdxID
+		// Licenses GitHub doesn't recognize have the ID NOASSERTION
+		if d.License == "" || d.License == "NOASSERTION" {
+			d.License = l.Name
+		}
+	}
+	for _, e := range repo.Languages.Edges {
+		lang := Language{Name: e.Node.Name, Bytes: e.Size}
+		if repo.Languages.TotalSize > 0 {
+			lang.Percent = float64(e.Size) * 100 / float64(repo.Languages.TotalSize)
+		}
+		d.Languages = append(d.Languages, lang)
+	}
+	if r := repo.LatestRelease; r != nil {
+		d.LatestRelease = &Release{Tag: r.TagName, Name: r.Name, URL: r.URL, PublishedAt: r.PublishedAt}
+	}
+
+	if ref := repo.DefaultBranchRef; ref != nil {
+		d.DefaultBranch = ref.Name
+		if rule := ref.BranchProtectionRule; rule != nil {
+			p := &BranchProtection{
+				Pattern:                        rule.Pattern,
+				Rulesets:                       []int64{},
+				RequiresCodeOwnerReviews:       rule.RequiresCodeOwnerReviews,
+				DismissesStaleReviews:          rule.DismissesStaleReviews,
+				RequiredChecks:                 []string{},
+				RequiresLinearHistory:          rule.RequiresLinearHistory,
+				RequiresSignatures:             rule.RequiresCommitSignatures,
+				RequiresConversationResolution: rule.RequiresConversationResolution,
+				AllowsForcePushes:              rule.AllowsForcePushes,
+				AllowsDeletions:                rule.AllowsDeletions,
+				EnforcedForAdmins:              rule.IsAdminEnforced,
+			}
+			if rule.RequiresApprovingReviews {
+				p.RequiredApprovals = rule.RequiredApprovingReviewCount
+			}
+			if rule.RequiresStatusChecks {
+				p.RequiredChecks = append(p.RequiredChecks, rule.RequiredStatusCheckContexts...)
+				p.RequiresUpToDate = rule.RequiresStrictStatusChecks
+			}
+			d.Protection = p
+		}
+
+		rules, err := getBranchRules(ctx, ownerRepo, ref.Name)
+		switch {
+		case ctx.Err() != nil:
+			return nil, context.Cause(ctx)
+		case isNotFound(err):
+			// GitHub Enterprise Server versions without rulesets
+		case err != nil:
+			d.Warnings = append(d.Warnings, fmt.Sprintf("couldn't read the rulesets of %s: %v", ref.Name, err))
+		case len(rules) > 0:
+			if d.Protection == nil {
+				// Without a classic rule, only the rulesets restrict the branch
+				d.Protection = &BranchProtection{
+					Rulesets:          []int64{},
+					RequiredChecks:    []string{},
+					AllowsForcePushes: true,
+					AllowsDeletions:   true,
+				}
+			}
+			d.Protection.addRules(rules)
+		}
+	}
+	return d, nil
+}
@@ -0,0 +1,150 @@
+package auth
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// Review is a review submitted on a pull request
+type Review struct {
+	ID   int64 `json:"id"`
+	User struct {
+		Login string `json:"login"`
+	} `json:"user"`
+	State       string `json:"state"` // APPROVED, CHANGES_REQUESTED, COMMENTED, DISMISSED or PENDING
+	Body        string `json:"body"`
+	SubmittedAt string `json:"submitted_at"`
+	HTMLURL     string `json:"html_url"`
+}
+
+// ReviewRequest is an open PR whose author asked for your review
+type ReviewRequest struct {
+	Number int
+	Title  string
+	URL    string
+	Author string
+	Repo   string // "owner/repo"
+}
+
+// ListReviews returns the reviews of PR number in ownerRepo, oldest first
+func ListReviews(ctx context.Context, ownerRepo string, number int) ([]Review, error) {
+	data, err := APIRequest(ctx, "GET", fmt.Sprintf("/repos/%s/pulls/%d/reviews?per_page=100", ownerRepo, number), nil)
+	if err != nil {
+		return nil, err
+	}
+
+	var reviews []Review
+	if err := json.Unmarshal(data, &reviews); err != nil {
+		return nil, fmt.Errorf("failed to parse reviews: %w", err)
+	}
+	return reviews, nil
+}
+
+// SubmitReview submits a review on PR number in ownerRepo. event is APPROVE,
+// COMMENT or REQUEST_CHANGES; the latter two need a body.
+func SubmitReview(ctx context.Context, ownerRepo string, number int, event, body string) (*Review, error) {
+	req := map[string]string{"event": event}
+	if body != "" {
+		req["body"] = body
+	}
+
+	data, err := APIRequest(ctx, "POST", fmt.Sprintf("/repos/%s/pulls/%d/reviews", ownerRepo, number), req)
+	if err != nil {
+		return nil, err
+	}
+
+	var review Review
+	if err := json.Unmarshal(data, &review); err != nil {
+		return nil, fmt.Errorf("failed to parse review: %w", err)
+	}
+	return &review, nil
+}
+
+// RequestedReviewers returns the users ("@login") and teams ("org/team")
+// whose review of PR number is still pending
+func RequestedReviewers(ctx context.Context, ownerRepo string, number int) ([]string, error) {
+	data, err := APIRequest(ctx, "GET", fmt.Sprintf("/repos/%s/pulls/%d/requested_reviewers", ownerRepo, number), nil)
+	if err != nil {
+		return nil, err
+	}
+
+	var result struct {
+		Users []struct {
+			Login string `json:"login"`
+		} `json:"users"`
+		Teams []struct {
+			Slug string `json:"slug"`
+		} `json:"teams"`
+	}
+	if err := json.Unmarshal(data, &result); err != nil {
+		return nil, fmt.Errorf("failed to parse requested reviewers: %w", err)
+	}
+
+	owner, _, _ := strings.Cut(ownerRepo, "/")
+	var names []string
+	for _, u := range result.Users {
+		names = append(names, "@"+u.Login)
+	}
+	for _, t := range result.Teams {
+		names = append(names, owner+"/"+t.Slug)
+	}
+	return names, nil
+}
+
+// ListReviewRequests searches for open PRs awaiting your review, most
+// recently updated first. It returns up to 20 of them and the total count.
+func ListReviewRequests(ctx context.Context) ([]ReviewRequest, int, error) {
+	userData, err := APIRequest(ctx, "GET", "/user", nil)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	var user struct {
+		Login string `json:"login"`
+	}
+	if err := json.Unmarshal(userData, &user); err != nil {
+		return nil, 0, fmt.Errorf("failed to parse user: %w", err)
+	}
+
+	query := fmt.Sprintf("is:pr is:open review-requested:%s", user.Login)
+	endpoint := fmt.Sprintf("/search/issues?q=%s&sort=updated&per_page=20", strings.ReplaceAll(query, " ", "+"))
+
+	data, err := APIRequest(ctx, "GET", endpoint, nil)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	var result struct {
+		TotalCount int `json:"total_count"`
+		Items      []struct {
+			Number        int    `json:"number"`
+			Title         string `json:"title"`
+			HTMLURL       string `json:"html_url"`
+			RepositoryURL string `json:"repository_url"`
+			User          struct {
+				Login string `json:"login"`
+			} `json:"user"`
+		} `json:"items"`
+	}
+	if err := json.Unmarshal(data, &result); err != nil {
+		return nil, 0, fmt.Errorf("failed to parse response: %w", err)
+	}
+
+	requests := make([]ReviewRequest, len(result.Items))
+	for i, item := range result.Items {
+		repo := item.RepositoryURL
+		if _, after, ok := strings.Cut(repo, "/repos/"); ok {
+			repo = after
+		}
+		requests[i] = ReviewRequest{
+			Number: item.Number,
+			Title:  item.Title,
+			URL:    item.HTMLURL,
+			Author: item.User.Login,
+			Repo:   repo,
+		}
+	}
+	return requests, result.TotalCount, nil
+}
@@ -0,0 +1,171 @@
+package auth
+
+import (
+	"context"
+	"fmt"
+	"strings"
+)
+
+// ReviewThread is a conversation attached to a line of a pull request's diff
+type ReviewThread struct {
+	ID         string // GraphQL node ID, used to reply to or resolve the thread
+	Path       string
+	Line       int // line in the current diff, or the original line if outdated
+	StartLine  int // first line of a multi-line comment, 0 otherwise
+	IsResolved bool
+	IsOutdated bool
+	ResolvedBy string
+	Comments   []ThreadComment
+}
+
+// ThreadComment is one comment in a review thread
+type ThreadComment struct {
+	Author    string
+	Body      string
+	CreatedAt string
+	DiffHunk  string // the diff up to the commented line
+	URL       string
+}
+
+// ListReviewThreads returns the review threads of PR number in ownerRepo,
+// in the order they were started
+func ListReviewThreads(ctx context.Context, ownerRepo string, number int) ([]ReviewThread, error) {
+	owner, name, ok := strings.Cut(ownerRepo, "/")
+	if !ok {
+		return nil, fmt.Errorf("invalid repository: %s", ownerRepo)
+	}
+
+	query := `query($owner: String!, $name: String!, $number: Int!, $cursor: String) {
+  repository(owner: $owner, name: $name) {
+    pullRequest(number: $number) {
+      reviewThreads(first: 100, after: $cursor) {
+        pageInfo { hasNextPage endCursor }
+        nodes {
+          id path line originalLine startLine originalStartLine isResolved isOutdated
+          resolvedBy { login }
+          comments(first: 100) { nodes { author { login } body createdAt diffHunk url } }
+        }
+      }
+    }
+  }
+}`
+
+	var threads []ReviewThread
+	vars := map[string]interface{}{"owner": owner, "name": name, "number": number}
+	for {
+		var data struct {
+			Repository struct {
+				PullRequest *struct {
+					ReviewThreads struct {
+						PageInfo struct {
+							HasNextPage bool   `json:"hasNextPage"`
+							EndCursor   string `json:"endCursor"`
+						} `json:"pageInfo"`
+						Nodes []struct {
+							ID                string `json:"id"`
+							Path              string `json:"path"`
+							Line              int    `json:"line"`
+							OriginalLine      int    `json:"originalLine"`
+							StartLine         int    `json:"startLine"`
+							OriginalStartLine int    `json:"originalStartLine"`
+							IsResolved        bool   `json:"isResolved"`
+							IsOutdated        bool   `json:"isOutdated"`
+							ResolvedBy        *struct {
+								Login string `json:"login"`
+							} `json:"resolvedBy"`
+							Comments struct {
+								Nodes []struct {
+									Author *struct {
+										Login string `json:"login"`
+									} `json:"author"`
+									Body      string `json:"body"`
+									CreatedAt string `json:"createdAt"`
+									DiffHunk  string `json:"diffHunk"`
+									URL       string `json:"url"`
+								} `json:"nodes"`
+							} `json:"comments"`
+						} `json:"nodes"`
+					} `json:"reviewThreads"`
+				} `json:"pullRequest"`
+			} `json:"repository"`
+		}
+		if err := GraphQL(ctx, query, vars, &data); err != nil {
+			return nil, err
+		}
+		pr := data.Repository.PullRequest
+		if pr == nil {
+			return nil, fmt.Errorf("PR #%d not found in %s", number, ownerRepo)
+		}
+
+		for _, n := range pr.ReviewThreads.Nodes {
+			t := ReviewThread{
+				ID:         n.ID,
+				Path:       n.Path,
+				Line:       n.Line,
+				StartLine:  n.StartLine,
+				IsResolved: n.IsResolved,
+				IsOutdated: n.IsOutdated,
+			}
+			// Outdated threads no longer have a line in the current diff
+			if t.Line == 0 {
+				t.Line, t.StartLine = n.OriginalLine, n.OriginalStartLine
+			}
+			if n.ResolvedBy != nil {
+				t.ResolvedBy = n.ResolvedBy.Login
+			}
+			for _, c := range n.Comments.Nodes {
+				comment := ThreadComment{Body: c.Body, CreatedAt: c.CreatedAt, DiffHunk: c.DiffHunk, URL: c.URL}
+				// Comments of deleted accounts have no author
+				comment.Author = "ghost"
+				if c.Author != nil {
+					comment.Author = c.Author.Login
+				}
+				t.Comments = append(t.Comments, comment)
+			}
+			threads = append(threads, t)
+		}
+
+		if !pr.ReviewThreads.PageInfo.HasNextPage {
+			return threads, nil
+		}
+		vars["cursor"] = pr.ReviewThreads.PageInfo.EndCursor
+	}
+}
+
+// ReplyToThread adds a comment to the review thread with node ID threadID
+// and returns the comment's URL
+func ReplyToThread(ctx context.Context, threadID, body string) (string, error) {
+	query := `mutation($input: AddPullRequestReviewThreadReplyInput!) {
+  addPullRequestReviewThreadReply(input: $input) { comment { url } }
+}`
+	input := map[string]interface{}{"pullRequestReviewThreadId": threadID, "body": body}
+
+	var data struct {
+		AddPullRequestReviewThreadReply struct {
+			Comment *struct {
+				URL string `json:"url"`
+			} `json:"comment"`
+		} `json:"addPullRequestReviewThreadReply"`
+	}
+	if err := GraphQL(ctx, query, map[string]interface{}{"input": input}, &data); err != nil {
+		return "", err
+	}
+	if c := data.AddPullRequestReviewThreadReply.Comment; c != nil {
+		return c.URL, nil
+	}
+	return "", nil
+}
+
+// ResolveThread marks the review thread with node ID threadID as resolved,
+// or as unresolved again if resolve is false
+func ResolveThread(ctx context.Context, threadID string, resolve bool) error {
+	mutation, input := "resolveReviewThread", "ResolveReviewThreadInput"
+	if !resolve {
+		mutation, input = "unresolveReviewThread", "UnresolveReviewThreadInput"
+	}
+	query := fmt.Sprintf(`mutation($input: %s!) {
+  %s(input: $input) { thread { isResolved } }
+}`, input, mutation)
+	vars := map[string]interface{}{"input": map[string]interface{}{"threadId": threadID}}
+	return GraphQL(ctx, query, vars, nil)
+}
@@ -4,6 +4,8 @@ import (
 	"fmt"
 	"os/exec"
 	"runtime"
+
+	"cli-tools/internal/trace"
 )
 
 // Open opens the specified URL in the default browser
@@ -21,5 +23,5 @@ func Open(url string) error {
 		return fmt.Errorf("unsupported platform: %s", runtime.GOOS)
 	}
 
-	return cmd.Run()
+	return trace.Run(cmd)
 }
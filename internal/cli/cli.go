@@ -0,0 +1,161 @@
+package cli
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"os/signal"
+	"slices"
+	"strings"
+	"sync/atomic"
+	"syscall"
+	"time"
+
+	"cli-tools/internal/trace"
+)
+
+// ErrInterrupted is the cancellation cause when the user presses Ctrl-C
+var ErrInterrupted = errors.New("interrupted")
+
+// interruptsIgnored is set while a child process owns the terminal and
+// Ctrl-C is meant for it
+var interruptsIgnored atomic.Bool
+
+// IgnoreInterrupts keeps Ctrl-C from cancelling the context returned by Init
+// until the returned function is called. Like git does while its pager runs,
+// this lets a child such as less handle Ctrl-C itself. SIGTERM still cancels.
+func IgnoreInterrupts() (restore func()) {
+	interruptsIgnored.Store(true)
+	return func() { interruptsIgnored.Store(false) }
+}
+
+// Init handles the global flags shared by every command and removes them
+// from os.Args, so each command only sees its own arguments.
+//
+// Global flags:
+//
+//	--debug, --verbose     trace git, gh and HTTP calls to stderr
+//	--debug-file <path>    trace as JSON lines appended to path
+//	--timeout <duration>   give up after the given time (e.g. 30s, 2m)
+//
+// Tracing can also be enabled with CLI_TOOLS_DEBUG=1 and CLI_TOOLS_DEBUG_FILE,
+// and the timeout set with CLI_TOOLS_TIMEOUT.
+//
+// A command that has a flag of its own with a global flag's name lists it in
+// keep, e.g. "--debug"; it is then left in os.Args for the command to parse,
+// and the other spellings (--verbose) still enable the global behavior.
+//
+// The returned context is cancelled on timeout or on the first SIGINT/SIGTERM,
+// which stops in-flight git, gh and HTTP calls. A second signal exits
+// immediately. Callers should defer the returned cancel function.
+func Init(keep ...string) (context.Context, context.CancelFunc) {
+	if err := trace.EnableFromEnv(); err != nil {
+		fmt.Fprintf(os.Stderr, "Error: cannot open debug file: %v\n", err)
+		os.Exit(1)
+	}
+
+	debug := false
+	debugFile := ""
+	timeout := os.Getenv("CLI_TOOLS_TIMEOUT")
+	args := []string{os.Args[0]}
+
+	for i := 1; i < len(os.Args); i++ {
+		arg := os.Args[i]
+		name, _, _ := strings.Cut(arg, "=")
+		switch {
+		case arg == "--":
+			// Everything after "--" belongs to the command
+			args = append(args, os.Args[i:]...)
+			i = len(os.Args)
+		case slices.Contains(keep, name):
+			args = append(args, arg)
+		case arg == "--debug" || arg == "--verbose":
+			debug = true
+		case arg == "--debug-file" || arg == "--timeout":
+			if i+1 >= len(os.Args) {
+				fmt.Fprintf(os.Stderr, "Error: %s requires a value\n", arg)
+				os.Exit(1)
+			}
+			i++
+			if arg == "--timeout" {
+				timeout = os.Args[i]
+			} else {
+				debugFile = os.Args[i]
+			}
+		case strings.HasPrefix(arg, "--debug-file="):
+			debugFile = strings.TrimPrefix(arg, "--debug-file=")
+		case strings.HasPrefix(arg, "--timeout="):
+			timeout = strings.TrimPrefix(arg, "--timeout=")
+		default:
+			args = append(args, arg)
+		}
+	}
+	os.Args = args
+
+	if debug || debugFile != "" {
+		if err := trace.EnableTo(debugFile); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: cannot open debug file: %v\n", err)
+			os.Exit(1)
+		}
+	}
+
+	ctx := context.Background()
+	cancelTimeout := context.CancelFunc(func() {})
+	if timeout != "" {
+		d, err := time.ParseDuration(timeout)
+		if err != nil || d <= 0 {
+			fmt.Fprintf(os.Stderr, "Error: invalid timeout %q (use e.g. 30s, 2m)\n", timeout)
+			os.Exit(1)
+		}
+		ctx, cancelTimeout = context.WithTimeoutCause(ctx, d, fmt.Errorf("timed out after %s", d))
+	}
+
+	ctx, cancel := context.WithCancelCause(ctx)
+	sigs := make(chan os.Signal, 1)
+	signal.Notify(sigs, os.Interrupt, syscall.SIGTERM)
+	go func() {
+		for {
+			select {
+			case sig := <-sigs:
+				if sig == os.Interrupt && interruptsIgnored.Load() {
+					continue
+				}
+				// Restore default handling so a second Ctrl-C kills the process
+				signal.Stop(sigs)
+				cancel(ErrInterrupted)
+				return
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+
+	return ctx, func() {
+		signal.Stop(sigs)
+		cancel(context.Canceled)
+		cancelTimeout()
+	}
+}
+
+// Parse parses os.Args[1:] with fs and returns the positional arguments.
+// Unlike fs.Parse, flags may follow positional arguments
+// (e.g. "pr-checkout 123 --detach"); "--" ends flag parsing.
+func Parse(fs *flag.FlagSet) []string {
+	var positional []string
+	args := os.Args[1:]
+	for {
+		// fs is expected to use flag.ExitOnError, so errors never get here
+		_ = fs.Parse(args)
+		rest := fs.Args()
+		if consumed := len(args) - len(rest); consumed > 0 && args[consumed-1] == "--" {
+			return append(positional, rest...)
+		}
+		if len(rest) == 0 {
+			return positional
+		}
+		positional = append(positional, rest[0])
+		args = rest[1:]
+	}
+}
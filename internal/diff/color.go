@@ -0,0 +1,44 @@
+// Package diff colors unified diffs for the terminal
+package diff
+
+import "strings"
+
+// ANSI colors, matching git's defaults for diffs
+const (
+	Reset = "\x1b[0m"
+	Bold  = "\x1b[1m"
+	Red   = "\x1b[31m"
+	Green = "\x1b[32m"
+	Cyan  = "\x1b[36m"
+	Dim   = "\x1b[2m"
+	RedBg = "\x1b[41m"
+)
+
+// ColorLine colors one diff line like git does, highlighting trailing
+// whitespace on added lines. inHunk is false for the file header lines
+// before the first "@@".
+func ColorLine(line string, inHunk bool) string {
+	switch {
+	case !inHunk:
+		return Bold + line + Reset
+	case strings.HasPrefix(line, "@@"):
+		// "@@ -1,2 +1,3 @@ func context" - the function context stays plain
+		if end := strings.Index(line[2:], "@@"); end >= 0 {
+			end += 4
+			return Cyan + line[:end] + Reset + line[end:]
+		}
+		return Cyan + line + Reset
+	case strings.HasPrefix(line, "+"):
+		trimmed := strings.TrimRight(line, " \t")
+		if trimmed != line && len(trimmed) > 1 {
+			return Green + trimmed + Reset + RedBg + line[len(trimmed):] + Reset
+		}
+		return Green + line + Reset
+	case strings.HasPrefix(line, "-"):
+		return Red + line + Reset
+	case strings.HasPrefix(line, `\`):
+		return Dim + line + Reset
+	default:
+		return line
+	}
+}
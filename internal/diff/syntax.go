@@ -0,0 +1,272 @@
+package diff
+
+import (
+	"path"
+	"slices"
+	"strings"
+)
+
+// Syntax colors, and the backgrounds that mark added and removed lines when
+// their code is highlighted
+const (
+	fgReset   = "\x1b[39m"
+	keywordFg = "\x1b[35m"
+	stringFg  = "\x1b[33m"
+	commentFg = "\x1b[90m"
+	numberFg  = "\x1b[36m"
+	addedBg   = "\x1b[48;5;22m"
+	removedBg = "\x1b[48;5;52m"
+)
+
+// language is enough of a language's lexical syntax to color it
+type language struct {
+	keywords     map[string]bool
+	lineComments []string  // e.g. "//"
+	blockComment [2]string // start and end, e.g. "/*" and "*/"; empty if none
+	quotes       string    // characters that start and end strings
+}
+
+var (
+	cComments = [2]string{"/*", "*/"}
+
+	goLang = &language{
+		keywords: words("break case chan const continue default defer else fallthrough for func go goto if import",
+			"interface map package range return select struct switch type var true false nil iota"),
+		lineComments: []string{"//"}, blockComment: cComments, quotes: "\"'`",
+	}
+	jsLang = &language{
+		keywords: words("async await break case catch class const continue debugger default delete do else export",
+			"extends finally for from function if import in instanceof let new of return static super switch this",
+			"throw try typeof var void while yield true false null undefined interface type enum implements readonly"),
+		lineComments: []string{"//"}, blockComment: cComments, quotes: "\"'`",
+	}
+	pythonLang = &language{
+		keywords: words("and as assert async await break class continue def del elif else except finally for from",
+			"global if import in is lambda nonlocal not or pass raise return try while with yield True False None"),
+		lineComments: []string{"#"}, quotes: "\"'",
+	}
+	rubyLang = &language{
+		keywords: words("alias and begin break case class def do else elsif end ensure false for if in",
+			"module next nil not or redo rescue retry return self super then true undef unless until when while yield"),
+		lineComments: []string{"#"}, quotes: "\"'",
+	}
+	rustLang = &language{
+		keywords: words("as async await break const continue crate dyn else enum extern false fn for if impl in let",
+			"loop match mod move mut pub ref return self Self static struct super trait true type unsafe use where while"),
+		// No ' quotes: they also start lifetimes
+		lineComments: []string{"//"}, blockComment: cComments, quotes: "\"",
+	}
+	cLang = &language{
+		keywords: words("auto break case catch char class const continue default delete do double else enum extern",
+			"final float for goto if import int long namespace new null nullptr package private protected public",
+			"return short signed sizeof static struct switch template this throw try typedef union unsigned using",
+			"virtual void volatile while boolean extends implements interface throws var val fun when object true false"),
+		lineComments: []string{"//"}, blockComment: cComments, quotes: "\"'",
+	}
+	shellLang = &language{
+		keywords: words("if then else elif fi case esac for while until do done in function return local export",
+			"readonly set unset shift exit"),
+		lineComments: []string{"#"}, quotes: "\"'",
+	}
+	yamlLang = &language{
+		keywords:     words("true false null"),
+		lineComments: []string{"#"}, quotes: "\"'",
+	}
+	jsonLang = &language{
+		keywords: words("true false null"),
+		quotes:   "\"",
+	}
+	sqlLang = &language{
+		keywords: words("select from where and or not insert into values update set delete create table drop alter",
+			"index join left right inner outer on as group by order having limit null is in like primary key"),
+		lineComments: []string{"--"}, blockComment: cComments, quotes: "'\"",
+	}
+)
+
+// extensions lists the file extensions of each language
+var extensions = map[*language]string{
+	goLang:     ".go",
+	jsLang:     ".js .mjs .cjs .jsx .ts .tsx",
+	pythonLang: ".py",
+	rubyLang:   ".rb",
+	rustLang:   ".rs",
+	cLang:      ".c .h .cc .cpp .hpp .cs .java .kt .kts .swift .scala",
+	shellLang:  ".sh .bash .zsh",
+	yamlLang:   ".yml .yaml",
+	jsonLang:   ".json",
+	sqlLang:    ".sql",
+}
+
+// languageFor returns the language of a file by its extension, or nil
+func languageFor(filePath string) *language {
+	ext := strings.ToLower(path.Ext(filePath))
+	if ext == "" {
+		return nil
+	}
+	for lang, exts := range extensions {
+		if slices.Contains(strings.Fields(exts), ext) {
+			return lang
+		}
+	}
+	return nil
+}
+
+// words builds a keyword set from space-separated lists
+func words(lists ...string) map[string]bool {
+	set := make(map[string]bool)
+	for _, list := range lists {
+		for _, w := range strings.Fields(list) {
+			set[w] = true
+		}
+	}
+	return set
+}
+
+// Colorer colors the diff of one file, highlighting the code in its hunks
+// when the file type is known. The old and new sides are tracked separately,
+// so a block comment opened on a removed line doesn't spill into added ones.
+type Colorer struct {
+	lang       *language // nil if the file type is unknown
+	oldComment bool      // the old side is inside a block comment
+	newComment bool      // the new side is inside a block comment
+}
+
+// NewColorer returns a Colorer for the diff of the file at filePath, which
+// picks the language by extension
+func NewColorer(filePath string) *Colorer {
+	return &Colorer{lang: languageFor(filePath)}
+}
+
+// Line colors one diff line. inHunk is false for the file header lines
+// before the first "@@". Files of unknown type are colored like git does.
+func (c *Colorer) Line(line string, inHunk bool) string {
+	if c.lang == nil || !inHunk || line == "" {
+		return ColorLine(line, inHunk)
+	}
+
+	switch line[0] {
+	case '@':
+		// A hunk may start anywhere, so forget any open comment
+		c.oldComment, c.newComment = false, false
+		return ColorLine(line, inHunk)
+	case '+':
+		code, trailing := splitTrailing(line[1:])
+		var out string
+		out, c.newComment = c.lang.highlight(code, c.newComment)
+		if trailing != "" && code != "" {
+			trailing = RedBg + trailing
+		}
+		return Green + "+" + addedBg + out + trailing + Reset
+	case '-':
+		var out string
+		out, c.oldComment = c.lang.highlight(line[1:], c.oldComment)
+		return Red + "-" + removedBg + out + Reset
+	case ' ':
+		out, comment := c.lang.highlight(line[1:], c.newComment)
+		c.oldComment, c.newComment = comment, comment
+		return " " + out + Reset
+	default:
+		return ColorLine(line, inHunk)
+	}
+}
+
+// splitTrailing splits s into its text and trailing whitespace
+func splitTrailing(s string) (string, string) {
+	trimmed := strings.TrimRight(s, " \t")
+	return trimmed, s[len(trimmed):]
+}
+
+// highlight colors the keywords, strings, comments and numbers in one line
+// of code. comment tells whether the line starts inside a block comment; the
+// returned bool whether it ends inside one. Only foreground colors are set,
+// so a background set by the caller stays.
+func (l *language) highlight(code string, comment bool) (string, bool) {
+	var b strings.Builder
+	paint := func(color, s string) {
+		b.WriteString(color + s + fgReset)
+	}
+
+	for i := 0; i < len(code); {
+		rest := code[i:]
+		if comment {
+			end := strings.Index(rest, l.blockComment[1])
+			if end < 0 {
+				paint(commentFg, rest)
+				return b.String(), true
+			}
+			end += len(l.blockComment[1])
+			paint(commentFg, rest[:end])
+			comment = false
+			i += end
+			continue
+		}
+		if start := l.blockComment[0]; start != "" && strings.HasPrefix(rest, start) {
+			end := strings.Index(rest[len(start):], l.blockComment[1])
+			if end < 0 {
+				paint(commentFg, rest)
+				return b.String(), true
+			}
+			end += len(start) + len(l.blockComment[1])
+			paint(commentFg, rest[:end])
+			i += end
+			continue
+		}
+		if l.isLineComment(code, i) {
+			paint(commentFg, rest)
+			break
+		}
+
+		c := code[i]
+		switch {
+		case strings.IndexByte(l.quotes, c) >= 0:
+			j := i + 1
+			for j < len(code) && code[j] != c {
+				if code[j] == '\\' {
+					j++
+				}
+				j++
+			}
+			j = min(j+1, len(code))
+			paint(stringFg, code[i:j])
+			i = j
+		case isWordByte(c):
+			j := i + 1
+			for j < len(code) && isWordByte(code[j]) {
+				j++
+			}
+			word := code[i:j]
+			switch {
+			case c >= '0' && c <= '9':
+				paint(numberFg, word)
+			case l.keywords[word]:
+				paint(keywordFg, word)
+			default:
+				b.WriteString(word)
+			}
+			i = j
+		default:
+			b.WriteByte(c)
+			i++
+		}
+	}
+	return b.String(), comment
+}
+
+// isLineComment reports whether a line comment starts at code[i]. A "#"
+// only starts one at the beginning of the line or after a space, so "$#"
+// in shell scripts or "#" in URLs doesn't.
+func (l *language) isLineComment(code string, i int) bool {
+	for _, prefix := range l.lineComments {
+		if !strings.HasPrefix(code[i:], prefix) {
+			continue
+		}
+		if prefix != "#" || i == 0 || code[i-1] == ' ' || code[i-1] == '\t' {
+			return true
+		}
+	}
+	return false
+}
+
+func isWordByte(c byte) bool {
+	return c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= '0' && c <= '9' || c == '_'
+}
@@ -0,0 +1,30 @@
+package fileref
+
+import (
+	"strconv"
+	"strings"
+)
+
+// Parse splits a "file", "file:line" or "file:start-end" argument into the
+// file path and line range. end equals start for a single line; both are 0
+// if no valid line was given, in which case the whole argument is the path.
+func Parse(arg string) (string, int, int) {
+	idx := strings.LastIndex(arg, ":")
+	if idx == -1 {
+		return arg, 0, 0
+	}
+
+	startStr, endStr, isRange := strings.Cut(arg[idx+1:], "-")
+	start, err := strconv.Atoi(startStr)
+	if err != nil || start <= 0 {
+		return arg, 0, 0
+	}
+	end := start
+	if isRange {
+		end, err = strconv.Atoi(endStr)
+		if err != nil || end < start {
+			return arg, 0, 0
+		}
+	}
+	return arg[:idx], start, end
+}
@@ -0,0 +1,40 @@
+// Package format lays out text for the terminal
+package format
+
+import (
+	"fmt"
+	"io"
+	"strings"
+)
+
+// Table writes rows as columns padded to the widest cell, two spaces apart.
+// The last cell of a row is not padded.
+func Table(w io.Writer, rows [][]string) {
+	var widths []int
+	for _, row := range rows {
+		for i, cell := range row {
+			if i == len(widths) {
+				widths = append(widths, 0)
+			}
+			widths[i] = max(widths[i], len([]rune(cell)))
+		}
+	}
+	for _, row := range rows {
+		var line strings.Builder
+		for i, cell := range row {
+			if i < len(row)-1 {
+				cell += strings.Repeat(" ", widths[i]-len([]rune(cell))+2)
+			}
+			line.WriteString(cell)
+		}
+		fmt.Fprintln(w, line.String())
+	}
+}
+
+// Plural returns one or many depending on n
+func Plural(n int, one, many string) string {
+	if n == 1 {
+		return one
+	}
+	return many
+}
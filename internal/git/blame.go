@@ -0,0 +1,123 @@
+package git
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"os/exec"
+	"strconv"
+	"strings"
+	"time"
+
+	"cli-tools/internal/trace"
+)
+
+// BlameLine is one line of `git blame --porcelain` output
+type BlameLine struct {
+	SHA        string
+	Line       int // line number in the current file
+	Author     string
+	AuthorMail string
+	AuthorTime time.Time
+	Summary    string
+	Text       string
+}
+
+// Uncommitted reports whether the line has local changes that are not committed yet
+func (b *BlameLine) Uncommitted() bool {
+	return strings.Trim(b.SHA, "0") == ""
+}
+
+// Blame runs git blame on lines start..end of path (the whole file if start is 0).
+// Commits listed in ignoreRevsFile, if set, are skipped over as with
+// `git blame --ignore-revs-file`.
+func Blame(ctx context.Context, path string, start, end int, ignoreRevsFile string) ([]BlameLine, error) {
+	args := []string{"blame", "--porcelain"}
+	if ignoreRevsFile != "" {
+		args = append(args, "--ignore-revs-file", ignoreRevsFile)
+	}
+	if start > 0 {
+		if end < start {
+			end = start
+		}
+		args = append(args, "-L", fmt.Sprintf("%d,%d", start, end))
+	}
+	args = append(args, "--", path)
+
+	cmd := exec.CommandContext(ctx, "git", args...)
+	out, err := trace.Output(cmd)
+	if err != nil {
+		if ctx.Err() != nil {
+			return nil, context.Cause(ctx)
+		}
+		if exitErr, ok := err.(*exec.ExitError); ok {
+			return nil, fmt.Errorf("git blame: %s", strings.TrimSpace(string(exitErr.Stderr)))
+		}
+		return nil, err
+	}
+
+	return parseBlamePorcelain(string(out))
+}
+
+// parseBlamePorcelain parses `git blame --porcelain` output. Commit details are
+// only printed the first time a commit appears, so they are remembered by SHA.
+func parseBlamePorcelain(out string) ([]BlameLine, error) {
+	commits := make(map[string]*BlameLine)
+	var lines []BlameLine
+	var cur *BlameLine
+
+	scanner := bufio.NewScanner(strings.NewReader(out))
+	scanner.Buffer(make([]byte, 0, 64*1024), 10*1024*1024)
+	for scanner.Scan() {
+		text := scanner.Text()
+
+		// Content lines are prefixed with a tab and end the entry
+		if strings.HasPrefix(text, "\t") {
+			if cur == nil {
+				return nil, fmt.Errorf("unexpected blame output: %q", text)
+			}
+			line := *commits[cur.SHA]
+			line.Line = cur.Line
+			line.Text = text[1:]
+			lines = append(lines, line)
+			cur = nil
+			continue
+		}
+
+		if cur == nil {
+			// Header: <sha> <orig-line> <final-line> [<num-lines>]
+			fields := strings.Fields(text)
+			if len(fields) < 3 {
+				return nil, fmt.Errorf("unexpected blame output: %q", text)
+			}
+			n, err := strconv.Atoi(fields[2])
+			if err != nil {
+				return nil, fmt.Errorf("unexpected blame output: %q", text)
+			}
+			cur = &BlameLine{SHA: fields[0], Line: n}
+			if _, ok := commits[cur.SHA]; !ok {
+				commits[cur.SHA] = &BlameLine{SHA: cur.SHA}
+			}
+			continue
+		}
+
+		key, value, _ := strings.Cut(text, " ")
+		commit := commits[cur.SHA]
+		switch key {
+		case "author":
+			commit.Author = value
+		case "author-mail":
+			commit.AuthorMail = strings.Trim(value, "<>")
+		case "author-time":
+			if secs, err := strconv.ParseInt(value, 10, 64); err == nil {
+				commit.AuthorTime = time.Unix(secs, 0)
+			}
+		case "summary":
+			commit.Summary = value
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	return lines, nil
+}
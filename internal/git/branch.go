@@ -0,0 +1,76 @@
+package git
+
+import (
+	"context"
+	"strings"
+)
+
+// Branch is a local branch and the remote branch it tracks
+type Branch struct {
+	Name         string
+	SHA          string
+	Upstream     string // e.g. "origin/feature"; empty if none is configured
+	UpstreamGone bool   // the upstream was deleted on the remote (after a fetch --prune)
+}
+
+// ListBranches returns the local branches, sorted by name
+func ListBranches(ctx context.Context) ([]Branch, error) {
+	out, err := output(ctx, "for-each-ref",
+		"--format=%(refname:short)\t%(objectname)\t%(upstream:short)\t%(upstream:track)",
+		"refs/heads")
+	if err != nil {
+		return nil, err
+	}
+
+	var branches []Branch
+	for _, line := range strings.Split(out, "\n") {
+		fields := strings.Split(line, "\t")
+		if len(fields) != 4 {
+			continue
+		}
+		branches = append(branches, Branch{
+			Name:         fields[0],
+			SHA:          fields[1],
+			Upstream:     fields[2],
+			UpstreamGone: fields[3] == "[gone]",
+		})
+	}
+	return branches, nil
+}
+
+// WorktreeBranches returns the branches checked out in any worktree of the
+// repository, including the main one, mapped to the worktree's path
+func WorktreeBranches(ctx context.Context) (map[string]string, error) {
+	out, err := output(ctx, "worktree", "list", "--porcelain")
+	if err != nil {
+		return nil, err
+	}
+
+	branches := make(map[string]string)
+	var path string
+	for _, line := range strings.Split(out, "\n") {
+		if p, ok := strings.CutPrefix(line, "worktree "); ok {
+			path = p
+		} else if ref, ok := strings.CutPrefix(line, "branch "); ok {
+			branches[strings.TrimPrefix(ref, "refs/heads/")] = path
+		}
+	}
+	return branches, nil
+}
+
+// IsAncestor reports whether commit a is an ancestor of (or equal to) commit b.
+// It returns false if either commit is not available locally.
+func IsAncestor(ctx context.Context, a, b string) bool {
+	_, err := output(ctx, "merge-base", "--is-ancestor", a, b)
+	return err == nil
+}
+
+// OnRemote reports whether commit is reachable from some remote-tracking
+// branch, i.e. none of its commits exist only locally
+func OnRemote(ctx context.Context, commit string) (bool, error) {
+	out, err := output(ctx, "rev-list", "--max-count=1", commit, "--not", "--remotes")
+	if err != nil {
+		return false, err
+	}
+	return out == "", nil
+}
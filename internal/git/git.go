@@ -1,44 +1,49 @@
 package git
 
 import (
+	"context"
+	"fmt"
+	"os"
 	"os/exec"
 	"path/filepath"
 	"strings"
+
+	"cli-tools/internal/trace"
 )
 
-// GetRemoteURL returns the URL of the origin remote
-func GetRemoteURL() (string, error) {
-	cmd := exec.Command("git", "remote", "get-url", "origin")
-	out, err := cmd.Output()
+// output runs git with the given arguments and returns its trimmed stdout.
+// If ctx was cancelled or timed out, the cause is returned instead of the
+// "signal: killed" error from the subprocess.
+func output(ctx context.Context, args ...string) (string, error) {
+	cmd := exec.CommandContext(ctx, "git", args...)
+	out, err := trace.Output(cmd)
 	if err != nil {
+		if ctx.Err() != nil {
+			return "", context.Cause(ctx)
+		}
 		return "", err
 	}
 	return strings.TrimSpace(string(out)), nil
 }
 
+// GetRemoteURL returns the URL of the origin remote
+func GetRemoteURL(ctx context.Context) (string, error) {
+	return output(ctx, "remote", "get-url", "origin")
+}
+
 // GetCurrentBranch returns the name of the current branch
-func GetCurrentBranch() (string, error) {
-	cmd := exec.Command("git", "rev-parse", "--abbrev-ref", "HEAD")
-	out, err := cmd.Output()
-	if err != nil {
-		return "", err
-	}
-	return strings.TrimSpace(string(out)), nil
+func GetCurrentBranch(ctx context.Context) (string, error) {
+	return output(ctx, "rev-parse", "--abbrev-ref", "HEAD")
 }
 
 // GetRepoRoot returns the root directory of the git repository
-func GetRepoRoot() (string, error) {
-	cmd := exec.Command("git", "rev-parse", "--show-toplevel")
-	out, err := cmd.Output()
-	if err != nil {
-		return "", err
-	}
-	return strings.TrimSpace(string(out)), nil
+func GetRepoRoot(ctx context.Context) (string, error) {
+	return output(ctx, "rev-parse", "--show-toplevel")
 }
 
 // GetRelativePath returns the path of a file relative to the repo root
-func GetRelativePath(filePath string) (string, error) {
-	root, err := GetRepoRoot()
+func GetRelativePath(ctx context.Context, filePath string) (string, error) {
+	root, err := GetRepoRoot(ctx)
 	if err != nil {
 		return "", err
 	}
@@ -57,20 +62,20 @@ func GetRelativePath(filePath string) (string, error) {
 }
 
 // GetDefaultBranch returns the default branch (main or master)
-func GetDefaultBranch() (string, error) {
+func GetDefaultBranch(ctx context.Context) (string, error) {
 	// Try to get the default branch from remote HEAD
-	cmd := exec.Command("git", "symbolic-ref", "refs/remotes/origin/HEAD", "--short")
-	out, err := cmd.Output()
+	branch, err := output(ctx, "symbolic-ref", "refs/remotes/origin/HEAD", "--short")
 	if err == nil {
-		branch := strings.TrimSpace(string(out))
 		// Remove "origin/" prefix
 		return strings.TrimPrefix(branch, "origin/"), nil
 	}
+	if ctx.Err() != nil {
+		return "", context.Cause(ctx)
+	}
 
 	// Fallback: check if main or master exists
 	for _, branch := range []string{"main", "master"} {
-		cmd := exec.Command("git", "rev-parse", "--verify", "refs/heads/"+branch)
-		if err := cmd.Run(); err == nil {
+		if _, err := output(ctx, "rev-parse", "--verify", "refs/heads/"+branch); err == nil {
 			return branch, nil
 		}
 	}
@@ -79,8 +84,114 @@ func GetDefaultBranch() (string, error) {
 }
 
 // IsInsideRepo checks if the current directory is inside a git repository
-func IsInsideRepo() bool {
-	cmd := exec.Command("git", "rev-parse", "--is-inside-work-tree")
-	err := cmd.Run()
+func IsInsideRepo(ctx context.Context) bool {
+	_, err := output(ctx, "rev-parse", "--is-inside-work-tree")
 	return err == nil
 }
+
+// GetRemoteURLFor returns the URL of the named remote
+func GetRemoteURLFor(ctx context.Context, remote string) (string, error) {
+	return output(ctx, "remote", "get-url", remote)
+}
+
+// GetUpstream returns the remote name and remote branch that branch tracks.
+// Both are empty if the branch has no upstream configured.
+func GetUpstream(ctx context.Context, branch string) (remote, remoteBranch string, err error) {
+	remote, err = output(ctx, "config", "--get", "branch."+branch+".remote")
+	if err != nil {
+		if ctx.Err() != nil {
+			return "", "", context.Cause(ctx)
+		}
+		return "", "", nil // git config exits 1 when the key is unset
+	}
+
+	merge, err := output(ctx, "config", "--get", "branch."+branch+".merge")
+	if err != nil {
+		if ctx.Err() != nil {
+			return "", "", context.Cause(ctx)
+		}
+		return "", "", nil
+	}
+
+	return remote, strings.TrimPrefix(merge, "refs/heads/"), nil
+}
+
+// ResolveCommit returns the full SHA of the commit that rev points to
+func ResolveCommit(ctx context.Context, rev string) (string, error) {
+	return output(ctx, "rev-parse", "--verify", "--quiet", rev+"^{commit}")
+}
+
+// BranchExists checks if a local branch with the given name exists
+func BranchExists(ctx context.Context, branch string) bool {
+	_, err := output(ctx, "rev-parse", "--verify", "--quiet", "refs/heads/"+branch)
+	return err == nil
+}
+
+// Run runs git with the given arguments, showing its output to the user.
+// Use it for commands that change the repository (fetch, checkout, ...).
+func Run(ctx context.Context, args ...string) error {
+	cmd := exec.CommandContext(ctx, "git", args...)
+	cmd.Stdout = os.Stderr
+	cmd.Stderr = os.Stderr
+	if err := trace.Run(cmd); err != nil {
+		if ctx.Err() != nil {
+			return context.Cause(ctx)
+		}
+		return fmt.Errorf("git %s failed: %w", strings.Join(args, " "), err)
+	}
+	return nil
+}
+
+// ListRemotes returns the names of the configured remotes
+func ListRemotes(ctx context.Context) ([]string, error) {
+	out, err := output(ctx, "remote")
+	if err != nil {
+		return nil, err
+	}
+	return strings.Fields(out), nil
+}
+
+// SetUpstream configures the remote and remote ref that branch tracks.
+// mergeRef may be a branch name or a full ref such as refs/pull/123/head.
+func SetUpstream(ctx context.Context, branch, remote, mergeRef string) error {
+	if !strings.HasPrefix(mergeRef, "refs/") {
+		mergeRef = "refs/heads/" + mergeRef
+	}
+	if _, err := output(ctx, "config", "branch."+branch+".remote", remote); err != nil {
+		return err
+	}
+	_, err := output(ctx, "config", "branch."+branch+".merge", mergeRef)
+	return err
+}
+
+// HasLocalChanges reports whether the working tree or index has uncommitted
+// changes to tracked files
+func HasLocalChanges(ctx context.Context) (bool, error) {
+	out, err := output(ctx, "status", "--porcelain", "--untracked-files=no")
+	if err != nil {
+		return false, err
+	}
+	return out != "", nil
+}
+
+// ConflictedFiles returns the files left with unresolved conflicts by a
+// merge or rebase
+func ConflictedFiles(ctx context.Context) ([]string, error) {
+	out, err := output(ctx, "diff", "--name-only", "--diff-filter=U")
+	if err != nil {
+		return nil, err
+	}
+	if out == "" {
+		return nil, nil
+	}
+	return strings.Split(out, "\n"), nil
+}
+
+// GetConfig returns the value of a git config key, or "" if it is unset
+func GetConfig(ctx context.Context, key string) string {
+	out, err := output(ctx, "config", "--get", key)
+	if err != nil {
+		return ""
+	}
+	return out
+}
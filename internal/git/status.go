@@ -0,0 +1,96 @@
+package git
+
+import (
+	"context"
+	"fmt"
+	"os/exec"
+	"strings"
+
+	"cli-tools/internal/trace"
+)
+
+// WorkingTree counts the uncommitted changes in the working tree and index
+type WorkingTree struct {
+	Staged     int // files with changes in the index
+	Unstaged   int // tracked files with changes not yet staged
+	Untracked  int
+	Conflicted int
+}
+
+// Clean reports whether there is nothing to commit, ignoring untracked files
+func (w *WorkingTree) Clean() bool {
+	return w.Staged == 0 && w.Unstaged == 0 && w.Conflicted == 0
+}
+
+// GetWorkingTree returns the state of the working tree and index
+func GetWorkingTree(ctx context.Context) (*WorkingTree, error) {
+	// Not output(): trimming would eat the leading space of the first line's
+	// status code
+	cmd := exec.CommandContext(ctx, "git", "status", "--porcelain")
+	raw, err := trace.Output(cmd)
+	if err != nil {
+		if ctx.Err() != nil {
+			return nil, context.Cause(ctx)
+		}
+		return nil, err
+	}
+	out := strings.TrimRight(string(raw), "\n")
+
+	w := &WorkingTree{}
+	for _, line := range strings.Split(out, "\n") {
+		if len(line) < 2 {
+			continue
+		}
+		x, y := line[0], line[1]
+		switch {
+		case x == '?':
+			w.Untracked++
+		case x == 'U' || y == 'U' || x == 'A' && y == 'A' || x == 'D' && y == 'D':
+			w.Conflicted++
+		default:
+			// A file can have both staged and unstaged changes
+			if x != ' ' {
+				w.Staged++
+			}
+			if y != ' ' {
+				w.Unstaged++
+			}
+		}
+	}
+	return w, nil
+}
+
+// AheadBehind counts the commits reachable from a but not from b (ahead)
+// and from b but not from a (behind)
+func AheadBehind(ctx context.Context, a, b string) (ahead, behind int, err error) {
+	out, err := output(ctx, "rev-list", "--left-right", "--count", a+"..."+b)
+	if err != nil {
+		return 0, 0, err
+	}
+	if _, err := fmt.Sscan(out, &ahead, &behind); err != nil {
+		return 0, 0, fmt.Errorf("unexpected rev-list output %q", out)
+	}
+	return ahead, behind, nil
+}
+
+// UpstreamRef returns the remote-tracking ref that branch tracks, such as
+// "origin/feature", or "" if it has none or it wasn't fetched
+func UpstreamRef(ctx context.Context, branch string) string {
+	out, err := output(ctx, "rev-parse", "--abbrev-ref", "--verify", "--quiet", branch+"@{upstream}")
+	if err != nil {
+		return ""
+	}
+	return out
+}
+
+// StashCount returns the number of stash entries
+func StashCount(ctx context.Context) (int, error) {
+	out, err := output(ctx, "stash", "list")
+	if err != nil {
+		return 0, err
+	}
+	if out == "" {
+		return 0, nil
+	}
+	return strings.Count(out, "\n") + 1, nil
+}
@@ -1,6 +1,7 @@
 package github
 
 import (
+	"context"
 	"fmt"
 	"regexp"
 	"strings"
@@ -10,15 +11,16 @@ import (
 
 // RepoInfo contains parsed GitHub repository information
 type RepoInfo struct {
-	Owner    string
-	Repo     string
-	Host     string // SSH host alias (e.g., "github.com", "github-rhei")
-	BaseURL  string // Full HTTPS URL to the repo
+	Owner   string
+	Repo    string
+	Host    string // SSH host alias (e.g., "github.com", "github-rhei")
+	BaseURL string // Full HTTPS URL to the repo
+	SSH     bool   // Whether the remote uses the SSH URL format
 }
 
 // GetRepoInfo parses the git remote and returns GitHub repo information
-func GetRepoInfo() (*RepoInfo, error) {
-	remoteURL, err := git.GetRemoteURL()
+func GetRepoInfo(ctx context.Context) (*RepoInfo, error) {
+	remoteURL, err := git.GetRemoteURL(ctx)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get remote URL: %w", err)
 	}
@@ -26,6 +28,26 @@ func GetRepoInfo() (*RepoInfo, error) {
 	return ParseRemoteURL(remoteURL)
 }
 
+// RemoteURLFor returns a git remote URL for another repository ("owner/repo")
+// on the same host, in the same format as this remote. SSH host aliases are
+// kept so the right account's key is used.
+func (info *RepoInfo) RemoteURLFor(ownerRepo string) string {
+	if info.SSH {
+		return fmt.Sprintf("git@%s:%s.git", info.Host, ownerRepo)
+	}
+	return fmt.Sprintf("https://%s/%s.git", info.Host, ownerRepo)
+}
+
+// GetRemoteRepoInfo returns GitHub repo information for the named remote
+func GetRemoteRepoInfo(ctx context.Context, remote string) (*RepoInfo, error) {
+	remoteURL, err := git.GetRemoteURLFor(ctx, remote)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get URL of remote %s: %w", remote, err)
+	}
+
+	return ParseRemoteURL(remoteURL)
+}
+
 // ParseRemoteURL converts a git remote URL to RepoInfo
 // Supports:
 //   - git@github.com:owner/repo.git
@@ -42,6 +64,7 @@ func ParseRemoteURL(remoteURL string) (*RepoInfo, error) {
 		info.Owner = matches[2]
 		info.Repo = matches[3]
 		info.BaseURL = fmt.Sprintf("https://github.com/%s/%s", info.Owner, info.Repo)
+		info.SSH = true
 		return info, nil
 	}
 
@@ -58,9 +81,50 @@ func ParseRemoteURL(remoteURL string) (*RepoInfo, error) {
 	return nil, fmt.Errorf("unable to parse remote URL: %s", remoteURL)
 }
 
+// FindRemote returns the name of the local remote that points to ownerRepo
+// ("owner/repo"), or an empty string if there is none
+func FindRemote(ctx context.Context, ownerRepo string) (string, error) {
+	remotes, err := git.ListRemotes(ctx)
+	if err != nil {
+		return "", err
+	}
+
+	// Prefer origin when several remotes point to the same repository
+	for i, remote := range remotes {
+		if remote == "origin" {
+			remotes[0], remotes[i] = remotes[i], remotes[0]
+		}
+	}
+
+	for _, remote := range remotes {
+		info, err := GetRemoteRepoInfo(ctx, remote)
+		if err != nil {
+			continue
+		}
+		if strings.EqualFold(info.Owner+"/"+info.Repo, ownerRepo) {
+			return remote, nil
+		}
+	}
+	return "", nil
+}
+
+// ParsePRURL extracts "owner/repo" and the PR number from a pull request URL
+// such as https://github.com/owner/repo/pull/123
+func ParsePRURL(prURL string) (string, int, error) {
+	prRegex := regexp.MustCompile(`^https?://[^/]+/([^/]+)/([^/]+)/pull/(\d+)`)
+	matches := prRegex.FindStringSubmatch(prURL)
+	if matches == nil {
+		return "", 0, fmt.Errorf("not a pull request URL: %s", prURL)
+	}
+
+	var number int
+	fmt.Sscanf(matches[3], "%d", &number)
+	return matches[1] + "/" + matches[2], number, nil
+}
+
 // GetRepoURL returns the HTTPS URL for the current repository
-func GetRepoURL() (string, error) {
-	info, err := GetRepoInfo()
+func GetRepoURL(ctx context.Context) (string, error) {
+	info, err := GetRepoInfo(ctx)
 	if err != nil {
 		return "", err
 	}
@@ -68,8 +132,8 @@ func GetRepoURL() (string, error) {
 }
 
 // GetOwnerRepo returns "owner/repo" for the current repository
-func GetOwnerRepo() (string, error) {
-	info, err := GetRepoInfo()
+func GetOwnerRepo(ctx context.Context) (string, error) {
+	info, err := GetRepoInfo(ctx)
 	if err != nil {
 		return "", err
 	}
@@ -77,8 +141,8 @@ func GetOwnerRepo() (string, error) {
 }
 
 // BuildURL constructs a GitHub URL by appending a path to the repo URL
-func BuildURL(path string) (string, error) {
-	baseURL, err := GetRepoURL()
+func BuildURL(ctx context.Context, path string) (string, error) {
+	baseURL, err := GetRepoURL(ctx)
 	if err != nil {
 		return "", err
 	}
@@ -86,20 +150,20 @@ func BuildURL(path string) (string, error) {
 }
 
 // BuildFileURL constructs a URL to view a file on GitHub
-func BuildFileURL(filePath string, line int, branch string) (string, error) {
-	baseURL, err := GetRepoURL()
+func BuildFileURL(ctx context.Context, filePath string, line int, branch string) (string, error) {
+	baseURL, err := GetRepoURL(ctx)
 	if err != nil {
 		return "", err
 	}
 
-	relPath, err := git.GetRelativePath(filePath)
+	relPath, err := git.GetRelativePath(ctx, filePath)
 	if err != nil {
 		return "", fmt.Errorf("failed to get relative path: %w", err)
 	}
 
 	// Use current branch if not specified
 	if branch == "" {
-		branch, err = git.GetCurrentBranch()
+		branch, err = git.GetCurrentBranch(ctx)
 		if err != nil {
 			return "", fmt.Errorf("failed to get current branch: %w", err)
 		}
@@ -113,20 +177,20 @@ func BuildFileURL(filePath string, line int, branch string) (string, error) {
 }
 
 // BuildBlameURL constructs a URL to view blame for a file on GitHub
-func BuildBlameURL(filePath string, line int, branch string) (string, error) {
-	baseURL, err := GetRepoURL()
+func BuildBlameURL(ctx context.Context, filePath string, line int, branch string) (string, error) {
+	baseURL, err := GetRepoURL(ctx)
 	if err != nil {
 		return "", err
 	}
 
-	relPath, err := git.GetRelativePath(filePath)
+	relPath, err := git.GetRelativePath(ctx, filePath)
 	if err != nil {
 		return "", fmt.Errorf("failed to get relative path: %w", err)
 	}
 
 	// Use current branch if not specified
 	if branch == "" {
-		branch, err = git.GetCurrentBranch()
+		branch, err = git.GetCurrentBranch(ctx)
 		if err != nil {
 			return "", fmt.Errorf("failed to get current branch: %w", err)
 		}
@@ -140,15 +204,15 @@ func BuildBlameURL(filePath string, line int, branch string) (string, error) {
 }
 
 // BuildCompareURL constructs a URL to create a PR (compare view)
-func BuildCompareURL(branch string) (string, error) {
-	baseURL, err := GetRepoURL()
+func BuildCompareURL(ctx context.Context, branch string) (string, error) {
+	baseURL, err := GetRepoURL(ctx)
 	if err != nil {
 		return "", err
 	}
 
 	if branch == "" {
 		var err error
-		branch, err = git.GetCurrentBranch()
+		branch, err = git.GetCurrentBranch(ctx)
 		if err != nil {
 			return "", fmt.Errorf("failed to get current branch: %w", err)
 		}
@@ -159,8 +223,8 @@ func BuildCompareURL(branch string) (string, error) {
 
 // GetSSHHostAlias extracts the SSH host alias from the remote URL
 // Returns empty string for HTTPS URLs
-func GetSSHHostAlias() (string, error) {
-	info, err := GetRepoInfo()
+func GetSSHHostAlias(ctx context.Context) (string, error) {
+	info, err := GetRepoInfo(ctx)
 	if err != nil {
 		return "", err
 	}
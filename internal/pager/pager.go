@@ -0,0 +1,125 @@
+package pager
+
+import (
+	"context"
+	"io"
+	"os"
+	"os/exec"
+	"runtime"
+	"strings"
+	"time"
+
+	"cli-tools/internal/cli"
+	"cli-tools/internal/trace"
+)
+
+// Pager receives long output. When stdout is a terminal it pipes the output
+// through the pager git would use; otherwise it writes straight to stdout.
+type Pager struct {
+	w        io.WriteCloser
+	cmd      *exec.Cmd
+	started  time.Time
+	restore  func() // lets Ctrl-C interrupt the command again
+	terminal bool
+}
+
+// IsTerminal reports whether f is attached to a terminal
+func IsTerminal(f *os.File) bool {
+	fi, err := f.Stat()
+	return err == nil && fi.Mode()&os.ModeCharDevice != 0
+}
+
+// Start starts the pager. If none is configured or it can't be started,
+// output goes to stdout directly.
+func Start(ctx context.Context) *Pager {
+	p := &Pager{w: nopCloser{os.Stdout}, terminal: IsTerminal(os.Stdout)}
+	if !p.terminal {
+		return p
+	}
+
+	command := pagerCommand(ctx)
+	if command == "" || command == "cat" {
+		return p
+	}
+
+	// Not tied to ctx: Ctrl-C is a keypress in less, and killing the pager
+	// would leave the terminal in its raw mode
+	var cmd *exec.Cmd
+	if runtime.GOOS == "windows" {
+		fields := strings.Fields(command)
+		cmd = exec.Command(fields[0], fields[1:]...)
+	} else {
+		cmd = exec.Command("sh", "-c", command)
+	}
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+	// Like git: quit if the output fits on one screen, keep colors
+	cmd.Env = os.Environ()
+	if os.Getenv("LESS") == "" {
+		cmd.Env = append(cmd.Env, "LESS=FRX")
+	}
+	if os.Getenv("LV") == "" {
+		cmd.Env = append(cmd.Env, "LV=-c")
+	}
+
+	w, err := cmd.StdinPipe()
+	if err != nil {
+		return p
+	}
+	started := time.Now()
+	if err := cmd.Start(); err != nil {
+		return p
+	}
+	p.w, p.cmd, p.started = w, cmd, started
+	// The pager gets Ctrl-C too, since it shares the terminal; like git,
+	// keep running until it exits
+	p.restore = cli.IgnoreInterrupts()
+	return p
+}
+
+// pagerCommand returns the pager to use, looked up the way git does:
+// GIT_PAGER, core.pager, PAGER, then less
+func pagerCommand(ctx context.Context) string {
+	if out, err := trace.Output(exec.CommandContext(ctx, "git", "var", "GIT_PAGER")); err == nil {
+		return strings.TrimSpace(string(out))
+	}
+	if p, ok := os.LookupEnv("PAGER"); ok {
+		return p
+	}
+	if runtime.GOOS == "windows" {
+		return ""
+	}
+	return "less"
+}
+
+// Write writes to the pager. Errors are ignored once the user quits the
+// pager early, so callers can keep writing.
+func (p *Pager) Write(b []byte) (int, error) {
+	if _, err := p.w.Write(b); err != nil && p.cmd == nil {
+		return 0, err
+	}
+	return len(b), nil
+}
+
+// Color reports whether the output may be colored: it ends up on a
+// terminal and NO_COLOR is not set
+func (p *Pager) Color() bool {
+	return p.terminal && os.Getenv("NO_COLOR") == ""
+}
+
+// Close finishes the output and waits for the user to quit the pager
+func (p *Pager) Close() error {
+	err := p.w.Close()
+	if p.cmd != nil {
+		err = trace.Wait(p.cmd, p.started)
+		p.restore()
+	}
+	return err
+}
+
+// nopCloser keeps Close from closing stdout
+type nopCloser struct {
+	io.Writer
+}
+
+func (nopCloser) Close() error { return nil }
@@ -0,0 +1,70 @@
+package picker
+
+import (
+	"sort"
+	"strings"
+	"unicode"
+)
+
+// match is an item that matched the query, with its score
+type match struct {
+	index int
+	score int
+}
+
+// filter returns the items matching query, best matches first.
+// An empty query matches everything in the original order.
+func filter(query string, items []string) []match {
+	var matches []match
+	for i, item := range items {
+		if score, ok := fuzzyScore(query, item); ok {
+			matches = append(matches, match{index: i, score: score})
+		}
+	}
+	sort.SliceStable(matches, func(i, j int) bool {
+		return matches[i].score > matches[j].score
+	})
+	return matches
+}
+
+// fuzzyScore reports whether the characters of query appear in s in order,
+// ignoring case and spaces in the query. Higher scores are better matches:
+// consecutive characters and matches at the start of words count extra,
+// and later first matches count less.
+func fuzzyScore(query, s string) (int, bool) {
+	text := []rune(strings.ToLower(s))
+	score := 0
+	pos := 0
+	prev := -2
+	first := -1
+
+	for _, r := range strings.ToLower(query) {
+		if unicode.IsSpace(r) {
+			continue
+		}
+		for pos < len(text) && text[pos] != r {
+			pos++
+		}
+		if pos == len(text) {
+			return 0, false
+		}
+
+		score++
+		if pos == prev+1 {
+			score += 5
+		}
+		if pos == 0 || !unicode.IsLetter(text[pos-1]) && !unicode.IsDigit(text[pos-1]) {
+			score += 3
+		}
+		if first < 0 {
+			first = pos
+		}
+		prev = pos
+		pos++
+	}
+
+	if first > 0 {
+		score -= min(first, 10)
+	}
+	return score, true
+}
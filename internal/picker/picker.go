@@ -0,0 +1,109 @@
+package picker
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"strconv"
+	"strings"
+
+	"cli-tools/internal/prompt"
+	"cli-tools/internal/trace"
+)
+
+// ErrCancelled is returned when the user leaves the picker without choosing
+var ErrCancelled = errors.New("cancelled")
+
+// Pick shows items in an interactive fuzzy finder and returns the index of
+// the chosen one. It uses fzf when installed, the built-in terminal finder
+// otherwise, and a numbered list where the terminal cannot be put in raw
+// mode (e.g. on Windows). Set CLI_TOOLS_PICKER to "fzf", "builtin" or
+// "prompt" to force one.
+//
+// It returns prompt.ErrNotInteractive if stdin is not a terminal.
+func Pick(ctx context.Context, title string, items []string) (int, error) {
+	if !prompt.IsInteractive() {
+		return 0, prompt.ErrNotInteractive
+	}
+	if len(items) == 0 {
+		return 0, errors.New("nothing to choose from")
+	}
+
+	mode := os.Getenv("CLI_TOOLS_PICKER")
+	if mode == "" || mode == "fzf" {
+		if _, err := exec.LookPath("fzf"); err == nil {
+			return pickWithFzf(ctx, title, items)
+		}
+	}
+	if mode != "prompt" {
+		idx, err := pickBuiltin(ctx, title, items)
+		if !errors.Is(err, errNoRawMode) {
+			return idx, err
+		}
+	}
+	return pickFromList(ctx, title, items)
+}
+
+// pickFromList lists items on stderr and lets the user pick one by number,
+// or narrow the list by typing text that fuzzily matches the items
+func pickFromList(ctx context.Context, title string, items []string) (int, error) {
+	visible := filter("", items)
+
+	fmt.Fprintln(os.Stderr, title)
+	for {
+		for n, m := range visible {
+			fmt.Fprintf(os.Stderr, "  %d) %s\n", n+1, items[m.index])
+		}
+		line, err := prompt.Input(ctx, fmt.Sprintf("Choose [1-%d] or type to filter", len(visible)), "")
+		if err != nil {
+			return 0, err
+		}
+
+		if n, err := strconv.Atoi(line); err == nil && n >= 1 && n <= len(visible) {
+			return visible[n-1].index, nil
+		}
+		matched := filter(line, items)
+		if len(matched) == 0 {
+			fmt.Fprintf(os.Stderr, "Nothing matches %q\n", line)
+			continue
+		}
+		visible = matched
+	}
+}
+
+// pickWithFzf delegates to fzf, feeding it "index<TAB>label" lines and
+// showing only the label
+func pickWithFzf(ctx context.Context, title string, items []string) (int, error) {
+	var input bytes.Buffer
+	for i, item := range items {
+		fmt.Fprintf(&input, "%d\t%s\n", i, strings.ReplaceAll(item, "\n", " "))
+	}
+
+	cmd := exec.CommandContext(ctx, "fzf",
+		"--delimiter", "\t", "--with-nth", "2..",
+		"--height", "40%", "--reverse", "--no-multi",
+		"--header", title)
+	cmd.Stdin = &input
+	cmd.Stderr = os.Stderr
+	out, err := trace.Output(cmd)
+	if err != nil {
+		if ctx.Err() != nil {
+			return 0, context.Cause(ctx)
+		}
+		// fzf exits 130 on Esc/Ctrl-C and 1 when nothing matched
+		if exitErr, ok := err.(*exec.ExitError); ok && (exitErr.ExitCode() == 130 || exitErr.ExitCode() == 1) {
+			return 0, ErrCancelled
+		}
+		return 0, fmt.Errorf("fzf: %w", err)
+	}
+
+	idxStr, _, _ := strings.Cut(string(out), "\t")
+	idx, err := strconv.Atoi(idxStr)
+	if err != nil || idx < 0 || idx >= len(items) {
+		return 0, fmt.Errorf("unexpected fzf output: %q", out)
+	}
+	return idx, nil
+}
@@ -0,0 +1,280 @@
+package picker
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"strconv"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"cli-tools/internal/trace"
+)
+
+// errNoRawMode is returned when the terminal cannot be switched to raw mode,
+// so Pick falls back to a line-based prompt
+var errNoRawMode = errors.New("terminal does not support raw mode")
+
+// maxHeight is the most items the built-in finder shows at once
+const maxHeight = 10
+
+// Key codes the built-in finder reacts to
+const (
+	keyCtrlC     = 3
+	keyCtrlN     = 14
+	keyCtrlP     = 16
+	keyCtrlU     = 21
+	keyCtrlW     = 23
+	keyEnter     = 13
+	keyNewline   = 10
+	keyEscape    = 27
+	keyBackspace = 127
+	keyCtrlH     = 8
+)
+
+// tty is the controlling terminal switched into raw mode
+type tty struct {
+	f     *os.File
+	saved string
+	width int
+}
+
+// openTTY opens /dev/tty and puts it in raw mode, remembering the previous
+// settings so close can restore them
+func openTTY(ctx context.Context) (*tty, error) {
+	f, err := os.OpenFile("/dev/tty", os.O_RDWR, 0)
+	if err != nil {
+		return nil, errNoRawMode
+	}
+
+	saved, err := stty(ctx, f, "-g")
+	if err != nil {
+		f.Close()
+		return nil, errNoRawMode
+	}
+	if _, err := stty(ctx, f, "raw", "-echo"); err != nil {
+		f.Close()
+		return nil, errNoRawMode
+	}
+
+	t := &tty{f: f, saved: saved, width: 80}
+	if size, err := stty(ctx, f, "size"); err == nil {
+		if fields := strings.Fields(size); len(fields) == 2 {
+			if cols, err := strconv.Atoi(fields[1]); err == nil && cols > 10 {
+				t.width = cols
+			}
+		}
+	}
+	return t, nil
+}
+
+// close restores the terminal settings. It runs even when ctx is done so an
+// interrupted picker never leaves the terminal in raw mode.
+func (t *tty) close() {
+	stty(context.Background(), t.f, t.saved)
+	t.f.Close()
+}
+
+// stty runs stty against the terminal f
+func stty(ctx context.Context, f *os.File, args ...string) (string, error) {
+	cmd := exec.CommandContext(ctx, "stty", args...)
+	cmd.Stdin = f
+	out, err := trace.Output(cmd)
+	return strings.TrimSpace(string(out)), err
+}
+
+// finder is the state of the built-in fuzzy finder
+type finder struct {
+	items   []string
+	query   []rune
+	matches []match
+	sel     int // index into matches
+	offset  int // first match shown
+}
+
+// pickBuiltin runs the built-in fuzzy finder on /dev/tty
+func pickBuiltin(ctx context.Context, title string, items []string) (int, error) {
+	t, err := openTTY(ctx)
+	if err != nil {
+		return 0, err
+	}
+	defer t.close()
+
+	keys := make(chan []byte)
+	done := make(chan struct{})
+	defer close(done)
+	go func() {
+		buf := make([]byte, 64)
+		for {
+			n, err := t.f.Read(buf)
+			if err != nil {
+				close(keys)
+				return
+			}
+			chunk := make([]byte, n)
+			copy(chunk, buf[:n])
+			select {
+			case keys <- chunk:
+			case <-done:
+				return
+			}
+		}
+	}()
+
+	f := &finder{items: items}
+	f.update()
+	fmt.Fprintf(t.f, "%s\r\n", title)
+	f.draw(t)
+
+	clear := func() {
+		// Clear the list, then the title line above it
+		fmt.Fprint(t.f, "\r\x1b[J\x1b[1A\r\x1b[J")
+	}
+
+	for {
+		var chunk []byte
+		select {
+		case <-ctx.Done():
+			clear()
+			return 0, context.Cause(ctx)
+		case c, ok := <-keys:
+			if !ok {
+				clear()
+				return 0, ErrCancelled
+			}
+			chunk = c
+		}
+
+		done, chosen := f.handle(chunk)
+		if done {
+			clear()
+			if chosen < 0 {
+				return 0, ErrCancelled
+			}
+			return chosen, nil
+		}
+		f.draw(t)
+	}
+}
+
+// handle applies a chunk of key presses. It reports whether the finder is
+// done and, if so, the chosen item index or -1 if the user cancelled.
+func (f *finder) handle(chunk []byte) (bool, int) {
+	for len(chunk) > 0 {
+		b := chunk[0]
+		switch {
+		case b == keyEscape:
+			// Arrow keys arrive as ESC [ A or ESC O A; a lone ESC cancels
+			if len(chunk) >= 3 && (chunk[1] == '[' || chunk[1] == 'O') {
+				switch chunk[2] {
+				case 'A':
+					f.move(-1)
+				case 'B':
+					f.move(1)
+				}
+				chunk = chunk[3:]
+				continue
+			}
+			return true, -1
+		case b == keyCtrlC:
+			return true, -1
+		case b == keyEnter || b == keyNewline:
+			if len(f.matches) == 0 {
+				chunk = chunk[1:]
+				continue
+			}
+			return true, f.matches[f.sel].index
+		case b == keyBackspace || b == keyCtrlH:
+			if len(f.query) > 0 {
+				f.query = f.query[:len(f.query)-1]
+				f.update()
+			}
+		case b == keyCtrlU:
+			f.query = nil
+			f.update()
+		case b == keyCtrlW:
+			q := strings.TrimRightFunc(string(f.query), unicode.IsSpace)
+			if i := strings.LastIndexFunc(q, unicode.IsSpace); i >= 0 {
+				q = q[:i+1]
+			} else {
+				q = ""
+			}
+			f.query = []rune(q)
+			f.update()
+		case b == keyCtrlP:
+			f.move(-1)
+		case b == keyCtrlN:
+			f.move(1)
+		case b >= 0x20:
+			r, size := utf8.DecodeRune(chunk)
+			if r != utf8.RuneError && unicode.IsPrint(r) {
+				f.query = append(f.query, r)
+				f.update()
+			}
+			chunk = chunk[size:]
+			continue
+		}
+		chunk = chunk[1:]
+	}
+	return false, 0
+}
+
+// update re-filters the items after the query changed
+func (f *finder) update() {
+	f.matches = filter(string(f.query), f.items)
+	f.sel = 0
+	f.offset = 0
+}
+
+// move moves the selection by delta, scrolling the visible window with it
+func (f *finder) move(delta int) {
+	if len(f.matches) == 0 {
+		return
+	}
+	f.sel = max(0, min(len(f.matches)-1, f.sel+delta))
+	if f.sel < f.offset {
+		f.offset = f.sel
+	}
+	if f.sel >= f.offset+maxHeight {
+		f.offset = f.sel - maxHeight + 1
+	}
+}
+
+// draw renders the query line and the visible matches below it, then puts
+// the cursor back at the end of the query
+func (f *finder) draw(t *tty) {
+	var b strings.Builder
+	b.WriteString("\r\x1b[J")
+	prompt := "> " + string(f.query)
+	fmt.Fprintf(&b, "%s  \x1b[2m%d/%d\x1b[0m", prompt, len(f.matches), len(f.items))
+
+	end := min(len(f.matches), f.offset+maxHeight)
+	for i := f.offset; i < end; i++ {
+		label := truncate(f.items[f.matches[i].index], t.width-3)
+		if i == f.sel {
+			fmt.Fprintf(&b, "\r\n\x1b[7m> %s\x1b[0m", label)
+		} else {
+			fmt.Fprintf(&b, "\r\n  %s", label)
+		}
+	}
+
+	lines := end - f.offset
+	if lines > 0 {
+		fmt.Fprintf(&b, "\x1b[%dA", lines)
+	}
+	fmt.Fprintf(&b, "\r\x1b[%dC", utf8.RuneCountInString(prompt))
+	fmt.Fprint(t.f, b.String())
+}
+
+// truncate shortens s to at most width runes, marking the cut with "…"
+func truncate(s string, width int) string {
+	s = strings.ReplaceAll(s, "\n", " ")
+	if width < 1 || utf8.RuneCountInString(s) <= width {
+		return s
+	}
+	r := []rune(s)
+	return string(r[:width-1]) + "…"
+}
@@ -0,0 +1,86 @@
+package prompt
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strings"
+
+	"cli-tools/internal/trace"
+)
+
+// scissors separates the text being edited from the help below it, like in
+// git commit --verbose. Everything from it on is dropped.
+const scissors = "# ------------------------ >8 ------------------------"
+
+// Edit opens the editor git would use on initial and returns the saved
+// text without surrounding blank lines. help is shown below a scissors line,
+// which is removed with everything after it; the user's text is kept as is,
+// including lines starting with "#". name is used for the temporary file,
+// so editors can pick a file type.
+func Edit(ctx context.Context, name, initial, help string) (string, error) {
+	if !IsInteractive() {
+		return "", ErrNotInteractive
+	}
+
+	editor, err := trace.Output(exec.CommandContext(ctx, "git", "var", "GIT_EDITOR"))
+	if err != nil {
+		if ctx.Err() != nil {
+			return "", context.Cause(ctx)
+		}
+		return "", fmt.Errorf("failed to find an editor: %w", err)
+	}
+
+	dir, err := os.MkdirTemp("", "cli-tools-")
+	if err != nil {
+		return "", err
+	}
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, name)
+	var text strings.Builder
+	text.WriteString(initial)
+	text.WriteString("\n\n" + scissors + "\n")
+	text.WriteString("# Do not modify or remove the line above.\n")
+	text.WriteString("# Everything below it is ignored.\n")
+	for _, line := range strings.Split(help, "\n") {
+		text.WriteString("# " + line + "\n")
+	}
+	if err := os.WriteFile(path, []byte(text.String()), 0o600); err != nil {
+		return "", err
+	}
+
+	// Like git, let the shell handle editors given with arguments
+	var cmd *exec.Cmd
+	if runtime.GOOS == "windows" {
+		fields := strings.Fields(string(editor))
+		cmd = exec.CommandContext(ctx, fields[0], append(fields[1:], path)...)
+	} else {
+		cmd = exec.CommandContext(ctx, "sh", "-c", strings.TrimSpace(string(editor))+` "$@"`, "editor", path)
+	}
+	cmd.Stdin = os.Stdin
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+	if err := trace.Run(cmd); err != nil {
+		if ctx.Err() != nil {
+			return "", context.Cause(ctx)
+		}
+		return "", fmt.Errorf("editor failed: %w", err)
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return "", err
+	}
+	saved := strings.ReplaceAll(string(data), "\r\n", "\n")
+	var lines []string
+	for _, line := range strings.Split(saved, "\n") {
+		if line == scissors {
+			break
+		}
+		lines = append(lines, line)
+	}
+	return strings.TrimSpace(strings.Join(lines, "\n")), nil
+}
@@ -0,0 +1,107 @@
+package prompt
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+)
+
+// ErrNotInteractive is returned when a question needs an answer but stdin is not a terminal
+var ErrNotInteractive = errors.New("cannot prompt: stdin is not a terminal")
+
+// IsInteractive reports whether stdin and stderr are attached to a terminal
+func IsInteractive() bool {
+	for _, f := range []*os.File{os.Stdin, os.Stderr} {
+		fi, err := f.Stat()
+		if err != nil || fi.Mode()&os.ModeCharDevice == 0 {
+			return false
+		}
+	}
+	return true
+}
+
+// lineResult is the outcome of reading a line from stdin
+type lineResult struct {
+	line string
+	err  error
+}
+
+var (
+	// stdin is shared by every prompt, so input read ahead of one answer,
+	// e.g. pasted lines, is still there for the next question
+	stdin = bufio.NewReader(os.Stdin)
+
+	// pending is a read that was given up on when its context was cancelled.
+	// A blocked read can't be interrupted, so it stays pending until a line
+	// arrives; the next readLine takes that line instead of starting a
+	// second read.
+	pending chan lineResult
+)
+
+// readLine reads one line from stdin, giving up when ctx is cancelled.
+// Questions are asked one at a time, so it isn't safe for concurrent use.
+func readLine(ctx context.Context) (string, error) {
+	ch := pending
+	pending = nil
+	if ch == nil {
+		ch = make(chan lineResult, 1)
+		go func() {
+			line, err := stdin.ReadString('\n')
+			ch <- lineResult{strings.TrimSpace(line), err}
+		}()
+	}
+
+	select {
+	case r := <-ch:
+		if r.err != nil && r.line == "" {
+			return "", r.err
+		}
+		return r.line, nil
+	case <-ctx.Done():
+		pending = ch
+		fmt.Fprintln(os.Stderr)
+		return "", context.Cause(ctx)
+	}
+}
+
+// Confirm asks a yes/no question on stderr. Anything but "y" or "yes" is a no.
+func Confirm(ctx context.Context, question string) (bool, error) {
+	if !IsInteractive() {
+		return false, ErrNotInteractive
+	}
+
+	fmt.Fprintf(os.Stderr, "%s [y/N]: ", question)
+	line, err := readLine(ctx)
+	if err != nil {
+		return false, err
+	}
+	switch strings.ToLower(line) {
+	case "y", "yes":
+		return true, nil
+	}
+	return false, nil
+}
+
+// Input asks for a line of text on stderr. An empty answer returns def.
+func Input(ctx context.Context, question, def string) (string, error) {
+	if !IsInteractive() {
+		return "", ErrNotInteractive
+	}
+
+	if def != "" {
+		fmt.Fprintf(os.Stderr, "%s [%s]: ", question, def)
+	} else {
+		fmt.Fprintf(os.Stderr, "%s: ", question)
+	}
+	line, err := readLine(ctx)
+	if err != nil {
+		return "", err
+	}
+	if line == "" {
+		return def, nil
+	}
+	return line, nil
+}
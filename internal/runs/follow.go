@@ -0,0 +1,100 @@
+package runs
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"time"
+
+	"cli-tools/internal/auth"
+)
+
+// clearScreen moves the cursor home and clears the terminal
+const clearScreen = "\x1b[H\x1b[2J"
+
+// Follow refreshes a workflow run every interval until it completes and
+// returns its final state. On a terminal the run is redrawn in place;
+// otherwise it is printed once, then only job and step transitions are.
+func Follow(ctx context.Context, w io.Writer, ownerRepo string, runID int64, interval time.Duration, terminal bool) (*auth.WorkflowRun, error) {
+	var run *auth.WorkflowRun
+	var prev map[string]string
+	var fetchErr error
+
+	for {
+		latest, err := auth.GetRun(ctx, ownerRepo, runID)
+		var jobs []auth.Job
+		if err == nil {
+			jobs, err = auth.ListJobs(ctx, ownerRepo, runID)
+		}
+		switch {
+		case ctx.Err() != nil:
+			return run, context.Cause(ctx)
+		case err != nil && run == nil:
+			return nil, err
+		case err != nil:
+			// Keep showing the last state through network hiccups
+			fetchErr = err
+		default:
+			run, fetchErr = latest, nil
+		}
+		done := run.Status == "completed"
+
+		if fetchErr == nil {
+			states := make(map[string]string)
+			var changes []string
+			for _, job := range jobs {
+				changes = track(states, prev, job.Name, Mark(job.Status, job.Conclusion), changes)
+				for _, step := range job.Steps {
+					changes = track(states, prev, job.Name+" / "+step.Name, Mark(step.Status, step.Conclusion), changes)
+				}
+			}
+
+			if terminal {
+				var buf bytes.Buffer
+				buf.WriteString(clearScreen)
+				PrintRun(&buf, run, jobs)
+				fmt.Fprintln(&buf)
+				if !done {
+					fmt.Fprintf(&buf, "Updated %s, refreshing every %s. Press Ctrl-C to stop.\n", time.Now().Format("15:04:05"), interval)
+				}
+				w.Write(buf.Bytes())
+			} else if prev == nil {
+				PrintRun(w, run, jobs)
+				fmt.Fprintln(w)
+			} else {
+				for _, c := range changes {
+					fmt.Fprintf(w, "%s  %s\n", time.Now().Format("15:04:05"), c)
+				}
+			}
+			prev = states
+		} else if terminal {
+			fmt.Fprintf(w, "Refresh failed: %v\n", fetchErr)
+		}
+
+		if done {
+			return run, nil
+		}
+
+		select {
+		case <-ctx.Done():
+			return run, context.Cause(ctx)
+		case <-time.After(interval):
+		}
+	}
+}
+
+// track records the mark of a job or step in states and appends a line to
+// changes if it differs from prev. Nothing is reported on the first pass.
+func track(states, prev map[string]string, name, mark string, changes []string) []string {
+	states[name] = mark
+	if prev == nil {
+		return changes
+	}
+	if old, seen := prev[name]; !seen {
+		return append(changes, fmt.Sprintf("%s: %s", name, mark))
+	} else if old != mark {
+		return append(changes, fmt.Sprintf("%s: %s -> %s", name, old, mark))
+	}
+	return changes
+}
@@ -0,0 +1,40 @@
+package runs
+
+import (
+	"context"
+	"fmt"
+
+	"cli-tools/internal/auth"
+	"cli-tools/internal/git"
+)
+
+// ForHead returns the runs for the commit checked out, newest first, and
+// the commit's SHA
+func ForHead(ctx context.Context, repo string) ([]auth.WorkflowRun, string, error) {
+	sha, err := git.ResolveCommit(ctx, "HEAD")
+	if err != nil {
+		return nil, "", err
+	}
+	list, err := auth.ListRuns(ctx, repo, auth.RunFilter{HeadSHA: sha, Limit: 100})
+	if err != nil {
+		return nil, "", err
+	}
+	return list, sha, nil
+}
+
+// Failed reports whether a completed run or job failed, as opposed to
+// passing or being cancelled
+func Failed(conclusion string) bool {
+	switch conclusion {
+	case "failure", "timed_out", "startup_failure":
+		return true
+	default:
+		return false
+	}
+}
+
+// Describe returns a short description of a run for messages, e.g.
+// "run 123 (CI)"
+func Describe(run *auth.WorkflowRun) string {
+	return fmt.Sprintf("run %d (%s)", run.ID, run.Name)
+}
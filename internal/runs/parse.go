@@ -0,0 +1,28 @@
+package runs
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"strconv"
+
+	"cli-tools/internal/github"
+)
+
+// runURLRegex matches the URL of a workflow run
+var runURLRegex = regexp.MustCompile(`^https?://[^/]+/([^/]+/[^/]+)/actions/runs/(\d+)`)
+
+// Parse returns the repository and ID of a run given as an ID on origin or
+// as a run URL
+func Parse(ctx context.Context, arg string) (string, int64, error) {
+	if m := runURLRegex.FindStringSubmatch(arg); m != nil {
+		id, err := strconv.ParseInt(m[2], 10, 64)
+		return m[1], id, err
+	}
+	id, err := strconv.ParseInt(arg, 10, 64)
+	if err != nil || id <= 0 {
+		return "", 0, fmt.Errorf("invalid run ID: %s", arg)
+	}
+	repo, err := github.GetOwnerRepo(ctx)
+	return repo, id, err
+}
@@ -0,0 +1,138 @@
+// Package runs shows GitHub Actions workflow runs in the terminal
+package runs
+
+import (
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"cli-tools/internal/auth"
+)
+
+// Mark returns a short mark for the state of a run, job or step:
+// "[OK]", "[FAIL]", "[...]" while it is still going, and so on
+func Mark(status, conclusion string) string {
+	if status != "completed" {
+		return "[...]"
+	}
+	switch conclusion {
+	case "success", "neutral":
+		return "[OK]"
+	case "failure", "startup_failure":
+		return "[FAIL]"
+	case "cancelled":
+		return "[CANCEL]"
+	case "skipped":
+		return "[SKIP]"
+	case "timed_out":
+		return "[TIMEOUT]"
+	default:
+		return "[" + strings.ToUpper(conclusion) + "]"
+	}
+}
+
+// Passed reports whether a completed run or job counts as passed
+func Passed(conclusion string) bool {
+	switch conclusion {
+	case "success", "neutral", "skipped":
+		return true
+	default:
+		return false
+	}
+}
+
+// Duration returns the time between two API timestamps, rounded to the
+// second. A missing end means the thing is still running. It is empty
+// if start is missing.
+func Duration(start, end string, now time.Time) string {
+	s, err := time.Parse(time.RFC3339, start)
+	if err != nil {
+		return ""
+	}
+	e, err := time.Parse(time.RFC3339, end)
+	if err != nil || e.Before(s) {
+		e = now
+	}
+	return e.Sub(s).Round(time.Second).String()
+}
+
+// RunDuration returns how long a run took, or has been running
+func RunDuration(run *auth.WorkflowRun, now time.Time) string {
+	start := run.RunStartedAt
+	if start == "" {
+		start = run.CreatedAt
+	}
+	if run.Status != "completed" {
+		return Duration(start, "", now)
+	}
+	return Duration(start, run.UpdatedAt, now)
+}
+
+// Age returns how long ago an API timestamp was, e.g. "5m ago"
+func Age(t string, now time.Time) string {
+	ts, err := time.Parse(time.RFC3339, t)
+	if err != nil {
+		return ""
+	}
+	d := now.Sub(ts)
+	switch {
+	case d < time.Minute:
+		return "just now"
+	case d < time.Hour:
+		return fmt.Sprintf("%dm ago", int(d.Minutes()))
+	case d < 24*time.Hour:
+		return fmt.Sprintf("%dh ago", int(d.Hours()))
+	default:
+		return fmt.Sprintf("%dd ago", int(d.Hours()/24))
+	}
+}
+
+// ShortSHA abbreviates a commit SHA
+func ShortSHA(sha string) string {
+	if len(sha) > 7 {
+		return sha[:7]
+	}
+	return sha
+}
+
+// PrintRun writes a run with its jobs and their steps
+func PrintRun(w io.Writer, run *auth.WorkflowRun, jobs []auth.Job) {
+	now := time.Now()
+	title := fmt.Sprintf("Run %d", run.ID)
+	if run.RunAttempt > 1 {
+		title += fmt.Sprintf(" (attempt %d)", run.RunAttempt)
+	}
+	fmt.Fprintf(w, "%s: %s - %s\n", title, run.Name, run.DisplayTitle)
+
+	state := run.Status
+	if run.Status == "completed" {
+		state = run.Conclusion
+	}
+	fmt.Fprintf(w, "Status: %s %s\n", Mark(run.Status, run.Conclusion), strings.ReplaceAll(state, "_", " "))
+	fmt.Fprintf(w, "Trigger: %s by @%s on %s (%s)\n", run.Event, run.Actor.Login, run.HeadBranch, ShortSHA(run.HeadSHA))
+	if d := RunDuration(run, now); d != "" {
+		fmt.Fprintf(w, "Duration: %s\n", d)
+	}
+	fmt.Fprintf(w, "URL: %s\n", run.HTMLURL)
+
+	if len(jobs) == 0 {
+		return
+	}
+	fmt.Fprintln(w)
+	fmt.Fprintln(w, "Jobs:")
+	for _, job := range jobs {
+		line := fmt.Sprintf("  %-9s %s", Mark(job.Status, job.Conclusion), job.Name)
+		if d := Duration(job.StartedAt, job.CompletedAt, now); d != "" && job.Status != "queued" {
+			line += "  " + d
+		}
+		fmt.Fprintln(w, line)
+		for _, step := range job.Steps {
+			line := fmt.Sprintf("      %-9s %s", Mark(step.Status, step.Conclusion), step.Name)
+			if d := Duration(step.StartedAt, step.CompletedAt, now); d != "" && step.Status != "queued" {
+				line += "  " + d
+			}
+			fmt.Fprintln(w, line)
+		}
+	}
+}
@@ -0,0 +1,170 @@
+package trace
+
+import (
+	"errors"
+	"io"
+	"log/slog"
+	"net/http"
+	"os"
+	"os/exec"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// logger is nil while tracing is disabled
+var logger *slog.Logger
+
+// Enable turns on tracing of subprocess and HTTP calls.
+// Output is human-readable text, or slog JSON when asJSON is set.
+func Enable(w io.Writer, asJSON bool) {
+	opts := &slog.HandlerOptions{Level: slog.LevelDebug}
+	if asJSON {
+		logger = slog.New(slog.NewJSONHandler(w, opts))
+	} else {
+		logger = slog.New(slog.NewTextHandler(w, opts))
+	}
+}
+
+// EnableFromEnv turns on tracing when CLI_TOOLS_DEBUG is set to a true value.
+// If CLI_TOOLS_DEBUG_FILE is also set, JSON logs are appended to that file
+// instead of being written to stderr.
+func EnableFromEnv() error {
+	switch strings.ToLower(os.Getenv("CLI_TOOLS_DEBUG")) {
+	case "", "0", "false", "no", "off":
+		return nil
+	}
+	return EnableTo(os.Getenv("CLI_TOOLS_DEBUG_FILE"))
+}
+
+// EnableTo turns on tracing, writing JSON to path or text to stderr if path is empty
+func EnableTo(path string) error {
+	if path == "" {
+		Enable(os.Stderr, false)
+		return nil
+	}
+
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o600)
+	if err != nil {
+		return err
+	}
+	Enable(f, true)
+	return nil
+}
+
+// Enabled reports whether tracing is turned on
+func Enabled() bool {
+	return logger != nil
+}
+
+// Run runs cmd like cmd.Run, logging the invocation when tracing is enabled
+func Run(cmd *exec.Cmd) error {
+	start := time.Now()
+	err := cmd.Run()
+	logExec(cmd, start, err)
+	return err
+}
+
+// Output runs cmd like cmd.Output, logging the invocation when tracing is enabled
+func Output(cmd *exec.Cmd) ([]byte, error) {
+	start := time.Now()
+	out, err := cmd.Output()
+	logExec(cmd, start, err)
+	return out, err
+}
+
+// Wait waits for cmd, started at start with cmd.Start, like cmd.Wait,
+// logging the invocation when tracing is enabled
+func Wait(cmd *exec.Cmd, start time.Time) error {
+	err := cmd.Wait()
+	logExec(cmd, start, err)
+	return err
+}
+
+func logExec(cmd *exec.Cmd, start time.Time, err error) {
+	if logger == nil {
+		return
+	}
+
+	args := make([]string, len(cmd.Args))
+	for i, arg := range cmd.Args {
+		args[i] = Redact(arg)
+	}
+
+	attrs := []any{
+		slog.String("cmd", strings.Join(args, " ")),
+		slog.Duration("duration", time.Since(start)),
+	}
+	if cmd.Dir != "" {
+		attrs = append(attrs, slog.String("dir", cmd.Dir))
+	}
+	if cmd.ProcessState != nil {
+		attrs = append(attrs, slog.Int("exit", cmd.ProcessState.ExitCode()))
+	}
+	if err != nil {
+		var exitErr *exec.ExitError
+		if errors.As(err, &exitErr) && len(exitErr.Stderr) > 0 {
+			attrs = append(attrs, slog.String("stderr", Redact(strings.TrimSpace(string(exitErr.Stderr)))))
+		} else {
+			attrs = append(attrs, slog.String("error", err.Error()))
+		}
+	}
+	logger.Debug("exec", attrs...)
+}
+
+// Transport is an http.RoundTripper that logs each request when tracing is enabled
+type Transport struct {
+	Base http.RoundTripper // defaults to http.DefaultTransport
+}
+
+// RoundTrip implements http.RoundTripper
+func (t *Transport) RoundTrip(req *http.Request) (*http.Response, error) {
+	base := t.Base
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	if logger == nil {
+		return base.RoundTrip(req)
+	}
+
+	start := time.Now()
+	resp, err := base.RoundTrip(req)
+
+	attrs := []any{
+		slog.String("method", req.Method),
+		slog.String("url", Redact(req.URL.String())),
+		slog.Duration("duration", time.Since(start)),
+	}
+	if err != nil {
+		attrs = append(attrs, slog.String("error", err.Error()))
+		logger.Debug("http", attrs...)
+		return resp, err
+	}
+
+	attrs = append(attrs, slog.Int("status", resp.StatusCode))
+	for _, h := range []string{"X-RateLimit-Limit", "X-RateLimit-Remaining", "X-RateLimit-Reset", "X-RateLimit-Resource"} {
+		if v := resp.Header.Get(h); v != "" {
+			attrs = append(attrs, slog.String(strings.ToLower(strings.TrimPrefix(h, "X-")), v))
+		}
+	}
+	logger.Debug("http", attrs...)
+	return resp, nil
+}
+
+// tokenPattern matches GitHub token formats (classic, fine-grained, OAuth, app)
+var tokenPattern = regexp.MustCompile(`\b(gh[pousr]_[A-Za-z0-9]{20,}|github_pat_[A-Za-z0-9_]{20,})\b`)
+
+// credentialPattern matches credentials embedded in URLs and auth headers
+var credentialPattern = regexp.MustCompile(`(?i)(://[^/:@\s]+:)[^@\s]+@|((?:authorization|token|bearer)[:= ]+)\S+`)
+
+// Redact removes anything that looks like a token or credential from s
+func Redact(s string) string {
+	s = tokenPattern.ReplaceAllString(s, "[REDACTED]")
+	return credentialPattern.ReplaceAllStringFunc(s, func(m string) string {
+		sub := credentialPattern.FindStringSubmatch(m)
+		if sub[1] != "" {
+			return sub[1] + "[REDACTED]@"
+		}
+		return sub[2] + "[REDACTED]"
+	})
+}
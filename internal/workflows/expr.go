@@ -0,0 +1,253 @@
+package workflows
+
+import (
+	"fmt"
+	"strings"
+
+	"cli-tools/internal/yaml"
+)
+
+var (
+	// exprContexts are the names an expression can start a value with,
+	// besides literals
+	exprContexts = keys("github", "env", "vars", "job", "jobs", "steps", "runner", "secrets", "strategy",
+		"matrix", "needs", "inputs", "true", "false", "null", "nan", "infinity")
+	exprFunctions = keys("contains", "startswith", "endswith", "format", "join", "tojson", "fromjson",
+		"hashfiles", "success", "always", "cancelled", "failure")
+)
+
+// checkExpressions checks every ${{ }} expression in the workflow, and the
+// if: conditions, which are expressions even without ${{ }}
+func (l *linter) checkExpressions(n *yaml.Node) {
+	switch n.Kind {
+	case yaml.Mapping:
+		for _, p := range n.Pairs {
+			if p.Key == "if" && p.Value.Kind == yaml.Scalar && !strings.Contains(p.Value.Value, "${{") {
+				if msg := checkExpr(p.Value.Value); msg != "" {
+					l.add(p.Value.Line, "invalid if: %s", msg)
+				}
+				continue
+			}
+			l.checkExpressions(p.Value)
+		}
+	case yaml.Sequence:
+		for _, item := range n.Items {
+			l.checkExpressions(item)
+		}
+	case yaml.Scalar:
+		l.checkEmbedded(n)
+	}
+}
+
+// checkEmbedded checks the ${{ }} expressions in a scalar
+func (l *linter) checkEmbedded(n *yaml.Node) {
+	s := n.Value
+	offset := 0
+	for {
+		start := strings.Index(s[offset:], "${{")
+		if start < 0 {
+			return
+		}
+		start += offset
+		line := n.LineAt(start)
+
+		end := exprEnd(s, start+3)
+		if end < 0 {
+			l.add(line, "unclosed ${{ expression")
+			return
+		}
+		body := strings.TrimSpace(s[start+3 : end])
+		if body == "" {
+			l.add(line, "empty ${{ }} expression")
+		} else if msg := checkExpr(body); msg != "" {
+			l.add(line, "invalid expression ${{ %s }}: %s", body, msg)
+		}
+		offset = end + 2
+	}
+}
+
+// exprEnd returns the index of the }} closing an expression that starts at
+// i, skipping strings, or -1
+func exprEnd(s string, i int) int {
+	for ; i < len(s); i++ {
+		switch {
+		case s[i] == '\'':
+			for i++; i < len(s) && s[i] != '\''; i++ {
+			}
+		case strings.HasPrefix(s[i:], "}}"):
+			return i
+		}
+	}
+	return -1
+}
+
+// checkExpr checks the syntax of an expression and returns what is wrong
+// with it, or ""
+func checkExpr(expr string) string {
+	var stack []byte
+	operand := false // the last token completed a value
+	prev := ""       // the last token
+
+	for i := 0; i < len(expr); {
+		c := expr[i]
+		switch {
+		case c == ' ' || c == '\t' || c == '\n':
+			i++
+			continue
+
+		case c == '\'':
+			j := i + 1
+			for ; j < len(expr); j++ {
+				if expr[j] == '\'' {
+					if j+1 < len(expr) && expr[j+1] == '\'' {
+						j++
+						continue
+					}
+					break
+				}
+			}
+			if j >= len(expr) {
+				return "unterminated string"
+			}
+			if operand {
+				return "missing operator before " + expr[i:j+1]
+			}
+			operand, prev = true, "string"
+			i = j + 1
+			continue
+
+		case c == '"':
+			return "strings in expressions use single quotes"
+
+		case isDigit(c) || c == '-' && i+1 < len(expr) && isDigit(expr[i+1]) && !operand:
+			j := i + 1
+			for j < len(expr) && (isIdent(expr[j]) || expr[j] == '.') {
+				j++
+			}
+			if operand {
+				return "missing operator before " + expr[i:j]
+			}
+			operand, prev = true, "number"
+			i = j
+			continue
+
+		case isIdentStart(c):
+			j := i + 1
+			for j < len(expr) && isIdent(expr[j]) {
+				j++
+			}
+			name := expr[i:j]
+			i = j
+			if prev == "." {
+				operand, prev = true, "property"
+				continue
+			}
+			if operand {
+				return "missing operator before " + name
+			}
+			rest := strings.TrimLeft(expr[i:], " ")
+			switch {
+			case strings.HasPrefix(rest, "("):
+				if !exprFunctions[strings.ToLower(name)] {
+					return "unknown function " + name
+				}
+				prev = "function"
+			case !exprContexts[strings.ToLower(name)]:
+				return "unknown context " + name
+			default:
+				operand, prev = true, "name"
+			}
+			continue
+		}
+
+		// Punctuation and operators
+		tok := string(c)
+		if i+1 < len(expr) {
+			switch two := expr[i : i+2]; two {
+			case "==", "!=", "<=", ">=", "&&", "||":
+				tok = two
+			}
+		}
+		i += len(tok)
+
+		switch tok {
+		case "(":
+			if operand {
+				return "unexpected ("
+			}
+			stack = append(stack, '(')
+		case ")":
+			if len(stack) == 0 || stack[len(stack)-1] != '(' {
+				return "unbalanced )"
+			}
+			if !operand && prev != "(" {
+				return "missing value before )"
+			}
+			stack = stack[:len(stack)-1]
+			operand = true
+		case "[":
+			if !operand {
+				return "unexpected ["
+			}
+			stack = append(stack, '[')
+			operand = false
+		case "]":
+			if len(stack) == 0 || stack[len(stack)-1] != '[' {
+				return "unbalanced ]"
+			}
+			if !operand {
+				return "missing index before ]"
+			}
+			stack = stack[:len(stack)-1]
+		case ".":
+			if !operand {
+				return "unexpected ."
+			}
+			operand = false
+		case "*":
+			if prev != "." {
+				return "unexpected *"
+			}
+			operand = true
+		case ",":
+			if !operand || len(stack) == 0 || stack[len(stack)-1] != '(' {
+				return "unexpected ,"
+			}
+			operand = false
+		case "!":
+			if operand {
+				return "unexpected !"
+			}
+		case "==", "!=", "<=", ">=", "&&", "||", "<", ">":
+			if !operand {
+				return "missing value before " + tok
+			}
+			operand = false
+		case "&", "|", "=":
+			return fmt.Sprintf("unknown operator %s (did you mean %s%s?)", tok, tok, tok)
+		default:
+			return "unexpected " + tok
+		}
+		prev = tok
+	}
+
+	if len(stack) > 0 {
+		return fmt.Sprintf("unclosed %c", stack[len(stack)-1])
+	}
+	if !operand {
+		return "expression ends without a value"
+	}
+	return ""
+}
+
+func isDigit(c byte) bool {
+	return c >= '0' && c <= '9'
+}
+
+func isIdentStart(c byte) bool {
+	return c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c == '_'
+}
+
+func isIdent(c byte) bool {
+	return isIdentStart(c) || isDigit(c) || c == '-'
+}
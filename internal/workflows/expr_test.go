@@ -0,0 +1,39 @@
+package workflows
+
+import "testing"
+
+func TestCheckExpr(t *testing.T) {
+	tests := []struct {
+		expr string
+		want string
+	}{
+		{"github.ref == 'refs/heads/main'", ""},
+		{"success() && !cancelled()", ""},
+		{"contains(github.event.pull_request.labels.*.name, 'deploy')", ""},
+		{"fromJSON(needs.setup.outputs.matrix)[0]", ""},
+		{"matrix.os != 'windows-latest' || (inputs.force && 1 >= 0.5)", ""},
+		{"steps.build.outputs['artifact-name']", ""},
+		{"format('{0} it''s', env.NAME)", ""},
+		{"github.event_name == \"push\"", "strings in expressions use single quotes"},
+		{"'unterminated", "unterminated string"},
+		{"github.ref 'main'", "missing operator before 'main'"},
+		{"github.ref matrix.os", "missing operator before matrix"},
+		{"gihtub.ref", "unknown context gihtub"},
+		{"startsWit(github.ref, 'v')", "unknown function startsWit"},
+		{"(github.ref", "unclosed ("},
+		{"github.ref)", "unbalanced )"},
+		{"success(", "unclosed ("},
+		{"github.ref(", "unexpected ("},
+		{"github.ref = 'x'", "unknown operator = (did you mean ==?)"},
+		{"github.", "expression ends without a value"},
+		{"github.ref ==", "expression ends without a value"},
+		{"== github.ref", "missing value before =="},
+		{"github..ref", "unexpected ."},
+		{"steps[]", "missing index before ]"},
+	}
+	for _, tt := range tests {
+		if got := checkExpr(tt.expr); got != tt.want {
+			t.Errorf("checkExpr(%q) = %q, want %q", tt.expr, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,512 @@
+package workflows
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+
+	"cli-tools/internal/yaml"
+)
+
+// Problem is something wrong with a workflow file
+type Problem struct {
+	Line int
+	Msg  string
+}
+
+// keySet is the set of keys allowed in a mapping
+type keySet map[string]bool
+
+func keys(names ...string) keySet {
+	set := make(keySet)
+	for _, name := range names {
+		set[name] = true
+	}
+	return set
+}
+
+var (
+	workflowKeys = keys("name", "run-name", "on", "permissions", "env", "defaults", "concurrency", "jobs")
+	jobKeys      = keys("name", "permissions", "needs", "if", "runs-on", "environment", "concurrency", "outputs",
+		"env", "defaults", "steps", "timeout-minutes", "strategy", "continue-on-error", "container", "services")
+	reusableJobKeys = keys("name", "uses", "with", "secrets", "needs", "if", "permissions", "strategy", "concurrency")
+	stepKeys        = keys("id", "if", "name", "uses", "run", "shell", "with", "env", "continue-on-error",
+		"timeout-minutes", "working-directory")
+	strategyKeys = keys("matrix", "fail-fast", "max-parallel")
+	filterKeys   = keys("branches", "branches-ignore", "tags", "tags-ignore", "paths", "paths-ignore", "types")
+	inputKeys    = keys("description", "required", "default", "type", "options")
+	callKeys     = keys("inputs", "outputs", "secrets")
+
+	// events are the events a workflow can be triggered by
+	events = keys("branch_protection_rule", "check_run", "check_suite", "create", "delete", "deployment",
+		"deployment_status", "discussion", "discussion_comment", "fork", "gollum", "issue_comment", "issues",
+		"label", "merge_group", "milestone", "page_build", "public", "pull_request", "pull_request_review",
+		"pull_request_review_comment", "pull_request_target", "push", "registry_package", "release",
+		"repository_dispatch", "schedule", "status", "watch", "workflow_call", "workflow_dispatch", "workflow_run")
+	inputTypes = keys("string", "boolean", "number", "choice", "environment")
+)
+
+// jobIDRegex matches valid job and step IDs
+var jobIDRegex = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_-]*$`)
+
+// linter collects the problems of one workflow
+type linter struct {
+	problems []Problem
+	seen     map[Problem]bool
+}
+
+// add records a problem. An aliased node is checked everywhere it is used,
+// so a problem in it is only recorded once.
+func (l *linter) add(line int, format string, args ...any) {
+	p := Problem{line, fmt.Sprintf(format, args...)}
+	if l.seen[p] {
+		return
+	}
+	l.seen[p] = true
+	l.problems = append(l.problems, p)
+}
+
+// Lint checks a parsed workflow against the workflow syntax: known keys,
+// triggers, jobs and their needs, steps, matrices and ${{ }} expressions.
+// Problems are sorted by line.
+func Lint(root *yaml.Node) []Problem {
+	l := &linter{seen: make(map[Problem]bool)}
+	if root.Kind != yaml.Mapping {
+		l.add(root.Line, "a workflow must be a mapping")
+		return l.problems
+	}
+
+	l.checkKeys(root, workflowKeys, "workflow")
+	if on := root.Get("on"); on == nil {
+		l.add(root.Line, "missing on: the workflow has no triggers")
+	} else {
+		l.checkTriggers(on)
+	}
+	if jobs := root.Get("jobs"); jobs == nil {
+		l.add(root.Line, "missing jobs")
+	} else {
+		l.checkJobs(jobs)
+	}
+	l.checkExpressions(root)
+
+	sort.SliceStable(l.problems, func(i, j int) bool { return l.problems[i].Line < l.problems[j].Line })
+	return l.problems
+}
+
+// checkKeys reports duplicate keys and keys not in allowed
+func (l *linter) checkKeys(n *yaml.Node, allowed keySet, where string) {
+	seen := make(map[string]bool)
+	for _, p := range n.Pairs {
+		if seen[p.Key] {
+			l.add(p.Line, "duplicate key %s in %s", p.Key, where)
+		}
+		seen[p.Key] = true
+		if allowed != nil && !allowed[p.Key] {
+			l.add(p.Line, "unknown key %s in %s", p.Key, where)
+		}
+	}
+}
+
+// expect reports n if it isn't one of kinds, and returns whether it is
+func (l *linter) expect(n *yaml.Node, what string, kinds ...yaml.Kind) bool {
+	for _, k := range kinds {
+		if n.Kind == k {
+			return true
+		}
+	}
+	names := make([]string, len(kinds))
+	for i, k := range kinds {
+		names[i] = "a " + k.String()
+	}
+	l.add(n.Line, "%s must be %s, not %s", what, strings.Join(names, " or "), describe(n))
+	return false
+}
+
+// describe names the kind of a node for messages
+func describe(n *yaml.Node) string {
+	if n.Kind == yaml.Null {
+		return "empty"
+	}
+	return "a " + n.Kind.String()
+}
+
+// isExpression reports whether n is a scalar holding a ${{ }} expression,
+// which may stand in for any value
+func isExpression(n *yaml.Node) bool {
+	return n.Kind == yaml.Scalar && strings.Contains(n.Value, "${{")
+}
+
+func (l *linter) checkTriggers(on *yaml.Node) {
+	switch on.Kind {
+	case yaml.Scalar:
+		l.checkEvent(on.Value, on.Line)
+	case yaml.Sequence:
+		for _, item := range on.Items {
+			if l.expect(item, "event", yaml.Scalar) {
+				l.checkEvent(item.Value, item.Line)
+			}
+		}
+	case yaml.Mapping:
+		l.checkKeys(on, nil, "on")
+		for _, p := range on.Pairs {
+			if l.checkEvent(p.Key, p.Line) {
+				l.checkTrigger(p.Key, p.Value)
+			}
+		}
+	default:
+		l.add(on.Line, "on must list at least one event")
+	}
+}
+
+// checkEvent reports unknown events and returns whether event is known
+func (l *linter) checkEvent(event string, line int) bool {
+	if !events[event] {
+		l.add(line, "unknown event %s", event)
+		return false
+	}
+	return true
+}
+
+// checkTrigger checks the configuration of one event
+func (l *linter) checkTrigger(event string, n *yaml.Node) {
+	where := "on." + event
+	switch event {
+	case "push", "pull_request", "pull_request_target":
+		if n.Kind == yaml.Null || !l.expect(n, where, yaml.Mapping) {
+			return
+		}
+		l.checkKeys(n, filterKeys, where)
+		for _, pair := range [][2]string{{"branches", "branches-ignore"}, {"tags", "tags-ignore"}, {"paths", "paths-ignore"}} {
+			if n.Get(pair[0]) != nil && n.Get(pair[1]) != nil {
+				l.add(n.Line, "%s can't have both %s and %s", where, pair[0], pair[1])
+			}
+		}
+		for _, p := range n.Pairs {
+			if filterKeys[p.Key] {
+				l.expect(p.Value, where+"."+p.Key, yaml.Sequence, yaml.Scalar)
+			}
+		}
+	case "schedule":
+		if !l.expect(n, where, yaml.Sequence) {
+			return
+		}
+		for _, item := range n.Items {
+			if !l.expect(item, "schedule entry", yaml.Mapping) {
+				continue
+			}
+			l.checkKeys(item, keys("cron"), "schedule entry")
+			cron := item.Get("cron")
+			switch {
+			case cron == nil:
+				l.add(item.Line, "schedule entry needs a cron expression")
+			case len(strings.Fields(cron.String())) != 5:
+				l.add(cron.Line, "cron expression %q must have 5 fields", cron.String())
+			}
+		}
+	case "workflow_dispatch":
+		if n.Kind == yaml.Null || !l.expect(n, where, yaml.Mapping) {
+			return
+		}
+		l.checkKeys(n, keys("inputs"), where)
+		if inputs := n.Get("inputs"); inputs != nil && l.expect(inputs, where+".inputs", yaml.Mapping) {
+			for _, p := range inputs.Pairs {
+				l.checkInput(p, inputTypes)
+			}
+		}
+	case "workflow_call":
+		if n.Kind == yaml.Null || !l.expect(n, where, yaml.Mapping) {
+			return
+		}
+		l.checkKeys(n, callKeys, where)
+		if inputs := n.Get("inputs"); inputs != nil && l.expect(inputs, where+".inputs", yaml.Mapping) {
+			for _, p := range inputs.Pairs {
+				l.checkInput(p, keys("string", "boolean", "number"))
+			}
+		}
+	case "workflow_run":
+		if l.expect(n, where, yaml.Mapping) && n.Get("workflows") == nil {
+			l.add(n.Line, "on.workflow_run needs workflows")
+		}
+	}
+}
+
+// checkInput checks an input of workflow_dispatch or workflow_call
+func (l *linter) checkInput(p yaml.Pair, types keySet) {
+	where := "input " + p.Key
+	if p.Value.Kind == yaml.Null {
+		return
+	}
+	if !l.expect(p.Value, where, yaml.Mapping) {
+		return
+	}
+	in := p.Value
+	l.checkKeys(in, inputKeys, where)
+
+	typ := in.Get("type")
+	if typ == nil && types["choice"] {
+		return // workflow_dispatch inputs default to string
+	}
+	if typ == nil {
+		l.add(p.Line, "%s needs a type", where)
+		return
+	}
+	if !types[typ.String()] {
+		l.add(typ.Line, "%s has unknown type %s", where, typ.String())
+		return
+	}
+
+	def := in.Get("default")
+	options := in.Get("options")
+	switch typ.String() {
+	case "choice":
+		if options == nil || options.Kind != yaml.Sequence || len(options.Items) == 0 {
+			l.add(typ.Line, "%s is a choice but has no options", where)
+			return
+		}
+		if def != nil && def.Kind == yaml.Scalar {
+			found := false
+			for _, o := range options.Items {
+				found = found || o.String() == def.Value
+			}
+			if !found {
+				l.add(def.Line, "default %q of %s is not one of its options", def.Value, where)
+			}
+		}
+	case "boolean":
+		if def != nil && def.Kind == yaml.Scalar && !isExpression(def) {
+			if _, ok := def.Bool(); !ok {
+				l.add(def.Line, "default of %s must be true or false", where)
+			}
+		}
+	}
+	if options != nil && typ.String() != "choice" {
+		l.add(options.Line, "options only apply to choice inputs")
+	}
+}
+
+func (l *linter) checkJobs(jobs *yaml.Node) {
+	if !l.expect(jobs, "jobs", yaml.Mapping) {
+		return
+	}
+	if len(jobs.Pairs) == 0 {
+		l.add(jobs.Line, "jobs must define at least one job")
+		return
+	}
+	l.checkKeys(jobs, nil, "jobs")
+
+	needs := make(map[string][]string)
+	lines := make(map[string]int)
+	for _, p := range jobs.Pairs {
+		if !jobIDRegex.MatchString(p.Key) {
+			l.add(p.Line, "invalid job ID %s: use letters, digits, - and _, starting with a letter or _", p.Key)
+		}
+		lines[p.Key] = p.Line
+		if !l.expect(p.Value, "job "+p.Key, yaml.Mapping) {
+			continue
+		}
+		needs[p.Key] = l.checkJob(p.Key, p.Line, p.Value, jobs)
+	}
+	l.checkCycles(jobs, needs, lines)
+}
+
+// checkJob checks the job declared at line and returns the jobs it needs
+func (l *linter) checkJob(id string, line int, job, jobs *yaml.Node) []string {
+	where := "job " + id
+	if job.Get("uses") != nil {
+		l.checkKeys(job, reusableJobKeys, where)
+	} else {
+		l.checkKeys(job, jobKeys, where)
+		if job.Get("runs-on") == nil {
+			l.add(line, "%s needs runs-on", where)
+		}
+		if steps := job.Get("steps"); steps == nil {
+			l.add(line, "%s needs steps", where)
+		} else {
+			l.checkSteps(where, steps)
+		}
+	}
+
+	if t := job.Get("timeout-minutes"); t != nil && !isExpression(t) && !isNumber(t) {
+		l.add(t.Line, "timeout-minutes of %s must be a number", where)
+	}
+	if strategy := job.Get("strategy"); strategy != nil {
+		l.checkStrategy(where, strategy)
+	}
+
+	var needed []string
+	if n := job.Get("needs"); n != nil {
+		var items []*yaml.Node
+		switch n.Kind {
+		case yaml.Scalar:
+			items = []*yaml.Node{n}
+		case yaml.Sequence:
+			items = n.Items
+		default:
+			l.expect(n, "needs of "+where, yaml.Scalar, yaml.Sequence)
+		}
+		for _, item := range items {
+			if !l.expect(item, "needs of "+where, yaml.Scalar) {
+				continue
+			}
+			switch {
+			case item.Value == id:
+				l.add(item.Line, "%s needs itself", where)
+			case jobs.Get(item.Value) == nil:
+				l.add(item.Line, "%s needs unknown job %s", where, item.Value)
+			default:
+				needed = append(needed, item.Value)
+			}
+		}
+	}
+	return needed
+}
+
+// checkCycles reports each cycle of jobs needing each other once
+func (l *linter) checkCycles(jobs *yaml.Node, needs map[string][]string, lines map[string]int) {
+	const (
+		unvisited = iota
+		visiting
+		done
+	)
+	state := make(map[string]int)
+	var stack []string
+
+	var visit func(id string)
+	visit = func(id string) {
+		state[id] = visiting
+		stack = append(stack, id)
+		for _, dep := range needs[id] {
+			switch state[dep] {
+			case visiting:
+				start := 0
+				for stack[start] != dep {
+					start++
+				}
+				cycle := append(append([]string{}, stack[start:]...), dep)
+				l.add(lines[dep], "jobs %s form a needs cycle", strings.Join(cycle, " -> "))
+			case unvisited:
+				visit(dep)
+			}
+		}
+		stack = stack[:len(stack)-1]
+		state[id] = done
+	}
+	for _, p := range jobs.Pairs {
+		if state[p.Key] == unvisited {
+			visit(p.Key)
+		}
+	}
+}
+
+func (l *linter) checkSteps(where string, steps *yaml.Node) {
+	if !l.expect(steps, "steps of "+where, yaml.Sequence) {
+		return
+	}
+	if len(steps.Items) == 0 {
+		l.add(steps.Line, "%s has no steps", where)
+	}
+
+	ids := make(map[string]bool)
+	for i, step := range steps.Items {
+		name := fmt.Sprintf("step %d of %s", i+1, where)
+		if !l.expect(step, name, yaml.Mapping) {
+			continue
+		}
+		l.checkKeys(step, stepKeys, name)
+
+		uses, run := step.Get("uses"), step.Get("run")
+		switch {
+		case uses == nil && run == nil:
+			l.add(step.Line, "%s needs uses or run", name)
+		case uses != nil && run != nil:
+			l.add(step.Line, "%s can't have both uses and run", name)
+		case uses != nil && step.Get("shell") != nil:
+			l.add(step.Get("shell").Line, "shell only applies to run steps")
+		case run != nil && step.Get("with") != nil:
+			l.add(step.Get("with").Line, "with only applies to uses steps")
+		}
+		if uses != nil && l.expect(uses, "uses of "+name, yaml.Scalar) && !isExpression(uses) {
+			if u := uses.Value; !strings.HasPrefix(u, "./") && !strings.HasPrefix(u, "docker://") && !strings.Contains(u, "@") {
+				l.add(uses.Line, "uses %s needs a version, e.g. %s@v1", u, u)
+			}
+		}
+		if id := step.Get("id"); id != nil {
+			switch {
+			case !jobIDRegex.MatchString(id.String()):
+				l.add(id.Line, "invalid step ID %s", id.String())
+			case ids[id.String()]:
+				l.add(id.Line, "duplicate step ID %s in %s", id.String(), where)
+			}
+			ids[id.String()] = true
+		}
+	}
+}
+
+func (l *linter) checkStrategy(where string, strategy *yaml.Node) {
+	if !l.expect(strategy, "strategy of "+where, yaml.Mapping) {
+		return
+	}
+	l.checkKeys(strategy, strategyKeys, "strategy of "+where)
+
+	matrix := strategy.Get("matrix")
+	if matrix == nil || isExpression(matrix) {
+		return
+	}
+	if !l.expect(matrix, "matrix of "+where, yaml.Mapping) {
+		return
+	}
+
+	dims := make(map[string]bool)
+	for _, p := range matrix.Pairs {
+		if p.Key == "include" || p.Key == "exclude" {
+			continue
+		}
+		dims[p.Key] = true
+		switch {
+		case isExpression(p.Value):
+		case p.Value.Kind != yaml.Sequence:
+			l.add(p.Value.Line, "matrix values for %s must be a list, not %s", p.Key, describe(p.Value))
+		case len(p.Value.Items) == 0:
+			l.add(p.Value.Line, "matrix values for %s are empty", p.Key)
+		}
+	}
+
+	include, exclude := matrix.Get("include"), matrix.Get("exclude")
+	for _, list := range []struct {
+		name string
+		node *yaml.Node
+	}{{"include", include}, {"exclude", exclude}} {
+		if list.node == nil || isExpression(list.node) || !l.expect(list.node, "matrix "+list.name, yaml.Sequence) {
+			continue
+		}
+		for _, entry := range list.node.Items {
+			if isExpression(entry) || !l.expect(entry, "matrix "+list.name+" entry", yaml.Mapping) {
+				continue
+			}
+			if list.name == "exclude" {
+				for _, p := range entry.Pairs {
+					if !dims[p.Key] {
+						l.add(p.Line, "matrix exclude uses %s, which is not a matrix key", p.Key)
+					}
+				}
+			}
+		}
+	}
+	if len(dims) == 0 && (include == nil || include.Kind != yaml.Sequence || len(include.Items) == 0) {
+		l.add(matrix.Line, "matrix of %s has no values", where)
+	}
+}
+
+// isNumber reports whether n is a plain number
+func isNumber(n *yaml.Node) bool {
+	if n.Kind != yaml.Scalar || n.Quoted || n.Value == "" {
+		return false
+	}
+	for i, c := range n.Value {
+		if (c < '0' || c > '9') && c != '.' && (c != '-' || i > 0) {
+			return false
+		}
+	}
+	return true
+}
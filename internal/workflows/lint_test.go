@@ -0,0 +1,172 @@
+package workflows
+
+import (
+	"reflect"
+	"testing"
+
+	"cli-tools/internal/yaml"
+)
+
+func TestLint(t *testing.T) {
+	tests := []struct {
+		name string
+		src  string
+		want []Problem
+	}{
+		{"valid", `
+name: CI
+on:
+  push:
+    branches: [main]
+  schedule: [cron: "40 1 * * *"]
+jobs:
+  build:
+    runs-on: ubuntu-latest
+    steps:
+      - uses: actions/checkout@v4
+      - run: go test ./...
+        if: github.event_name == 'push'
+  deploy:
+    needs: build
+    uses: ./.github/workflows/deploy.yml
+`, nil},
+		{"missing on and jobs", "name: x\n", []Problem{
+			{1, "missing on: the workflow has no triggers"},
+			{1, "missing jobs"},
+		}},
+		{"not a mapping", "- a\n", []Problem{{1, "a workflow must be a mapping"}}},
+		{"unknown keys", `
+on: push
+job:
+  a: 1
+jobs:
+  a:
+    runs-on: x
+    step: []
+    steps: [run: x]
+`, []Problem{
+			{3, "unknown key job in workflow"},
+			{8, "unknown key step in job a"},
+		}},
+		{"events", `
+on: [push, pul_request]
+jobs:
+  a: {runs-on: x, steps: [run: x]}
+`, []Problem{{2, "unknown event pul_request"}}},
+		{"cron", `
+on:
+  schedule:
+    - cron: "* * *"
+jobs:
+  a: {runs-on: x, steps: [run: x]}
+`, []Problem{{4, `cron expression "* * *" must have 5 fields`}}},
+		{"needs", `
+on: push
+jobs:
+  a:
+    runs-on: x
+    needs: [a, c]
+    steps: [run: x]
+  b:
+    runs-on: x
+`, []Problem{
+			{6, "job a needs itself"},
+			{6, "job a needs unknown job c"},
+			{8, "job b needs steps"},
+		}},
+		{"steps", `
+on: push
+jobs:
+  a:
+    runs-on: x
+    steps:
+      - name: nothing
+      - uses: actions/checkout
+        run: x
+`, []Problem{
+			{7, "step 1 of job a needs uses or run"},
+			{8, "step 2 of job a can't have both uses and run"},
+			{8, "uses actions/checkout needs a version, e.g. actions/checkout@v1"},
+		}},
+		{"matrix", `
+on: push
+jobs:
+  a:
+    runs-on: x
+    strategy:
+      matrix:
+        os: linux
+        go: []
+        exclude:
+          - arch: arm
+    steps: [run: x]
+`, []Problem{
+			{8, "matrix values for os must be a list, not a scalar"},
+			{9, "matrix values for go are empty"},
+			{11, "matrix exclude uses arch, which is not a matrix key"},
+		}},
+		{"expressions", `
+on: push
+jobs:
+  a:
+    runs-on: ${{ matrix.os }}
+    if: github.ref = 'x'
+    steps:
+      - run: echo ${{ gihtub.sha }} ${{ }}
+      - run: echo ${{ github.sha
+`, []Problem{
+			{6, "invalid if: unknown operator = (did you mean ==?)"},
+			{8, "invalid expression ${{ gihtub.sha }}: unknown context gihtub"},
+			{8, "empty ${{ }} expression"},
+			{9, "unclosed ${{ expression"},
+		}},
+		{"expression lines", `
+on: push
+jobs:
+  a:
+    runs-on: x
+    steps:
+      - run: >-
+          echo one
+          ${{ github.sha) }}
+      - run: |
+          echo ${{ github.sha
+      - run: echo one
+          two ${{ }}
+      - run: "echo one
+
+          ${{ gihtub.sha }}"
+`, []Problem{
+			{9, "invalid expression ${{ github.sha) }}: unbalanced )"},
+			{11, "unclosed ${{ expression"},
+			{13, "empty ${{ }} expression"},
+			{16, "invalid expression ${{ gihtub.sha }}: unknown context gihtub"},
+		}},
+		{"aliased problems reported once", `
+on: push
+jobs:
+  a:
+    runs-on: x
+    steps:
+      - &step
+        run: echo ${{ github.sha) }}
+  b:
+    runs-on: x
+    steps:
+      - *step
+`, []Problem{
+			{8, "invalid expression ${{ github.sha) }}: unbalanced )"},
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			root, err := yaml.Parse([]byte(tt.src))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := Lint(root); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("got  %v\nwant %v", got, tt.want)
+			}
+		})
+	}
+}
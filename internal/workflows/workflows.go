@@ -0,0 +1,161 @@
+// Package workflows reads the GitHub Actions workflow files of a repository
+package workflows
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+
+	"cli-tools/internal/yaml"
+)
+
+// Dir is where workflow files live, relative to the repository root
+const Dir = ".github/workflows"
+
+// Workflow is a parsed workflow file
+type Workflow struct {
+	Path string // relative to the repository root
+	Name string // the name: key, or the file name if it has none
+	Root *yaml.Node
+}
+
+// File returns the workflow's file name, which the API accepts as its ID
+func (w *Workflow) File() string {
+	return filepath.Base(w.Path)
+}
+
+// Input is an input of a workflow_dispatch trigger
+type Input struct {
+	Name        string
+	Description string
+	Type        string // string, boolean, number, choice or environment
+	Required    bool
+	Default     string
+	Options     []string // for choice inputs
+}
+
+// List returns the paths of the workflow files under root, relative to it
+func List(root string) ([]string, error) {
+	entries, err := os.ReadDir(filepath.Join(root, Dir))
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var paths []string
+	for _, e := range entries {
+		ext := filepath.Ext(e.Name())
+		if !e.IsDir() && (ext == ".yml" || ext == ".yaml") {
+			paths = append(paths, filepath.ToSlash(filepath.Join(Dir, e.Name())))
+		}
+	}
+	sort.Strings(paths)
+	return paths, nil
+}
+
+// Load parses the workflow file at path, relative to root
+func Load(root, path string) (*Workflow, error) {
+	data, err := os.ReadFile(filepath.Join(root, path))
+	if err != nil {
+		return nil, err
+	}
+	node, err := yaml.Parse(data)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+
+	w := &Workflow{Path: path, Name: node.Get("name").String(), Root: node}
+	if w.Name == "" {
+		w.Name = filepath.Base(path)
+	}
+	return w, nil
+}
+
+// Triggers returns the node of each event the workflow runs on. Events
+// listed without configuration have a null node.
+func (w *Workflow) Triggers() map[string]*yaml.Node {
+	on := w.Root.Get("on")
+	triggers := make(map[string]*yaml.Node)
+	if on == nil {
+		return triggers
+	}
+	switch on.Kind {
+	case yaml.Scalar:
+		triggers[on.Value] = &yaml.Node{Kind: yaml.Null, Line: on.Line}
+	case yaml.Sequence:
+		for _, item := range on.Items {
+			triggers[item.String()] = &yaml.Node{Kind: yaml.Null, Line: item.Line}
+		}
+	case yaml.Mapping:
+		for _, p := range on.Pairs {
+			triggers[p.Key] = p.Value
+		}
+	}
+	return triggers
+}
+
+// DispatchInputs returns the inputs of the workflow's workflow_dispatch
+// trigger in file order. ok is false if it can't be triggered manually.
+func (w *Workflow) DispatchInputs() (inputs []Input, ok bool) {
+	dispatch, ok := w.Triggers()["workflow_dispatch"]
+	if !ok {
+		return nil, false
+	}
+
+	spec := dispatch.Get("inputs")
+	if spec == nil {
+		return nil, true
+	}
+	for _, p := range spec.Pairs {
+		in := Input{
+			Name:        p.Key,
+			Description: p.Value.Get("description").String(),
+			Type:        p.Value.Get("type").String(),
+			Default:     p.Value.Get("default").String(),
+		}
+		if in.Type == "" {
+			in.Type = "string"
+		}
+		in.Required, _ = p.Value.Get("required").Bool()
+		if options := p.Value.Get("options"); options != nil {
+			for _, o := range options.Items {
+				in.Options = append(in.Options, o.String())
+			}
+		}
+		inputs = append(inputs, in)
+	}
+	return inputs, true
+}
+
+// Validate checks that value suits the input's type
+func (in *Input) Validate(value string) error {
+	if value == "" {
+		if in.Required {
+			return fmt.Errorf("input %s is required", in.Name)
+		}
+		return nil
+	}
+	switch in.Type {
+	case "boolean":
+		if value != "true" && value != "false" {
+			return fmt.Errorf("input %s must be true or false, not %q", in.Name, value)
+		}
+	case "number":
+		if _, err := strconv.ParseFloat(value, 64); err != nil {
+			return fmt.Errorf("input %s must be a number, not %q", in.Name, value)
+		}
+	case "choice":
+		for _, o := range in.Options {
+			if value == o {
+				return nil
+			}
+		}
+		return fmt.Errorf("input %s must be one of %s, not %q", in.Name, strings.Join(in.Options, ", "), value)
+	}
+	return nil
+}
@@ -0,0 +1,911 @@
+// Package yaml parses the subset of YAML used by GitHub Actions workflow
+// files: block mappings and sequences, plain and quoted scalars, block
+// scalars (| and >), flow collections, and anchors and aliases. Every node
+// keeps the line it starts on so callers can point at problems. Tags,
+// explicit keys and multiple documents are not supported; they are reported
+// as an *UnsupportedError rather than a syntax error.
+package yaml
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Kind is the type of a node
+type Kind int
+
+const (
+	Null Kind = iota
+	Scalar
+	Mapping
+	Sequence
+)
+
+// String returns the name of the kind, as used in error messages
+func (k Kind) String() string {
+	switch k {
+	case Scalar:
+		return "scalar"
+	case Mapping:
+		return "mapping"
+	case Sequence:
+		return "sequence"
+	default:
+		return "null"
+	}
+}
+
+// Node is a parsed YAML value
+type Node struct {
+	Kind   Kind
+	Line   int    // 1-based line the value starts on
+	Value  string // Scalar
+	Quoted bool   // the scalar was quoted, so it is always a string
+	Pairs  []Pair // Mapping, in file order
+	Items  []*Node
+
+	lines []lineStart // where a scalar spanning several lines continues
+}
+
+// lineStart records that a scalar's value continues from line at offset
+type lineStart struct {
+	offset int
+	line   int
+}
+
+// Pair is a key and its value in a mapping
+type Pair struct {
+	Key   string
+	Line  int
+	Value *Node
+}
+
+// Error is a syntax error at a line
+type Error struct {
+	Line int
+	Msg  string
+}
+
+func (e *Error) Error() string {
+	return fmt.Sprintf("line %d: %s", e.Line, e.Msg)
+}
+
+// UnsupportedError is valid YAML that uses a feature this package doesn't
+// parse, such as tags
+type UnsupportedError struct {
+	Line    int
+	Feature string // e.g. "tags"
+}
+
+func (e *UnsupportedError) Error() string {
+	return fmt.Sprintf("line %d: %s are not supported", e.Line, e.Feature)
+}
+
+// Get returns the value of key in a mapping, or nil if n is not a mapping
+// or has no such key. With duplicate keys the last one wins.
+func (n *Node) Get(key string) *Node {
+	if n == nil || n.Kind != Mapping {
+		return nil
+	}
+	var value *Node
+	for _, p := range n.Pairs {
+		if p.Key == key {
+			value = p.Value
+		}
+	}
+	return value
+}
+
+// LineAt returns the line the byte at offset of a scalar's value comes
+// from. A block scalar's value starts on the line after its | or >, and
+// the space or newline a line break was folded into belongs to the line
+// before it.
+func (n *Node) LineAt(offset int) int {
+	line := n.Line
+	for _, s := range n.lines {
+		if s.offset > offset {
+			break
+		}
+		line = s.line
+	}
+	return line
+}
+
+// String returns the value of a scalar, or "" for anything else
+func (n *Node) String() string {
+	if n == nil || n.Kind != Scalar {
+		return ""
+	}
+	return n.Value
+}
+
+// Bool returns the value of a true/false scalar. ok is false for anything else.
+func (n *Node) Bool() (value, ok bool) {
+	if n == nil || n.Kind != Scalar || n.Quoted {
+		return false, false
+	}
+	switch n.Value {
+	case "true", "True", "TRUE":
+		return true, true
+	case "false", "False", "FALSE":
+		return false, true
+	}
+	return false, false
+}
+
+// line is one line of input
+type line struct {
+	num    int
+	indent int
+	text   string // without indentation, comment and trailing space; "" if blank
+	raw    string
+	tab    bool // indented with a tab, which is only allowed in block scalars
+}
+
+// parser reads nodes from lines
+type parser struct {
+	lines   []line
+	pos     int
+	anchors map[string]*Node // by name, as defined so far
+}
+
+// Parse parses a YAML document
+func Parse(data []byte) (*Node, error) {
+	p := &parser{anchors: make(map[string]*Node)}
+	// The newline ending the last line doesn't start another one
+	text := strings.TrimSuffix(strings.ReplaceAll(string(data), "\r\n", "\n"), "\n")
+	for i, raw := range strings.Split(text, "\n") {
+		trimmed := strings.TrimLeft(raw, " ")
+		indent := len(raw) - len(trimmed)
+		text := strings.TrimRight(stripComment(trimmed), " \t")
+		if indent == 0 && (text == "---" || text == "...") {
+			if p.hasContent() && text == "---" {
+				return nil, &UnsupportedError{i + 1, "multiple documents"}
+			}
+			text = ""
+		}
+		p.lines = append(p.lines, line{num: i + 1, indent: indent, text: text, raw: raw, tab: strings.HasPrefix(trimmed, "\t")})
+	}
+
+	node, err := p.parseBlock(0)
+	if err != nil {
+		return nil, err
+	}
+	if l := p.next(); l != nil {
+		return nil, &Error{l.num, "unexpected content"}
+	}
+	return node, nil
+}
+
+// hasContent reports whether any non-blank line was read so far
+func (p *parser) hasContent() bool {
+	for _, l := range p.lines {
+		if l.text != "" {
+			return true
+		}
+	}
+	return false
+}
+
+// checkTab reports a tab in the indentation of a line that is part of the
+// structure
+func checkTab(l *line) error {
+	if l.tab {
+		return &Error{l.num, "tabs are not allowed for indentation"}
+	}
+	return nil
+}
+
+// next skips blank lines and returns the next line, or nil at the end
+func (p *parser) next() *line {
+	for p.pos < len(p.lines) && p.lines[p.pos].text == "" {
+		p.pos++
+	}
+	if p.pos == len(p.lines) {
+		return nil
+	}
+	return &p.lines[p.pos]
+}
+
+// parseBlock parses the block node starting at the next line, which must be
+// indented at least minIndent. It is null if there is no such line.
+func (p *parser) parseBlock(minIndent int) (*Node, error) {
+	l := p.next()
+	if l == nil || l.indent < minIndent {
+		line := 1
+		if l != nil {
+			line = l.num
+		}
+		return &Node{Kind: Null, Line: line}, nil
+	}
+	if err := checkTab(l); err != nil {
+		return nil, err
+	}
+	switch {
+	case isExplicitKey(l.text):
+		return nil, &UnsupportedError{l.num, "explicit keys (?)"}
+	case isSeqItem(l.text):
+		return p.parseSequence(l.indent)
+	case isKey(l.text):
+		return p.parseMapping(l.indent)
+	default:
+		p.pos++
+		return p.parseInline(l.text, l, l.indent-1)
+	}
+}
+
+// parseMapping parses a block mapping whose keys are at indent
+func (p *parser) parseMapping(indent int) (*Node, error) {
+	node := &Node{Kind: Mapping, Line: p.next().num}
+	for {
+		l := p.next()
+		if l == nil || l.indent < indent {
+			return node, nil
+		}
+		if err := checkTab(l); err != nil {
+			return nil, err
+		}
+		if l.indent > indent {
+			return nil, &Error{l.num, "unexpected indentation"}
+		}
+		key, rest, ok := splitKey(l.text)
+		if !ok {
+			switch {
+			case isSeqItem(l.text):
+				return nil, &Error{l.num, "sequence item where a mapping key was expected"}
+			case isExplicitKey(l.text):
+				return nil, &UnsupportedError{l.num, "explicit keys (?)"}
+			case l.text[0] == '&' || l.text[0] == '*':
+				return nil, &UnsupportedError{l.num, "anchors and aliases on mapping keys"}
+			case l.text[0] == '!':
+				return nil, &UnsupportedError{l.num, "tags"}
+			}
+			return nil, &Error{l.num, "expected a mapping key"}
+		}
+		p.pos++
+
+		anchor, rest, err := splitAnchor(rest, l.num)
+		if err != nil {
+			return nil, err
+		}
+		var value *Node
+		switch {
+		case rest != "":
+			value, err = p.parseInline(rest, l, indent)
+		default:
+			// The value is a nested block, or a sequence at the same indentation
+			next := p.next()
+			if next != nil && next.indent == indent && isSeqItem(next.text) {
+				value, err = p.parseSequence(indent)
+			} else {
+				value, err = p.parseBlock(indent + 1)
+				if err == nil && value.Kind == Null {
+					value.Line = l.num
+				}
+			}
+		}
+		if err != nil {
+			return nil, err
+		}
+		p.define(anchor, value)
+		node.Pairs = append(node.Pairs, Pair{Key: key, Line: l.num, Value: value})
+	}
+}
+
+// parseSequence parses a block sequence whose dashes are at indent
+func (p *parser) parseSequence(indent int) (*Node, error) {
+	node := &Node{Kind: Sequence, Line: p.next().num}
+	for {
+		l := p.next()
+		if l == nil || l.indent < indent || l.indent == indent && !isSeqItem(l.text) {
+			return node, nil
+		}
+		if err := checkTab(l); err != nil {
+			return nil, err
+		}
+		if l.indent > indent {
+			return nil, &Error{l.num, "unexpected indentation"}
+		}
+
+		anchor, rest, err := splitAnchor(strings.TrimLeft(l.text[1:], " "), l.num)
+		if err != nil {
+			return nil, err
+		}
+		var item *Node
+		switch {
+		case anchor != "" && isKey(rest):
+			// "- &a key: value" anchors the key, not the mapping
+			return nil, &UnsupportedError{l.num, "anchors and aliases on mapping keys"}
+		case rest == "":
+			p.pos++
+			item, err = p.parseBlock(indent + 1)
+			if err == nil && item.Kind == Null {
+				item.Line = l.num
+			}
+		case isSeqItem(rest) || isKey(rest):
+			// "- key: value" or "- - item": the rest of the line starts a
+			// nested block indented to where its text begins
+			l.indent += len(l.text) - len(rest)
+			l.text = rest
+			item, err = p.parseBlock(l.indent)
+		default:
+			p.pos++
+			item, err = p.parseInline(rest, l, indent)
+		}
+		if err != nil {
+			return nil, err
+		}
+		p.define(anchor, item)
+		node.Items = append(node.Items, item)
+	}
+}
+
+// define records the node an anchor names; later ones replace earlier ones
+func (p *parser) define(anchor string, n *Node) {
+	if anchor != "" {
+		p.anchors[anchor] = n
+	}
+}
+
+// parseInline parses a value that starts on line l after a key or dash.
+// parentIndent is the indentation of that key or dash; continuation lines
+// must be indented further.
+func (p *parser) parseInline(text string, l *line, parentIndent int) (*Node, error) {
+	switch {
+	case text[0] == '|' || text[0] == '>':
+		return p.parseBlockScalar(text, l, parentIndent)
+	case text[0] == '[' || text[0] == '{':
+		// Flow collections continue on the following lines until their
+		// brackets balance, whatever the indentation of those lines, so the
+		// closing bracket may be back at the key's indentation
+		f := &flow{lines: []lineStart{{0, l.num}}, anchors: p.anchors}
+		for depth(text) > 0 {
+			next := p.next()
+			if next == nil {
+				return nil, &Error{l.num, "unclosed " + string(text[0])}
+			}
+			f.lines = append(f.lines, lineStart{len(text) + 1, next.num})
+			text += " " + next.text
+			p.pos++
+		}
+		f.text = text
+		node, err := f.parse()
+		if err != nil {
+			return nil, err
+		}
+		if f.skipSpace(); f.pos < len(f.text) {
+			return nil, &Error{f.lineAt(f.pos), "unexpected text after " + string(text[0])}
+		}
+		return node, nil
+	case text[0] == '&':
+		anchor, rest, err := splitAnchor(text, l.num)
+		if err != nil {
+			return nil, err
+		}
+		var node *Node
+		switch {
+		case isKey(rest):
+			return nil, &UnsupportedError{l.num, "anchors and aliases on mapping keys"}
+		case rest == "":
+			// The anchored value is the block that follows
+			node, err = p.parseBlock(parentIndent + 1)
+		default:
+			node, err = p.parseInline(rest, l, parentIndent)
+		}
+		if err != nil {
+			return nil, err
+		}
+		p.define(anchor, node)
+		return node, nil
+	case text[0] == '*':
+		name := text[1:]
+		if strings.ContainsAny(name, " \t") {
+			return nil, &Error{l.num, "unexpected text after alias"}
+		}
+		return p.alias(name, l.num)
+	case text[0] == '!':
+		return nil, &UnsupportedError{l.num, "tags"}
+	case text[0] == '"' || text[0] == '\'':
+		return p.parseQuoted(text, l, parentIndent)
+	}
+
+	// A plain scalar, folded over any more-indented lines that follow
+	value := text
+	var lines []lineStart
+	for {
+		next := p.next()
+		if next == nil || next.indent <= parentIndent || isKey(next.text) || isSeqItem(next.text) {
+			break
+		}
+		lines = append(lines, lineStart{len(value) + 1, next.num})
+		value += " " + next.text
+		p.pos++
+	}
+	node := plain(value, l.num)
+	node.lines = lines
+	return node, nil
+}
+
+// alias returns the node an anchor named, as of line
+func (p *parser) alias(name string, line int) (*Node, error) {
+	if name == "" {
+		return nil, &Error{line, "missing alias name after *"}
+	}
+	n, ok := p.anchors[name]
+	if !ok {
+		return nil, &Error{line, fmt.Sprintf("unknown alias *%s", name)}
+	}
+	return n, nil
+}
+
+// parseQuoted parses a quoted scalar that starts on line l. It may continue
+// on lines indented further than parentIndent, where line breaks fold into
+// spaces and blank lines into newlines.
+func (p *parser) parseQuoted(text string, l *line, parentIndent int) (*Node, error) {
+	value, end, err := quoted(text, 0, l.num)
+	var starts []lineStart // offsets in text
+	for err != nil && end == len(text) && p.pos < len(p.lines) {
+		next := p.lines[p.pos]
+		cont := strings.TrimSpace(next.raw)
+		if cont != "" && next.indent <= parentIndent {
+			break
+		}
+		// Comments can't start inside a string, so take the raw line
+		switch {
+		case cont == "":
+			text += "\n"
+		case strings.HasSuffix(text, "\n"):
+			starts = append(starts, lineStart{len(text), next.num})
+			text += cont
+		default:
+			starts = append(starts, lineStart{len(text) + 1, next.num})
+			text += " " + cont
+		}
+		p.pos++
+		value, end, err = quoted(text, 0, l.num)
+	}
+	if err != nil {
+		return nil, err
+	}
+	if strings.TrimSpace(stripComment(text[end:])) != "" {
+		return nil, &Error{l.num, "unexpected text after quoted string"}
+	}
+
+	node := &Node{Kind: Scalar, Line: l.num, Value: value, Quoted: true}
+	for _, s := range starts {
+		// Escapes make the value shorter than the text, so find where each
+		// line starts in it by unquoting the text before that line
+		if prefix, _, err := quoted(text[:s.offset]+text[:1], 0, l.num); err == nil {
+			node.lines = append(node.lines, lineStart{len(prefix), s.line})
+		}
+	}
+	return node, nil
+}
+
+// parseBlockScalar parses a literal (|) or folded (>) block scalar
+func (p *parser) parseBlockScalar(header string, l *line, parentIndent int) (*Node, error) {
+	literal := header[0] == '|'
+	chomp := byte(0)
+	contentIndent := 0
+	for _, c := range header[1:] {
+		switch {
+		case c == '-' || c == '+':
+			chomp = byte(c)
+		case c >= '1' && c <= '9':
+			contentIndent = parentIndent + 1 + int(c-'1')
+			if parentIndent < 0 {
+				contentIndent = int(c - '0')
+			}
+		default:
+			return nil, &Error{l.num, fmt.Sprintf("invalid block scalar header %q", header)}
+		}
+	}
+
+	// Gather the raw lines more indented than the parent, plus blank lines
+	var lines []string
+	var nums []int // the line number of each of lines
+	for p.pos < len(p.lines) {
+		next := p.lines[p.pos]
+		blank := strings.TrimSpace(next.raw) == ""
+		if !blank && next.indent <= parentIndent {
+			break
+		}
+		if !blank && contentIndent == 0 {
+			contentIndent = next.indent
+		}
+		if !blank && next.indent < contentIndent {
+			break
+		}
+		if blank {
+			lines = append(lines, "")
+		} else {
+			lines = append(lines, next.raw[contentIndent:])
+		}
+		nums = append(nums, next.num)
+		p.pos++
+	}
+	// Trailing blank lines belong to the block only for chomping
+	trailing := 0
+	for len(lines) > 0 && lines[len(lines)-1] == "" {
+		lines = lines[:len(lines)-1]
+		trailing++
+	}
+
+	node := &Node{Kind: Scalar, Line: l.num, Quoted: true}
+	var b strings.Builder
+	for i, s := range lines {
+		if i > 0 {
+			prev := lines[i-1]
+			// In folded scalars, blank lines become newlines and the break
+			// after one is dropped
+			switch {
+			case literal || s == "":
+				b.WriteString("\n")
+			case prev == "":
+			case strings.HasPrefix(s, " ") || strings.HasPrefix(prev, " "):
+				b.WriteString("\n")
+			default:
+				b.WriteString(" ")
+			}
+		}
+		node.lines = append(node.lines, lineStart{b.Len(), nums[i]})
+		b.WriteString(s)
+	}
+	value := b.String()
+
+	switch {
+	case len(lines) == 0:
+	case chomp == '-':
+	case chomp == '+':
+		value += strings.Repeat("\n", trailing+1)
+	default:
+		value += "\n"
+	}
+	node.Value = value
+	return node, nil
+}
+
+// flow parses a flow collection such as [a, b] or {a: 1}
+type flow struct {
+	text    string
+	pos     int
+	lines   []lineStart      // where each line joined into text starts
+	anchors map[string]*Node // shared with the parser
+}
+
+// lineAt returns the line the text at pos comes from
+func (f *flow) lineAt(pos int) int {
+	line := f.lines[0].line
+	for _, s := range f.lines {
+		if s.offset > pos {
+			break
+		}
+		line = s.line
+	}
+	return line
+}
+
+func (f *flow) skipSpace() {
+	for f.pos < len(f.text) && f.text[f.pos] == ' ' {
+		f.pos++
+	}
+}
+
+func (f *flow) errorf(format string, args ...any) error {
+	return &Error{f.lineAt(f.pos), fmt.Sprintf(format, args...)}
+}
+
+// parse parses the value at the current position
+func (f *flow) parse() (*Node, error) {
+	f.skipSpace()
+	if f.pos == len(f.text) {
+		return nil, f.errorf("unexpected end of flow collection")
+	}
+	line := f.lineAt(f.pos)
+	switch c := f.text[f.pos]; c {
+	case '[':
+		f.pos++
+		node := &Node{Kind: Sequence, Line: line}
+		for {
+			f.skipSpace()
+			if f.pos < len(f.text) && f.text[f.pos] == ']' {
+				f.pos++
+				return node, nil
+			}
+			item, err := f.parse()
+			if err != nil {
+				return nil, err
+			}
+			if f.pairFollows(item) {
+				// [key: value] is a mapping with a single pair
+				f.pos++
+				value, err := f.parse()
+				if err != nil {
+					return nil, err
+				}
+				item = &Node{Kind: Mapping, Line: item.Line, Pairs: []Pair{{Key: item.Value, Line: item.Line, Value: value}}}
+			}
+			node.Items = append(node.Items, item)
+			if err := f.separator(']'); err != nil {
+				return nil, err
+			}
+		}
+	case '{':
+		f.pos++
+		node := &Node{Kind: Mapping, Line: line}
+		for {
+			f.skipSpace()
+			if f.pos < len(f.text) && f.text[f.pos] == '}' {
+				f.pos++
+				return node, nil
+			}
+			key, err := f.parse()
+			if err != nil {
+				return nil, err
+			}
+			if key.Kind == Mapping || key.Kind == Sequence {
+				return nil, f.errorf("mapping keys must be scalars")
+			}
+			f.skipSpace()
+			value := &Node{Kind: Null, Line: key.Line}
+			if f.pos < len(f.text) && f.text[f.pos] == ':' {
+				f.pos++
+				if value, err = f.parse(); err != nil {
+					return nil, err
+				}
+			}
+			node.Pairs = append(node.Pairs, Pair{Key: key.Value, Line: key.Line, Value: value})
+			if err := f.separator('}'); err != nil {
+				return nil, err
+			}
+		}
+	case '&':
+		start := f.pos + 1
+		f.scanName()
+		anchor := f.text[start:f.pos]
+		if anchor == "" {
+			return nil, f.errorf("missing anchor name after &")
+		}
+		value, err := f.parse()
+		if err != nil {
+			return nil, err
+		}
+		f.anchors[anchor] = value
+		return value, nil
+	case '*':
+		start := f.pos + 1
+		f.scanName()
+		n, ok := f.anchors[f.text[start:f.pos]]
+		switch {
+		case start == f.pos:
+			return nil, f.errorf("missing alias name after *")
+		case !ok:
+			return nil, f.errorf("unknown alias *%s", f.text[start:f.pos])
+		}
+		return n, nil
+	case '!':
+		return nil, &UnsupportedError{line, "tags"}
+	case '"', '\'':
+		value, end, err := quoted(f.text, f.pos, line)
+		if err != nil {
+			return nil, err
+		}
+		f.pos = end
+		return &Node{Kind: Scalar, Line: line, Value: value, Quoted: true}, nil
+	case ']', '}', ',':
+		return &Node{Kind: Null, Line: line}, nil
+	default:
+		// A plain scalar ends at a flow indicator or ": "
+		start := f.pos
+		for f.pos < len(f.text) {
+			c := f.text[f.pos]
+			if c == ',' || c == ']' || c == '}' || c == '[' || c == '{' {
+				break
+			}
+			if c == ':' && (f.pos+1 == len(f.text) || strings.ContainsRune(" ,]}", rune(f.text[f.pos+1]))) {
+				break
+			}
+			f.pos++
+		}
+		return plain(strings.TrimSpace(f.text[start:f.pos]), line), nil
+	}
+}
+
+// scanName moves past the & or * at the current position and the anchor
+// name after it, which ends at a space or flow indicator
+func (f *flow) scanName() {
+	f.pos++
+	for f.pos < len(f.text) && !strings.ContainsRune(" ,[]{}", rune(f.text[f.pos])) {
+		f.pos++
+	}
+}
+
+// pairFollows reports whether item is followed by a ":" that makes it the
+// key of a single-pair mapping, as in [key: value]
+func (f *flow) pairFollows(item *Node) bool {
+	if item.Kind != Scalar && item.Kind != Null {
+		return false
+	}
+	f.skipSpace()
+	if f.pos == len(f.text) || f.text[f.pos] != ':' {
+		return false
+	}
+	// "a":b is a pair, but a:b is a plain scalar
+	return item.Quoted || f.pos+1 == len(f.text) || strings.ContainsRune(" ,]}", rune(f.text[f.pos+1]))
+}
+
+// separator consumes a comma, or checks for the closing bracket
+func (f *flow) separator(closing byte) error {
+	f.skipSpace()
+	if f.pos == len(f.text) {
+		return f.errorf("missing %c", closing)
+	}
+	switch f.text[f.pos] {
+	case ',':
+		f.pos++
+		return nil
+	case closing:
+		return nil
+	default:
+		return f.errorf("expected , or %c", closing)
+	}
+}
+
+// plain returns the node for a plain scalar
+func plain(value string, line int) *Node {
+	switch value {
+	case "", "~", "null", "Null", "NULL":
+		return &Node{Kind: Null, Line: line}
+	}
+	return &Node{Kind: Scalar, Line: line, Value: value}
+}
+
+// quoted parses the quoted string starting at text[start] and returns its
+// value and the index just past the closing quote. If the string isn't
+// closed the index is len(text), so callers can add the next line and retry.
+func quoted(text string, start, lineNum int) (string, int, error) {
+	q := text[start]
+	var b strings.Builder
+	for i := start + 1; i < len(text); i++ {
+		c := text[i]
+		switch {
+		case c == q && q == '\'' && i+1 < len(text) && text[i+1] == '\'':
+			b.WriteByte('\'')
+			i++
+		case c == q:
+			return b.String(), i + 1, nil
+		case c == '\\' && q == '"' && i+1 < len(text):
+			i++
+			switch e := text[i]; e {
+			case 'n':
+				b.WriteByte('\n')
+			case 't':
+				b.WriteByte('\t')
+			case 'r':
+				b.WriteByte('\r')
+			case '0':
+				b.WriteByte(0)
+			case 'u', 'x':
+				size := 4
+				if e == 'x' {
+					size = 2
+				}
+				if i+size >= len(text) {
+					return "", 0, &Error{lineNum, "invalid escape in string"}
+				}
+				r, err := strconv.ParseUint(text[i+1:i+1+size], 16, 32)
+				if err != nil {
+					return "", 0, &Error{lineNum, "invalid escape in string"}
+				}
+				b.WriteRune(rune(r))
+				i += size
+			default:
+				b.WriteByte(e)
+			}
+		default:
+			b.WriteByte(c)
+		}
+	}
+	return "", len(text), &Error{lineNum, "unterminated string"}
+}
+
+// stripComment removes a trailing comment: a # at the start of the text or
+// after a space, outside quotes
+func stripComment(text string) string {
+	var quote byte
+	for i := 0; i < len(text); i++ {
+		c := text[i]
+		switch {
+		case quote == '"' && c == '\\':
+			i++
+		case quote != 0:
+			if c == quote {
+				quote = 0
+			}
+		case (c == '"' || c == '\'') && (i == 0 || strings.IndexByte(" [{,:-", text[i-1]) >= 0):
+			quote = c
+		case c == '#' && (i == 0 || text[i-1] == ' ' || text[i-1] == '\t'):
+			return text[:i]
+		}
+	}
+	return text
+}
+
+// depth returns how many flow brackets are still open at the end of text
+func depth(text string) int {
+	d := 0
+	var quote byte
+	for i := 0; i < len(text); i++ {
+		c := text[i]
+		switch {
+		case quote == '"' && c == '\\':
+			i++
+		case quote != 0:
+			if c == quote {
+				quote = 0
+			}
+		case c == '"' || c == '\'':
+			quote = c
+		case c == '[' || c == '{':
+			d++
+		case c == ']' || c == '}':
+			d--
+		}
+	}
+	return d
+}
+
+// splitAnchor splits "&name rest" into the anchor name and the rest. The
+// name is "" if text doesn't start with an anchor.
+func splitAnchor(text string, line int) (name, rest string, err error) {
+	if !strings.HasPrefix(text, "&") {
+		return "", text, nil
+	}
+	name, rest, _ = strings.Cut(text[1:], " ")
+	if name == "" {
+		return "", "", &Error{line, "missing anchor name after &"}
+	}
+	return name, strings.TrimLeft(rest, " "), nil
+}
+
+// isExplicitKey reports whether text starts a "? key" mapping entry
+func isExplicitKey(text string) bool {
+	return text == "?" || strings.HasPrefix(text, "? ")
+}
+
+// isSeqItem reports whether text is a block sequence item
+func isSeqItem(text string) bool {
+	return text == "-" || strings.HasPrefix(text, "- ")
+}
+
+// isKey reports whether text starts with a mapping key
+func isKey(text string) bool {
+	_, _, ok := splitKey(text)
+	return ok
+}
+
+// splitKey splits "key: value" into its key and the rest of the line
+func splitKey(text string) (key, rest string, ok bool) {
+	if text == "" || strings.ContainsRune("[{|>&*!", rune(text[0])) || isSeqItem(text) {
+		return "", "", false
+	}
+	if text[0] == '"' || text[0] == '\'' {
+		value, end, err := quoted(text, 0, 0)
+		if err != nil {
+			return "", "", false
+		}
+		after := strings.TrimLeft(text[end:], " ")
+		if after != ":" && !strings.HasPrefix(after, ": ") {
+			return "", "", false
+		}
+		return value, strings.TrimSpace(after[1:]), true
+	}
+
+	for i := 0; i < len(text); i++ {
+		if text[i] == ':' && (i+1 == len(text) || text[i+1] == ' ') {
+			return strings.TrimRight(text[:i], " "), strings.TrimSpace(text[i+1:]), true
+		}
+	}
+	return "", "", false
+}
@@ -0,0 +1,262 @@
+package yaml
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+)
+
+// dump writes a node compactly: mappings as {k: v}, sequences as [a, b],
+// quoted scalars in quotes, and nulls as ~
+func dump(n *Node) string {
+	switch n.Kind {
+	case Mapping:
+		pairs := make([]string, len(n.Pairs))
+		for i, p := range n.Pairs {
+			pairs[i] = p.Key + ": " + dump(p.Value)
+		}
+		return "{" + strings.Join(pairs, ", ") + "}"
+	case Sequence:
+		items := make([]string, len(n.Items))
+		for i, item := range n.Items {
+			items[i] = dump(item)
+		}
+		return "[" + strings.Join(items, ", ") + "]"
+	case Scalar:
+		if n.Quoted {
+			return fmt.Sprintf("%q", n.Value)
+		}
+		return n.Value
+	default:
+		return "~"
+	}
+}
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		name string
+		in   string
+		want string
+	}{
+		{"mapping", "a: 1\nb: two\n", "{a: 1, b: two}"},
+		{"nested", "a:\n  b:\n    c: d\n", "{a: {b: {c: d}}}"},
+		{"null values", "a:\nb: ~\nc: null\n", "{a: ~, b: ~, c: ~}"},
+		{"sequence", "- a\n- b\n", "[a, b]"},
+		{"sequence at key indent", "a:\n- x\n- y\nb: z\n", "{a: [x, y], b: z}"},
+		{"sequence of mappings", "- a: 1\n  b: 2\n- c: 3\n", "[{a: 1, b: 2}, {c: 3}]"},
+		{"comments", "# top\na: 1 # one\nb: 'x # y'\n", `{a: 1, b: "x # y"}`},
+		{"document markers", "---\na: 1\n...\n", "{a: 1}"},
+		{"quoted", `a: "x\ty"` + "\nb: 'it''s'\n", `{a: "x\ty", b: "it's"}`},
+		{"quoted key", `"on": push` + "\n", "{on: push}"},
+		{"plain folded", "a: one\n  two\n  three\nb: c\n", "{a: one two three, b: c}"},
+		{"quoted over lines", "a: \"one\n  two # not a comment\n\n  three\" # comment\nb: c\n", `{a: "one two # not a comment\nthree", b: c}`},
+		{"literal", "a: |\n  x\n   y\n\nb: c\n", `{a: "x\n y\n", b: c}`},
+		{"literal strip", "a: |-\n  x\n  y\n", `{a: "x\ny"}`},
+		{"literal keep", "a: |+\n  x\n\n", `{a: "x\n\n"}`},
+		{"folded", "a: >\n  one\n  two\n\n  three\n", `{a: "one two\nthree\n"}`},
+		{"folded more indented", "a: >-\n  one\n    two\n  three\n", `{a: "one\n  two\nthree"}`},
+		{"flow sequence", "a: [x, 'y', [1, 2], {k: v}]\n", `{a: [x, "y", [1, 2], {k: v}]}`},
+		{"flow mapping", "a: {x: 1, y: , z}\n", "{a: {x: 1, y: ~, z: ~}}"},
+		{"flow over lines", "a: [x,\n  y]\n", "{a: [x, y]}"},
+		{"flow closed at key indent", "branches: [\n  main,\n  dev,\n]\nwith: {\n  a: 1\n}\nb: c\n", "{branches: [main, dev], with: {a: 1}, b: c}"},
+		{"flow closed at item indent", "- [\n  x\n]\n- y\n", "[[x], y]"},
+		{"flow sequence pair", `on: {schedule: [cron: "40 1 * * *"]}` + "\n", `{on: {schedule: [{cron: "40 1 * * *"}]}}`},
+		{"flow sequence pairs", `a: [x: 1, "y":2, z, u:v]` + "\n", `{a: [{x: 1}, {y: 2}, z, u:v]}`},
+		{"flow url", "a: [http://example.com]\n", "{a: [http://example.com]}"},
+		{"anchor scalar", "a: &x one\nb: *x\n", "{a: one, b: one}"},
+		{"anchor block", "a: &x\n  k: v\nb: *x\n", "{a: {k: v}, b: {k: v}}"},
+		{"anchor sequence item", "- &s\n  uses: c\n- *s\n", "[{uses: c}, {uses: c}]"},
+		{"anchor flow", "a: &b [x, y]\nc: [*b, &d z, *d]\n", "{a: [x, y], c: [[x, y], z, z]}"},
+		{"anchor redefined", "a: &x 1\nb: *x\nc: &x 2\nd: *x\n", "{a: 1, b: 1, c: 2, d: 2}"},
+		{"anchor on own line", "a:\n  &x\n  k: v\nb: *x\n", "{a: {k: v}, b: {k: v}}"},
+		{"empty", "", "~"},
+		{"scalar document", "hello\n", "hello"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			n, err := Parse([]byte(tt.in))
+			if err != nil {
+				t.Fatalf("Parse: %v", err)
+			}
+			if got := dump(n); got != tt.want {
+				t.Errorf("got  %s\nwant %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseLines(t *testing.T) {
+	n, err := Parse([]byte("# comment\na: 1\nb:\n  - x\n  -\n    c: d\ne:\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	b := n.Get("b")
+	tests := []struct {
+		name string
+		got  int
+		want int
+	}{
+		{"mapping", n.Line, 2},
+		{"pair", n.Pairs[1].Line, 3},
+		{"scalar", n.Get("a").Line, 2},
+		{"sequence", b.Line, 4},
+		{"item", b.Items[0].Line, 4},
+		{"nested mapping", b.Items[1].Line, 6},
+		{"null value", n.Get("e").Line, 7},
+	}
+	for _, tt := range tests {
+		if tt.got != tt.want {
+			t.Errorf("%s: line %d, want %d", tt.name, tt.got, tt.want)
+		}
+	}
+}
+
+func TestLineAt(t *testing.T) {
+	tests := []struct {
+		name string
+		in   string
+		at   string // the text whose line is wanted
+		want int
+	}{
+		{"single line", "a: x y z\n", "z", 1},
+		{"literal first line", "a: |\n  x\n  y\n", "x", 2},
+		{"literal", "a: |\n  x\n\n  y\n", "y", 4},
+		{"folded", "a: >-\n  one\n  two\n  three\n", "three", 4},
+		{"folded after blank", "a: >\n  one\n\n  two\n", "two", 4},
+		{"folded more indented", "a: >\n  one\n    two\n", "two", 3},
+		{"plain", "a: one\n  two\n  three\n", "three", 3},
+		{"plain first line", "a: one\n  two\n", "one", 1},
+		{"quoted", "a: \"one\n  two\"\n", "two", 2},
+		{"quoted with escapes", "a: \"\\t\\u00e9\n  two\n\n  three\"\n", "three", 4},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			n, err := Parse([]byte(tt.in))
+			if err != nil {
+				t.Fatal(err)
+			}
+			value := n.Get("a")
+			offset := strings.Index(value.Value, tt.at)
+			if offset < 0 {
+				t.Fatalf("%q not in %q", tt.at, value.Value)
+			}
+			if got := value.LineAt(offset); got != tt.want {
+				t.Errorf("LineAt(%d) = %d, want %d", offset, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestFlowLines(t *testing.T) {
+	n, err := Parse([]byte("a: [x,\n  y, {k:\n    v}]\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	items := n.Get("a").Items
+	if got := items[0].Line; got != 1 {
+		t.Errorf("x is on line %d, want 1", got)
+	}
+	if got := items[1].Line; got != 2 {
+		t.Errorf("y is on line %d, want 2", got)
+	}
+	if got := items[2].Get("k").Line; got != 3 {
+		t.Errorf("v is on line %d, want 3", got)
+	}
+
+	_, err = Parse([]byte("a: [x,\n  y z: w: v]\n"))
+	var e *Error
+	if !errors.As(err, &e) || e.Line != 2 {
+		t.Errorf("got %v, want an error on line 2", err)
+	}
+}
+
+func TestParseErrors(t *testing.T) {
+	tests := []struct {
+		name string
+		in   string
+		line int
+		msg  string
+	}{
+		{"bad indentation", "a: 1\n  b: 2\n", 2, "unexpected indentation"},
+		{"tab", "a:\n\tb: 1\n", 2, "tabs are not allowed for indentation"},
+		{"item in mapping", "a: 1\n- b\n", 2, "sequence item where a mapping key was expected"},
+		{"not a key", "a: 1\nb\n", 2, "expected a mapping key"},
+		{"unclosed flow", "a: [x,\nb: 1\n", 1, "unclosed ["},
+		{"flow trailing text", "a: [x] y\n", 1, "unexpected text after ["},
+		{"flow separator", "a: ['x' y]\n", 1, "expected , or ]"},
+		{"unterminated string", "a: \"x\nb: 1\n", 1, "unterminated string"},
+		{"text after string", "a: \"x\" y\n", 1, "unexpected text after quoted string"},
+		{"bad escape", `a: "\uZZZZ"` + "\n", 1, "invalid escape in string"},
+		{"block scalar header", "a: |x\n  y\n", 1, `invalid block scalar header "|x"`},
+		{"unknown alias", "a: *x\n", 1, "unknown alias *x"},
+		{"unknown flow alias", "a: [*x]\n", 1, "unknown alias *x"},
+		{"alias before anchor", "a: *x\nb: &x 1\n", 1, "unknown alias *x"},
+		{"empty anchor", "a: & x\n", 1, "missing anchor name after &"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := Parse([]byte(tt.in))
+			var e *Error
+			if !errors.As(err, &e) {
+				t.Fatalf("got %v, want a syntax error", err)
+			}
+			if e.Line != tt.line || e.Msg != tt.msg {
+				t.Errorf("got line %d: %s, want line %d: %s", e.Line, e.Msg, tt.line, tt.msg)
+			}
+		})
+	}
+}
+
+func TestParseUnsupported(t *testing.T) {
+	tests := []struct {
+		name    string
+		in      string
+		line    int
+		feature string
+	}{
+		{"tag", "a: !!str 1\n", 1, "tags"},
+		{"flow tag", "a: [!x 1]\n", 1, "tags"},
+		{"tagged key", "!x a: 1\n", 1, "tags"},
+		{"explicit key", "? a\n: 1\n", 1, "explicit keys (?)"},
+		{"explicit key in mapping", "a: 1\n? b\n", 2, "explicit keys (?)"},
+		{"anchored key", "a: 1\n&k b: 2\n", 2, "anchors and aliases on mapping keys"},
+		{"alias key", "a: 1\n*k : 2\n", 2, "anchors and aliases on mapping keys"},
+		{"anchored key in sequence", "- &k a: 1\n", 1, "anchors and aliases on mapping keys"},
+		{"documents", "a: 1\n---\nb: 2\n", 2, "multiple documents"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := Parse([]byte(tt.in))
+			var e *UnsupportedError
+			if !errors.As(err, &e) {
+				t.Fatalf("got %v, want an unsupported feature", err)
+			}
+			if e.Line != tt.line || e.Feature != tt.feature {
+				t.Errorf("got line %d: %s, want line %d: %s", e.Line, e.Feature, tt.line, tt.feature)
+			}
+		})
+	}
+}
+
+func TestBool(t *testing.T) {
+	tests := []struct {
+		in        string
+		value, ok bool
+	}{
+		{"a: true", true, true},
+		{"a: False", false, true},
+		{"a: 'true'", false, false},
+		{"a: yes", false, false},
+		{"a: [x]", false, false},
+	}
+	for _, tt := range tests {
+		n, err := Parse([]byte(tt.in))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if value, ok := n.Get("a").Bool(); value != tt.value || ok != tt.ok {
+			t.Errorf("%s: got %v, %v; want %v, %v", tt.in, value, ok, tt.value, tt.ok)
+		}
+	}
+}